@@ -0,0 +1,206 @@
+package model
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+//The testdata payloads were written by clients built from the proto2 version of metrics.proto
+
+func readTestdata(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal("Error reading testdata: " + err.Error())
+	}
+	return data
+}
+
+func checkUploadRequest(t *testing.T, req *ClientEventUploadRequest) {
+	if req.GetRequestId() != "9f2c1e" || req.GetDeviceType() != "android" || len(req.GetEvents()) != 2 {
+		t.Fatalf("Unexpected upload request: %v", req)
+	}
+	event := req.GetEvents()[0]
+	if event.GetEventId() != "evt-1" || event.GetEventType() != ClientEventType_USER_REGISTERED || event.GetTimestamp() != 1452548890 || event.GetData() != "signup via email" {
+		t.Errorf("Unexpected event: %v", event)
+	}
+	if len(event.GetKvPair()) != 1 || event.GetKvPair()[0].GetKey() != "country" || event.GetKvPair()[0].GetValue() != "NZ" {
+		t.Errorf("Unexpected kv pairs: %v", event.GetKvPair())
+	}
+	if event.GetKvPair()[0].GetTypedValue() != nil {
+		t.Errorf("String pairs should not get a typed value: %v", event.GetKvPair()[0])
+	}
+	if req.GetEvents()[1].GetEventType() != ClientEventType_UNKNOWN {
+		t.Errorf("Unexpected event type: %v", req.GetEvents()[1].GetEventType())
+	}
+}
+
+func TestCompat_ProtobufUploadRequest(t *testing.T) {
+	data := readTestdata(t, "upload_request.pb")
+	req := new(ClientEventUploadRequest)
+	if err := proto.Unmarshal(data, req); err != nil {
+		t.Fatal("Error unmarshalling proto2 payload: " + err.Error())
+	}
+	checkUploadRequest(t, req)
+
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		t.Fatal("Error marshalling: " + err.Error())
+	}
+	if !bytes.Equal(data, encoded) {
+		t.Error("Encoding should not differ from the proto2 one")
+	}
+}
+
+func TestCompat_JSONUploadRequest(t *testing.T) {
+	req := new(ClientEventUploadRequest)
+	if err := UnmarshalJSON(readTestdata(t, "upload_request.json"), req); err != nil {
+		t.Fatal("Error unmarshalling proto2 JSON: " + err.Error())
+	}
+	checkUploadRequest(t, req)
+
+	named := new(ClientEventUploadRequest)
+	err := UnmarshalJSON([]byte(`{"request_id": "9f2c1e", "events": [{"event_id": "evt-1", "event_type": "USER_REGISTERED", "timestamp": "1452548890"}], "unknown": 1}`), named)
+	if err != nil {
+		t.Fatal("Error unmarshalling proto3 JSON: " + err.Error())
+	}
+	if named.GetEvents()[0].GetEventType() != ClientEventType_USER_REGISTERED || named.GetEvents()[0].GetTimestamp() != 1452548890 {
+		t.Errorf("Unexpected event: %v", named.GetEvents()[0])
+	}
+}
+
+func TestCompat_JSONWebhookSubscription(t *testing.T) {
+	subscription := new(WebhookSubscription)
+	if err := UnmarshalJSON(readTestdata(t, "webhook_subscription.json"), subscription); err != nil {
+		t.Fatal("Error unmarshalling proto2 JSON: " + err.Error())
+	}
+	if subscription.GetUrl() != "https://example.com/hook" || subscription.GetCreatedAt() != 1452548900 {
+		t.Errorf("Unexpected subscription: %v", subscription)
+	}
+	filter := subscription.GetFilter()
+	if len(filter.GetEventTypes()) != 1 || filter.GetEventTypes()[0] != ClientEventType_USER_REGISTERED || filter.GetDeviceTypes()[0] != "ios" {
+		t.Errorf("Unexpected filter: %v", filter)
+	}
+}
+
+//proto2 clients fail to decode messages missing a required field, so zero values they require must still be sent
+func TestCompat_ZeroValuesOfRequiredFields(t *testing.T) {
+	checks := []struct {
+		message proto.Message
+		fields  int
+	}{
+		{&UploadEventsResponse{Events: proto.Int32(0), Queued: proto.Bool(false)}, 2},
+		{&UploadCheckpoint{ProducerId: "producer", CommittedOffset: proto.Uint64(0), Events: proto.Int32(0), Skipped: proto.Int32(0)}, 4},
+		{&EventCount{Count: proto.Int64(0)}, 1},
+	}
+	for _, check := range checks {
+		data, err := proto.Marshal(check.message)
+		if err != nil {
+			t.Fatal("Error marshalling: " + err.Error())
+		}
+		fields := 0
+		for len(data) > 0 {
+			_, _, n := protowire.ConsumeField(data)
+			if n < 0 {
+				t.Fatal("Error reading field: " + protowire.ParseError(n).Error())
+			}
+			data = data[n:]
+			fields++
+		}
+		if fields != check.fields {
+			t.Errorf("%T should send %d fields, sent %d", check.message, check.fields, fields)
+		}
+	}
+}
+
+//ClientEventData and KeyValuePair as declared by the proto2 version of metrics.proto
+func proto2ClientEventDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	required := descriptorpb.FieldDescriptorProto_LABEL_REQUIRED.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	field := func(name string, number int32, label *descriptorpb.FieldDescriptorProto_Label, kind descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label, Type: kind.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("proto2/metrics.proto"),
+		Package: proto.String("model"),
+		Syntax:  proto.String("proto2"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("ClientEventType"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(1)},
+				{Name: proto.String("USER_REGISTERED"), Number: proto.Int32(2)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("ClientEventData"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("event_id", 1, required, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("event_type", 2, required, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".model.ClientEventType"),
+				field("timestamp", 3, required, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("data", 4, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("kv_pair", 5, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".model.KeyValuePair"),
+			}},
+			{Name: proto.String("KeyValuePair"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("key", 1, required, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("value", 2, required, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+		},
+	}
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatal("Error building proto2 descriptor: " + err.Error())
+	}
+	return fd.Messages().ByName("ClientEventData")
+}
+
+//Events are sent to old clients by GET /v1/events/{id}, they have to decode with the required fields of proto2
+func TestCompat_Proto2ClientsDecodeEvents(t *testing.T) {
+	descriptor := proto2ClientEventDescriptor(t)
+	decode := func(event *ClientEventData) (*dynamicpb.Message, error) {
+		data, err := proto.Marshal(event)
+		if err != nil {
+			t.Fatal("Error marshalling: " + err.Error())
+		}
+		decoded := dynamicpb.NewMessage(descriptor)
+		return decoded, proto.Unmarshal(data, decoded)
+	}
+
+	event := &ClientEventData{EventId: "evt-1", EventType: ClientEventType_USER_REGISTERED, Timestamp: proto.Int64(0), KvPair: []*KeyValuePair{
+		{Key: "referrer", Value: proto.String("")},
+		{Key: "duration", Value: proto.String("250"), TypedValue: &AttributeValue{Kind: &AttributeValue_IntValue{IntValue: 250}}},
+	}, Measurements: []*Measurement{{Name: "app_start", Value: 840}}, DeviceType: "android"}
+	decoded, err := decode(event)
+	if err != nil {
+		t.Fatal("proto2 client should decode an event with zero values: " + err.Error())
+	}
+	pairs := decoded.Get(descriptor.Fields().ByName("kv_pair")).List()
+	if decoded.Get(descriptor.Fields().ByName("timestamp")).Int() != 0 || pairs.Len() != 2 {
+		t.Errorf("Unexpected event: %v", decoded)
+	}
+	value := pairs.Get(1).Message().Descriptor().Fields().ByName("value")
+	if pairs.Get(1).Message().Get(value).String() != "250" {
+		t.Errorf("proto2 client should get the string form of a typed value: %v", pairs.Get(1))
+	}
+
+	//The decoding only passes because of the explicit presence, without it the fields are missing
+	event.Timestamp = nil
+	if _, err := decode(event); err == nil {
+		t.Error("proto2 client should reject an event without timestamp")
+	}
+	event.Timestamp, event.KvPair[0].Value = proto.Int64(0), nil
+	if _, err := decode(event); err == nil {
+		t.Error("proto2 client should reject a kv pair without value")
+	}
+}
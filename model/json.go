@@ -0,0 +1,104 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//JSON codecs following the proto3 JSON mapping, with the proto field names like event_id that the API has always used.
+//Unknown fields are ignored like they were before the move to proto3.
+var (
+	JSONMarshalOptions   = protojson.MarshalOptions{UseProtoNames: true}
+	JSONUnmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+//Canonical proto3 JSON: enums by name, 64 bit integers as strings and well-known types in their JSON form
+func MarshalJSON(m proto.Message) ([]byte, error) {
+	return JSONMarshalOptions.Marshal(m)
+}
+
+//Enums are accepted by name or number and int64 values as strings or numbers, so payloads of old clients still decode
+func UnmarshalJSON(data []byte, m proto.Message) error {
+	return JSONUnmarshalOptions.Unmarshal(data, m)
+}
+
+//Like UnmarshalJSON, but only accepts the canonical form MarshalJSON writes: enums given as numbers and 64 bit integers
+//given as JSON numbers are rejected
+func UnmarshalCanonicalJSON(data []byte, m proto.Message) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	if err := checkCanonicalMessage(m.ProtoReflect().Descriptor(), value, ""); err != nil {
+		return err
+	}
+	return UnmarshalJSON(data, m)
+}
+
+//Walks the decoded JSON along the message fields, anything that isn't a message of the expected shape is left to protojson
+func checkCanonicalMessage(md protoreflect.MessageDescriptor, value interface{}, path string) error {
+	object, ok := value.(map[string]interface{})
+	if !ok || strings.HasPrefix(string(md.FullName()), "google.protobuf.") {
+		return nil
+	}
+	for name, fieldValue := range object {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil || fieldValue == nil {
+			continue
+		}
+
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		switch {
+		case fd.IsList():
+			values, _ := fieldValue.([]interface{})
+			for i, element := range values {
+				if err := checkCanonicalValue(fd, element, fmt.Sprintf("%s[%d]", fieldPath, i)); err != nil {
+					return err
+				}
+			}
+		case fd.IsMap():
+			values, _ := fieldValue.(map[string]interface{})
+			for key, element := range values {
+				if err := checkCanonicalValue(fd.MapValue(), element, fieldPath+"["+key+"]"); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := checkCanonicalValue(fd, fieldValue, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkCanonicalValue(fd protoreflect.FieldDescriptor, value interface{}, path string) error {
+	_, isNumber := value.(json.Number)
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return checkCanonicalMessage(fd.Message(), value, path)
+	case protoreflect.EnumKind:
+		if isNumber && fd.Enum().FullName() != "google.protobuf.NullValue" {
+			return fmt.Errorf("%s: enum values must be given by name, got %v", path, value)
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if isNumber {
+			return fmt.Errorf("%s: 64 bit integers must be given as strings, got %v", path, value)
+		}
+	}
+	return nil
+}
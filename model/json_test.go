@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMarshalJSON_Canonical(t *testing.T) {
+	event := &ClientEventData{EventId: "evt-1", EventType: ClientEventType_USER_REGISTERED, Timestamp: proto.Int64(1452548890)}
+	data, err := MarshalJSON(event)
+	if err != nil {
+		t.Fatal("Error marshalling: " + err.Error())
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal("Error reading JSON: " + err.Error())
+	}
+	if fields["event_type"] != "USER_REGISTERED" {
+		t.Errorf("Enums should be written by name, got %v", fields["event_type"])
+	}
+	if fields["timestamp"] != "1452548890" {
+		t.Errorf("int64 values should be written as strings, got %v", fields["timestamp"])
+	}
+}
+
+func TestUnmarshalCanonicalJSON(t *testing.T) {
+	req := new(ClientEventUploadRequest)
+	canonical := `{"request_id": "9f2c1e", "events": [{"event_id": "evt-1", "event_type": "USER_REGISTERED", "timestamp": "1452548890"}], "unknown": 1}`
+	if err := UnmarshalCanonicalJSON([]byte(canonical), req); err != nil {
+		t.Fatal("Canonical JSON should be accepted: " + err.Error())
+	}
+	if req.GetEvents()[0].GetEventType() != ClientEventType_USER_REGISTERED || req.GetEvents()[0].GetTimestamp() != 1452548890 {
+		t.Errorf("Unexpected event: %v", req.GetEvents()[0])
+	}
+
+	integerForm := []string{
+		`{"events": [{"event_id": "evt-1", "event_type": 2}]}`,
+		`{"events": [{"event_id": "evt-1", "timestamp": 1452548890}]}`,
+	}
+	for _, data := range integerForm {
+		if err := UnmarshalCanonicalJSON([]byte(data), new(ClientEventUploadRequest)); err == nil {
+			t.Errorf("Integer form should be rejected: %s", data)
+		}
+		if err := UnmarshalJSON([]byte(data), new(ClientEventUploadRequest)); err != nil {
+			t.Errorf("Integer form should be accepted by UnmarshalJSON: %s", err.Error())
+		}
+	}
+	if err := UnmarshalCanonicalJSON([]byte(`{"filter": {"event_types": [2]}}`), new(WebhookSubscription)); err == nil {
+		t.Error("Repeated enums should be checked too")
+	}
+}
+
+func TestUnmarshalCanonicalJSON_TypedValues(t *testing.T) {
+	event := new(ClientEventData)
+	data := `{"kv_pair": [{"key": "country", "value": "NZ"}, {"key": "duration", "typed_value": {"int_value": "250"}}, {"key": "tags", "typed_value": {"list_value": {"values": [{"string_value": "a"}, {"bool_value": true}]}}}]}`
+	if err := UnmarshalCanonicalJSON([]byte(data), event); err != nil {
+		t.Fatal("Typed values should be accepted: " + err.Error())
+	}
+	pairs := event.GetKvPair()
+	if pairs[0].GetValue() != "NZ" || pairs[1].GetTypedValue().GetIntValue() != 250 || len(pairs[2].GetTypedValue().GetListValue().GetValues()) != 2 {
+		t.Errorf("Unexpected kv pairs: %v", pairs)
+	}
+
+	if err := UnmarshalCanonicalJSON([]byte(`{"kv_pair": [{"key": "duration", "typed_value": {"int_value": 250}}]}`), event); err == nil {
+		t.Error("Typed int64 values should be given as strings")
+	}
+}
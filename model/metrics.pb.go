@@ -1,233 +1,3827 @@
-// Code generated by protoc-gen-go.
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
 // source: metrics.proto
-// DO NOT EDIT!
 
-/*
-Package model is a generated protocol buffer package.
-
-It is generated from these files:
-	metrics.proto
-
-It has these top-level messages:
-	ClientEventData
-	ClientEventUploadRequest
-	KeyValuePair
-	ErrorResponse
-	HeartBeat
-*/
 package model
 
-import proto "github.com/golang/protobuf/proto"
-import math "math"
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = math.Inf
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 // The event type enum:
 type ClientEventType int32
 
 const (
+	ClientEventType_UNSPECIFIED     ClientEventType = 0
 	ClientEventType_UNKNOWN         ClientEventType = 1
 	ClientEventType_USER_REGISTERED ClientEventType = 2
 )
 
-var ClientEventType_name = map[int32]string{
-	1: "UNKNOWN",
-	2: "USER_REGISTERED",
-}
-var ClientEventType_value = map[string]int32{
-	"UNKNOWN":         1,
-	"USER_REGISTERED": 2,
-}
+// Enum value maps for ClientEventType.
+var (
+	ClientEventType_name = map[int32]string{
+		0: "UNSPECIFIED",
+		1: "UNKNOWN",
+		2: "USER_REGISTERED",
+	}
+	ClientEventType_value = map[string]int32{
+		"UNSPECIFIED":     0,
+		"UNKNOWN":         1,
+		"USER_REGISTERED": 2,
+	}
+)
 
 func (x ClientEventType) Enum() *ClientEventType {
 	p := new(ClientEventType)
 	*p = x
 	return p
 }
+
 func (x ClientEventType) String() string {
-	return proto.EnumName(ClientEventType_name, int32(x))
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ClientEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_metrics_proto_enumTypes[0].Descriptor()
+}
+
+func (ClientEventType) Type() protoreflect.EnumType {
+	return &file_metrics_proto_enumTypes[0]
+}
+
+func (x ClientEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ClientEventType.Descriptor instead.
+func (ClientEventType) EnumDescriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{0}
 }
-func (x *ClientEventType) UnmarshalJSON(data []byte) error {
-	value, err := proto.UnmarshalJSONEnum(ClientEventType_value, data, "ClientEventType")
-	if err != nil {
-		return err
+
+// What a measurement measures
+type MeasurementKind int32
+
+const (
+	MeasurementKind_MEASUREMENT_KIND_UNSPECIFIED MeasurementKind = 0
+	MeasurementKind_MEASUREMENT_KIND_COUNTER     MeasurementKind = 1
+	MeasurementKind_MEASUREMENT_KIND_GAUGE       MeasurementKind = 2
+	MeasurementKind_MEASUREMENT_KIND_TIMING      MeasurementKind = 3
+)
+
+// Enum value maps for MeasurementKind.
+var (
+	MeasurementKind_name = map[int32]string{
+		0: "MEASUREMENT_KIND_UNSPECIFIED",
+		1: "MEASUREMENT_KIND_COUNTER",
+		2: "MEASUREMENT_KIND_GAUGE",
+		3: "MEASUREMENT_KIND_TIMING",
 	}
-	*x = ClientEventType(value)
-	return nil
+	MeasurementKind_value = map[string]int32{
+		"MEASUREMENT_KIND_UNSPECIFIED": 0,
+		"MEASUREMENT_KIND_COUNTER":     1,
+		"MEASUREMENT_KIND_GAUGE":       2,
+		"MEASUREMENT_KIND_TIMING":      3,
+	}
+)
+
+func (x MeasurementKind) Enum() *MeasurementKind {
+	p := new(MeasurementKind)
+	*p = x
+	return p
+}
+
+func (x MeasurementKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MeasurementKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_metrics_proto_enumTypes[1].Descriptor()
+}
+
+func (MeasurementKind) Type() protoreflect.EnumType {
+	return &file_metrics_proto_enumTypes[1]
+}
+
+func (x MeasurementKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MeasurementKind.Descriptor instead.
+func (MeasurementKind) EnumDescriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{1}
+}
+
+// How a kv pair value is compared in a KeyValueCondition
+type Comparison int32
+
+const (
+	Comparison_COMPARISON_UNSPECIFIED      Comparison = 0
+	Comparison_COMPARISON_EQUAL            Comparison = 1
+	Comparison_COMPARISON_NOT_EQUAL        Comparison = 2
+	Comparison_COMPARISON_LESS             Comparison = 3
+	Comparison_COMPARISON_LESS_OR_EQUAL    Comparison = 4
+	Comparison_COMPARISON_GREATER          Comparison = 5
+	Comparison_COMPARISON_GREATER_OR_EQUAL Comparison = 6
+)
+
+// Enum value maps for Comparison.
+var (
+	Comparison_name = map[int32]string{
+		0: "COMPARISON_UNSPECIFIED",
+		1: "COMPARISON_EQUAL",
+		2: "COMPARISON_NOT_EQUAL",
+		3: "COMPARISON_LESS",
+		4: "COMPARISON_LESS_OR_EQUAL",
+		5: "COMPARISON_GREATER",
+		6: "COMPARISON_GREATER_OR_EQUAL",
+	}
+	Comparison_value = map[string]int32{
+		"COMPARISON_UNSPECIFIED":      0,
+		"COMPARISON_EQUAL":            1,
+		"COMPARISON_NOT_EQUAL":        2,
+		"COMPARISON_LESS":             3,
+		"COMPARISON_LESS_OR_EQUAL":    4,
+		"COMPARISON_GREATER":          5,
+		"COMPARISON_GREATER_OR_EQUAL": 6,
+	}
+)
+
+func (x Comparison) Enum() *Comparison {
+	p := new(Comparison)
+	*p = x
+	return p
+}
+
+func (x Comparison) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Comparison) Descriptor() protoreflect.EnumDescriptor {
+	return file_metrics_proto_enumTypes[2].Descriptor()
+}
+
+func (Comparison) Type() protoreflect.EnumType {
+	return &file_metrics_proto_enumTypes[2]
+}
+
+func (x Comparison) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Comparison.Descriptor instead.
+func (Comparison) EnumDescriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{2}
 }
 
 // Representing a single event.
 type ClientEventData struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
 	// Core event attributes:
-	EventId   *string          `protobuf:"bytes,1,req,name=event_id" json:"event_id,omitempty"`
-	EventType *ClientEventType `protobuf:"varint,2,req,name=event_type,enum=model.ClientEventType" json:"event_type,omitempty"`
-	Timestamp *int64           `protobuf:"varint,3,req,name=timestamp" json:"timestamp,omitempty"`
+	EventId   string          `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType ClientEventType `protobuf:"varint,2,opt,name=event_type,json=eventType,proto3,enum=meowtrics.v1.ClientEventType" json:"event_type,omitempty"`
+	Timestamp *int64          `protobuf:"varint,3,opt,name=timestamp,proto3,oneof" json:"timestamp,omitempty"`
 	// arbitrary data
-	Data *string `protobuf:"bytes,4,opt,name=data" json:"data,omitempty"`
+	Data string `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
 	// allow arbitrary key-value pairs
-	KvPair           []*KeyValuePair `protobuf:"bytes,5,rep,name=kv_pair" json:"kv_pair,omitempty"`
-	XXX_unrecognized []byte          `json:"-"`
+	KvPair []*KeyValuePair `protobuf:"bytes,5,rep,name=kv_pair,json=kvPair,proto3" json:"kv_pair,omitempty"`
+	// numeric values measured with the event
+	Measurements []*Measurement `protobuf:"bytes,6,rep,name=measurements,proto3" json:"measurements,omitempty"`
+	// set by the server to the device_type of the upload request when the event is stored
+	DeviceType    string `protobuf:"bytes,7,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientEventData) Reset() {
+	*x = ClientEventData{}
+	mi := &file_metrics_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (m *ClientEventData) Reset()         { *m = ClientEventData{} }
-func (m *ClientEventData) String() string { return proto.CompactTextString(m) }
-func (*ClientEventData) ProtoMessage()    {}
+func (x *ClientEventData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientEventData) ProtoMessage() {}
+
+func (x *ClientEventData) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientEventData.ProtoReflect.Descriptor instead.
+func (*ClientEventData) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{0}
+}
 
-func (m *ClientEventData) GetEventId() string {
-	if m != nil && m.EventId != nil {
-		return *m.EventId
+func (x *ClientEventData) GetEventId() string {
+	if x != nil {
+		return x.EventId
 	}
 	return ""
 }
 
-func (m *ClientEventData) GetEventType() ClientEventType {
-	if m != nil && m.EventType != nil {
-		return *m.EventType
+func (x *ClientEventData) GetEventType() ClientEventType {
+	if x != nil {
+		return x.EventType
 	}
-	return ClientEventType_UNKNOWN
+	return ClientEventType_UNSPECIFIED
 }
 
-func (m *ClientEventData) GetTimestamp() int64 {
-	if m != nil && m.Timestamp != nil {
-		return *m.Timestamp
+func (x *ClientEventData) GetTimestamp() int64 {
+	if x != nil && x.Timestamp != nil {
+		return *x.Timestamp
 	}
 	return 0
 }
 
-func (m *ClientEventData) GetData() string {
-	if m != nil && m.Data != nil {
-		return *m.Data
+func (x *ClientEventData) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *ClientEventData) GetKvPair() []*KeyValuePair {
+	if x != nil {
+		return x.KvPair
+	}
+	return nil
+}
+
+func (x *ClientEventData) GetMeasurements() []*Measurement {
+	if x != nil {
+		return x.Measurements
+	}
+	return nil
+}
+
+func (x *ClientEventData) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+// A named numeric value, e.g. {name: "app_start", kind: MEASUREMENT_KIND_TIMING, unit: "ms", value: 840}. Clients that
+// aggregate locally send a histogram instead of a single value.
+type Measurement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Kind          MeasurementKind        `protobuf:"varint,2,opt,name=kind,proto3,enum=meowtrics.v1.MeasurementKind" json:"kind,omitempty"`
+	Unit          string                 `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	Value         float64                `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	Histogram     *Histogram             `protobuf:"bytes,5,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Measurement) Reset() {
+	*x = Measurement{}
+	mi := &file_metrics_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Measurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Measurement) ProtoMessage() {}
+
+func (x *Measurement) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Measurement.ProtoReflect.Descriptor instead.
+func (*Measurement) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Measurement) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Measurement) GetKind() MeasurementKind {
+	if x != nil {
+		return x.Kind
+	}
+	return MeasurementKind_MEASUREMENT_KIND_UNSPECIFIED
+}
+
+func (x *Measurement) GetUnit() string {
+	if x != nil {
+		return x.Unit
 	}
 	return ""
 }
 
-func (m *ClientEventData) GetKvPair() []*KeyValuePair {
-	if m != nil {
-		return m.KvPair
+func (x *Measurement) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Measurement) GetHistogram() *Histogram {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+// A pre-aggregated client side histogram. bucket_counts has one more entry than upper_bounds: bucket i counts the
+// values above upper_bounds[i-1] up to upper_bounds[i], the last one the values above the last bound.
+type Histogram struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UpperBounds   []float64              `protobuf:"fixed64,1,rep,packed,name=upper_bounds,json=upperBounds,proto3" json:"upper_bounds,omitempty"`
+	BucketCounts  []uint64               `protobuf:"varint,2,rep,packed,name=bucket_counts,json=bucketCounts,proto3" json:"bucket_counts,omitempty"`
+	Sum           float64                `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min           *float64               `protobuf:"fixed64,4,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max           *float64               `protobuf:"fixed64,5,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_metrics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Histogram.ProtoReflect.Descriptor instead.
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Histogram) GetUpperBounds() []float64 {
+	if x != nil {
+		return x.UpperBounds
+	}
+	return nil
+}
+
+func (x *Histogram) GetBucketCounts() []uint64 {
+	if x != nil {
+		return x.BucketCounts
 	}
 	return nil
 }
 
+func (x *Histogram) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *Histogram) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *Histogram) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
 // The message uploaded to the server - containing multiple events
 type ClientEventUploadRequest struct {
-	RequestId *string `protobuf:"bytes,1,req,name=request_id" json:"request_id,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	// The clients device type (Android, iPhone etc.)
-	DeviceType *string `protobuf:"bytes,2,req,name=device_type" json:"device_type,omitempty"`
+	DeviceType string `protobuf:"bytes,2,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
 	// The events being uploaded
-	Events           []*ClientEventData `protobuf:"bytes,3,rep,name=events" json:"events,omitempty"`
-	XXX_unrecognized []byte             `json:"-"`
+	Events        []*ClientEventData `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientEventUploadRequest) Reset() {
+	*x = ClientEventUploadRequest{}
+	mi := &file_metrics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (m *ClientEventUploadRequest) Reset()         { *m = ClientEventUploadRequest{} }
-func (m *ClientEventUploadRequest) String() string { return proto.CompactTextString(m) }
-func (*ClientEventUploadRequest) ProtoMessage()    {}
+func (x *ClientEventUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientEventUploadRequest) ProtoMessage() {}
+
+func (x *ClientEventUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientEventUploadRequest.ProtoReflect.Descriptor instead.
+func (*ClientEventUploadRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{3}
+}
 
-func (m *ClientEventUploadRequest) GetRequestId() string {
-	if m != nil && m.RequestId != nil {
-		return *m.RequestId
+func (x *ClientEventUploadRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
 	}
 	return ""
 }
 
-func (m *ClientEventUploadRequest) GetDeviceType() string {
-	if m != nil && m.DeviceType != nil {
-		return *m.DeviceType
+func (x *ClientEventUploadRequest) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
 	}
 	return ""
 }
 
-func (m *ClientEventUploadRequest) GetEvents() []*ClientEventData {
-	if m != nil {
-		return m.Events
+func (x *ClientEventUploadRequest) GetEvents() []*ClientEventData {
+	if x != nil {
+		return x.Events
 	}
 	return nil
 }
 
-// The message for key value pairs
+// The message for key value pairs. Old clients only send the string value, newer ones can send a typed_value instead,
+// which takes precedence when set.
 type KeyValuePair struct {
-	Key              *string `protobuf:"bytes,1,req,name=key" json:"key,omitempty"`
-	Value            *string `protobuf:"bytes,2,req,name=value" json:"value,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	//Set to the string form of typed_value when the event is stored, old clients require it
+	Value         *string         `protobuf:"bytes,2,opt,name=value,proto3,oneof" json:"value,omitempty"`
+	TypedValue    *AttributeValue `protobuf:"bytes,3,opt,name=typed_value,json=typedValue,proto3" json:"typed_value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValuePair) Reset() {
+	*x = KeyValuePair{}
+	mi := &file_metrics_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValuePair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValuePair) ProtoMessage() {}
+
+func (x *KeyValuePair) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValuePair.ProtoReflect.Descriptor instead.
+func (*KeyValuePair) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{4}
 }
 
-func (m *KeyValuePair) Reset()         { *m = KeyValuePair{} }
-func (m *KeyValuePair) String() string { return proto.CompactTextString(m) }
-func (*KeyValuePair) ProtoMessage()    {}
+func (x *KeyValuePair) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
 
-func (m *KeyValuePair) GetKey() string {
-	if m != nil && m.Key != nil {
-		return *m.Key
+func (x *KeyValuePair) GetValue() string {
+	if x != nil && x.Value != nil {
+		return *x.Value
 	}
 	return ""
 }
 
-func (m *KeyValuePair) GetValue() string {
-	if m != nil && m.Value != nil {
-		return *m.Value
+func (x *KeyValuePair) GetTypedValue() *AttributeValue {
+	if x != nil {
+		return x.TypedValue
+	}
+	return nil
+}
+
+// A typed kv pair value
+type AttributeValue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*AttributeValue_StringValue
+	//	*AttributeValue_IntValue
+	//	*AttributeValue_DoubleValue
+	//	*AttributeValue_BoolValue
+	//	*AttributeValue_BytesValue
+	//	*AttributeValue_ListValue
+	Kind          isAttributeValue_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttributeValue) Reset() {
+	*x = AttributeValue{}
+	mi := &file_metrics_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttributeValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttributeValue) ProtoMessage() {}
+
+func (x *AttributeValue) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttributeValue.ProtoReflect.Descriptor instead.
+func (*AttributeValue) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AttributeValue) GetKind() isAttributeValue_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *AttributeValue) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*AttributeValue_StringValue); ok {
+			return x.StringValue
+		}
 	}
 	return ""
 }
 
+func (x *AttributeValue) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*AttributeValue_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *AttributeValue) GetDoubleValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*AttributeValue_DoubleValue); ok {
+			return x.DoubleValue
+		}
+	}
+	return 0
+}
+
+func (x *AttributeValue) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*AttributeValue_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *AttributeValue) GetBytesValue() []byte {
+	if x != nil {
+		if x, ok := x.Kind.(*AttributeValue_BytesValue); ok {
+			return x.BytesValue
+		}
+	}
+	return nil
+}
+
+func (x *AttributeValue) GetListValue() *AttributeValueList {
+	if x != nil {
+		if x, ok := x.Kind.(*AttributeValue_ListValue); ok {
+			return x.ListValue
+		}
+	}
+	return nil
+}
+
+type isAttributeValue_Kind interface {
+	isAttributeValue_Kind()
+}
+
+type AttributeValue_StringValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type AttributeValue_IntValue struct {
+	IntValue int64 `protobuf:"varint,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type AttributeValue_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,3,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type AttributeValue_BoolValue struct {
+	BoolValue bool `protobuf:"varint,4,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type AttributeValue_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,5,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+type AttributeValue_ListValue struct {
+	ListValue *AttributeValueList `protobuf:"bytes,6,opt,name=list_value,json=listValue,proto3,oneof"`
+}
+
+func (*AttributeValue_StringValue) isAttributeValue_Kind() {}
+
+func (*AttributeValue_IntValue) isAttributeValue_Kind() {}
+
+func (*AttributeValue_DoubleValue) isAttributeValue_Kind() {}
+
+func (*AttributeValue_BoolValue) isAttributeValue_Kind() {}
+
+func (*AttributeValue_BytesValue) isAttributeValue_Kind() {}
+
+func (*AttributeValue_ListValue) isAttributeValue_Kind() {}
+
+// A list of typed values, lists can be nested
+type AttributeValueList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*AttributeValue      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttributeValueList) Reset() {
+	*x = AttributeValueList{}
+	mi := &file_metrics_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttributeValueList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttributeValueList) ProtoMessage() {}
+
+func (x *AttributeValueList) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttributeValueList.ProtoReflect.Descriptor instead.
+func (*AttributeValueList) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AttributeValueList) GetValues() []*AttributeValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
 // The message to send as the error response payload if something goes wrong
 type ErrorResponse struct {
-	Code             *string `protobuf:"bytes,1,req,name=code" json:"code,omitempty"`
-	ErrorMessage     *string `protobuf:"bytes,2,opt,name=error_message" json:"error_message,omitempty"`
-	Description      *string `protobuf:"bytes,3,opt,name=description" json:"description,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorResponse) Reset() {
+	*x = ErrorResponse{}
+	mi := &file_metrics_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorResponse) ProtoMessage() {}
+
+func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (m *ErrorResponse) Reset()         { *m = ErrorResponse{} }
-func (m *ErrorResponse) String() string { return proto.CompactTextString(m) }
-func (*ErrorResponse) ProtoMessage()    {}
+// Deprecated: Use ErrorResponse.ProtoReflect.Descriptor instead.
+func (*ErrorResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{7}
+}
 
-func (m *ErrorResponse) GetCode() string {
-	if m != nil && m.Code != nil {
-		return *m.Code
+func (x *ErrorResponse) GetCode() string {
+	if x != nil {
+		return x.Code
 	}
 	return ""
 }
 
-func (m *ErrorResponse) GetErrorMessage() string {
-	if m != nil && m.ErrorMessage != nil {
-		return *m.ErrorMessage
+func (x *ErrorResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
 	}
 	return ""
 }
 
-func (m *ErrorResponse) GetDescription() string {
-	if m != nil && m.Description != nil {
-		return *m.Description
+func (x *ErrorResponse) GetDescription() string {
+	if x != nil {
+		return x.Description
 	}
 	return ""
 }
 
-// The message to check if server is up and running
-type HeartBeat struct {
-	Status           *string `protobuf:"bytes,1,req,name=status" json:"status,omitempty"`
-	Timestamp        *string `protobuf:"bytes,2,req,name=timestamp" json:"timestamp,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+// Status of one dependency checked by the readiness endpoint
+type ComponentStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (m *HeartBeat) Reset()         { *m = HeartBeat{} }
-func (m *HeartBeat) String() string { return proto.CompactTextString(m) }
-func (*HeartBeat) ProtoMessage()    {}
+func (x *ComponentStatus) Reset() {
+	*x = ComponentStatus{}
+	mi := &file_metrics_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-func (m *HeartBeat) GetStatus() string {
-	if m != nil && m.Status != nil {
-		return *m.Status
-	}
-	return ""
+func (x *ComponentStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (m *HeartBeat) GetTimestamp() string {
-	if m != nil && m.Timestamp != nil {
-		return *m.Timestamp
+func (*ComponentStatus) ProtoMessage() {}
+
+func (x *ComponentStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func init() {
-	proto.RegisterEnum("model.ClientEventType", ClientEventType_name, ClientEventType_value)
+// Deprecated: Use ComponentStatus.ProtoReflect.Descriptor instead.
+func (*ComponentStatus) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ComponentStatus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ComponentStatus) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ComponentStatus) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+// The message to check if server is up and running
+type HeartBeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Timestamp     string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Components    []*ComponentStatus     `protobuf:"bytes,3,rep,name=components,proto3" json:"components,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartBeat) Reset() {
+	*x = HeartBeat{}
+	mi := &file_metrics_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartBeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartBeat) ProtoMessage() {}
+
+func (x *HeartBeat) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartBeat.ProtoReflect.Descriptor instead.
+func (*HeartBeat) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *HeartBeat) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HeartBeat) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *HeartBeat) GetComponents() []*ComponentStatus {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+// The message to read or change the log level through the admin API
+type LogLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogLevel) Reset() {
+	*x = LogLevel{}
+	mi := &file_metrics_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLevel) ProtoMessage() {}
+
+func (x *LogLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLevel.ProtoReflect.Descriptor instead.
+func (*LogLevel) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LogLevel) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+// The message returned by the admin import and compact endpoints
+type EventCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         *int64                 `protobuf:"varint,1,opt,name=count,proto3,oneof" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventCount) Reset() {
+	*x = EventCount{}
+	mi := &file_metrics_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventCount) ProtoMessage() {}
+
+func (x *EventCount) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventCount.ProtoReflect.Descriptor instead.
+func (*EventCount) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EventCount) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+// Number of stored events of one event type
+type EventTypeCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventType     ClientEventType        `protobuf:"varint,1,opt,name=event_type,json=eventType,proto3,enum=meowtrics.v1.ClientEventType" json:"event_type,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventTypeCount) Reset() {
+	*x = EventTypeCount{}
+	mi := &file_metrics_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventTypeCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventTypeCount) ProtoMessage() {}
+
+func (x *EventTypeCount) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventTypeCount.ProtoReflect.Descriptor instead.
+func (*EventTypeCount) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *EventTypeCount) GetEventType() ClientEventType {
+	if x != nil {
+		return x.EventType
+	}
+	return ClientEventType_UNSPECIFIED
+}
+
+func (x *EventTypeCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// The message returned by the admin stats endpoint
+type StoreStats struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Events          *int64                 `protobuf:"varint,1,opt,name=events,proto3,oneof" json:"events,omitempty"`
+	EventTypes      []*EventTypeCount      `protobuf:"bytes,2,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	UptimeInSeconds *int64                 `protobuf:"varint,3,opt,name=uptime_in_seconds,json=uptimeInSeconds,proto3,oneof" json:"uptime_in_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StoreStats) Reset() {
+	*x = StoreStats{}
+	mi := &file_metrics_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StoreStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreStats) ProtoMessage() {}
+
+func (x *StoreStats) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreStats.ProtoReflect.Descriptor instead.
+func (*StoreStats) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StoreStats) GetEvents() int64 {
+	if x != nil && x.Events != nil {
+		return *x.Events
+	}
+	return 0
+}
+
+func (x *StoreStats) GetEventTypes() []*EventTypeCount {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *StoreStats) GetUptimeInSeconds() int64 {
+	if x != nil && x.UptimeInSeconds != nil {
+		return *x.UptimeInSeconds
+	}
+	return 0
+}
+
+// Compares the value of the kv pair key with value. Numbers are compared numerically, other values only support
+// (not) equal. COMPARISON_UNSPECIFIED means equal.
+type KeyValueCondition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Comparison    Comparison             `protobuf:"varint,2,opt,name=comparison,proto3,enum=meowtrics.v1.Comparison" json:"comparison,omitempty"`
+	Value         *AttributeValue        `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValueCondition) Reset() {
+	*x = KeyValueCondition{}
+	mi := &file_metrics_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValueCondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValueCondition) ProtoMessage() {}
+
+func (x *KeyValueCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValueCondition.ProtoReflect.Descriptor instead.
+func (*KeyValueCondition) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *KeyValueCondition) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValueCondition) GetComparison() Comparison {
+	if x != nil {
+		return x.Comparison
+	}
+	return Comparison_COMPARISON_UNSPECIFIED
+}
+
+func (x *KeyValueCondition) GetValue() *AttributeValue {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// Which stored events a webhook subscription receives, empty lists match every event. An event matches when its type
+// is one of event_types, its device type one of device_types, it has every pair in kv_pairs and meets every condition
+// in kv_conditions.
+type EventFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventTypes    []ClientEventType      `protobuf:"varint,1,rep,packed,name=event_types,json=eventTypes,proto3,enum=meowtrics.v1.ClientEventType" json:"event_types,omitempty"`
+	DeviceTypes   []string               `protobuf:"bytes,2,rep,name=device_types,json=deviceTypes,proto3" json:"device_types,omitempty"`
+	KvPairs       []*KeyValuePair        `protobuf:"bytes,3,rep,name=kv_pairs,json=kvPairs,proto3" json:"kv_pairs,omitempty"`
+	KvConditions  []*KeyValueCondition   `protobuf:"bytes,4,rep,name=kv_conditions,json=kvConditions,proto3" json:"kv_conditions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventFilter) Reset() {
+	*x = EventFilter{}
+	mi := &file_metrics_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventFilter) ProtoMessage() {}
+
+func (x *EventFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventFilter.ProtoReflect.Descriptor instead.
+func (*EventFilter) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *EventFilter) GetEventTypes() []ClientEventType {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *EventFilter) GetDeviceTypes() []string {
+	if x != nil {
+		return x.DeviceTypes
+	}
+	return nil
+}
+
+func (x *EventFilter) GetKvPairs() []*KeyValuePair {
+	if x != nil {
+		return x.KvPairs
+	}
+	return nil
+}
+
+func (x *EventFilter) GetKvConditions() []*KeyValueCondition {
+	if x != nil {
+		return x.KvConditions
+	}
+	return nil
+}
+
+// A webhook receiving matching events, managed through the admin API
+type WebhookSubscription struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url   string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	//Key of the X-Meowtrics-Signature HMAC, only returned when the subscription is created
+	Secret string `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	//application/json (default) or application/x-protobuf
+	ContentType   string       `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Filter        *EventFilter `protobuf:"bytes,5,opt,name=filter,proto3" json:"filter,omitempty"`
+	CreatedAt     int64        `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebhookSubscription) Reset() {
+	*x = WebhookSubscription{}
+	mi := &file_metrics_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookSubscription) ProtoMessage() {}
+
+func (x *WebhookSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookSubscription.ProtoReflect.Descriptor instead.
+func (*WebhookSubscription) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WebhookSubscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WebhookSubscription) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *WebhookSubscription) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *WebhookSubscription) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *WebhookSubscription) GetFilter() *EventFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *WebhookSubscription) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// The message returned by the admin webhooks endpoint
+type WebhookSubscriptionList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*WebhookSubscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebhookSubscriptionList) Reset() {
+	*x = WebhookSubscriptionList{}
+	mi := &file_metrics_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookSubscriptionList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookSubscriptionList) ProtoMessage() {}
+
+func (x *WebhookSubscriptionList) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookSubscriptionList.ProtoReflect.Descriptor instead.
+func (*WebhookSubscriptionList) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WebhookSubscriptionList) GetSubscriptions() []*WebhookSubscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+// The payload POSTed to a webhook for every matching event
+type WebhookEvent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId     string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	DeviceType     string                 `protobuf:"bytes,3,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	Event          *ClientEventData       `protobuf:"bytes,4,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WebhookEvent) Reset() {
+	*x = WebhookEvent{}
+	mi := &file_metrics_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookEvent) ProtoMessage() {}
+
+func (x *WebhookEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookEvent.ProtoReflect.Descriptor instead.
+func (*WebhookEvent) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WebhookEvent) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetEvent() *ClientEventData {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// A webhook delivery that failed every attempt
+type DeadLetter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delivery      *WebhookEvent          `protobuf:"bytes,1,opt,name=delivery,proto3" json:"delivery,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Attempts      int32                  `protobuf:"varint,3,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	LastError     string                 `protobuf:"bytes,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	FailedAt      int64                  `protobuf:"varint,5,opt,name=failed_at,json=failedAt,proto3" json:"failed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_metrics_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetter) ProtoMessage() {}
+
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeadLetter) GetDelivery() *WebhookEvent {
+	if x != nil {
+		return x.Delivery
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetFailedAt() int64 {
+	if x != nil {
+		return x.FailedAt
+	}
+	return 0
+}
+
+// The message returned by the admin dead letters endpoint
+type DeadLetterList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetters   []*DeadLetter          `protobuf:"bytes,1,rep,name=dead_letters,json=deadLetters,proto3" json:"dead_letters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterList) Reset() {
+	*x = DeadLetterList{}
+	mi := &file_metrics_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterList) ProtoMessage() {}
+
+func (x *DeadLetterList) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterList.ProtoReflect.Descriptor instead.
+func (*DeadLetterList) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeadLetterList) GetDeadLetters() []*DeadLetter {
+	if x != nil {
+		return x.DeadLetters
+	}
+	return nil
+}
+
+// The webhooks file the server keeps subscriptions and dead letters in, secrets included
+type WebhookState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*WebhookSubscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	DeadLetters   []*DeadLetter          `protobuf:"bytes,2,rep,name=dead_letters,json=deadLetters,proto3" json:"dead_letters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebhookState) Reset() {
+	*x = WebhookState{}
+	mi := &file_metrics_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookState) ProtoMessage() {}
+
+func (x *WebhookState) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookState.ProtoReflect.Descriptor instead.
+func (*WebhookState) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WebhookState) GetSubscriptions() []*WebhookSubscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *WebhookState) GetDeadLetters() []*DeadLetter {
+	if x != nil {
+		return x.DeadLetters
+	}
+	return nil
+}
+
+// A stored event pushed to the subscribers of the event stream, ids grow by one for every stored event. A resumed
+// stream starts with a message setting missed_events and carrying no event when events after the last event id are no
+// longer kept, or the id is unknown after a restart: the events up to its id were missed and have to be queried.
+type StreamedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DeviceType    string                 `protobuf:"bytes,2,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	Event         *ClientEventData       `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	MissedEvents  bool                   `protobuf:"varint,4,opt,name=missed_events,json=missedEvents,proto3" json:"missed_events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamedEvent) Reset() {
+	*x = StreamedEvent{}
+	mi := &file_metrics_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamedEvent) ProtoMessage() {}
+
+func (x *StreamedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamedEvent.ProtoReflect.Descriptor instead.
+func (*StreamedEvent) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StreamedEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StreamedEvent) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+func (x *StreamedEvent) GetEvent() *ClientEventData {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *StreamedEvent) GetMissedEvents() bool {
+	if x != nil {
+		return x.MissedEvents
+	}
+	return false
+}
+
+// The response to UploadEvents, queued is set when the events are stored in the background by async ingestion
+type UploadEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        *int32                 `protobuf:"varint,1,opt,name=events,proto3,oneof" json:"events,omitempty"`
+	Queued        *bool                  `protobuf:"varint,2,opt,name=queued,proto3,oneof" json:"queued,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadEventsResponse) Reset() {
+	*x = UploadEventsResponse{}
+	mi := &file_metrics_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadEventsResponse) ProtoMessage() {}
+
+func (x *UploadEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadEventsResponse.ProtoReflect.Descriptor instead.
+func (*UploadEventsResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *UploadEventsResponse) GetEvents() int32 {
+	if x != nil && x.Events != nil {
+		return *x.Events
+	}
+	return 0
+}
+
+func (x *UploadEventsResponse) GetQueued() bool {
+	if x != nil && x.Queued != nil {
+		return *x.Queued
+	}
+	return false
+}
+
+type GetEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventRequest) Reset() {
+	*x = GetEventRequest{}
+	mi := &file_metrics_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventRequest) ProtoMessage() {}
+
+func (x *GetEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventRequest.ProtoReflect.Descriptor instead.
+func (*GetEventRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetEventRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+// Stored events matching filter with timestamps in [from_timestamp, to_timestamp), ordered by event id. Pages of up to
+// limit events are read by passing the next_after_event_id of the previous page as after_event_id.
+type QueryEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *EventFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	FromTimestamp *int64                 `protobuf:"varint,2,opt,name=from_timestamp,json=fromTimestamp,proto3,oneof" json:"from_timestamp,omitempty"`
+	ToTimestamp   *int64                 `protobuf:"varint,3,opt,name=to_timestamp,json=toTimestamp,proto3,oneof" json:"to_timestamp,omitempty"`
+	AfterEventId  string                 `protobuf:"bytes,4,opt,name=after_event_id,json=afterEventId,proto3" json:"after_event_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEventsRequest) Reset() {
+	*x = QueryEventsRequest{}
+	mi := &file_metrics_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEventsRequest) ProtoMessage() {}
+
+func (x *QueryEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEventsRequest.ProtoReflect.Descriptor instead.
+func (*QueryEventsRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *QueryEventsRequest) GetFilter() *EventFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *QueryEventsRequest) GetFromTimestamp() int64 {
+	if x != nil && x.FromTimestamp != nil {
+		return *x.FromTimestamp
+	}
+	return 0
+}
+
+func (x *QueryEventsRequest) GetToTimestamp() int64 {
+	if x != nil && x.ToTimestamp != nil {
+		return *x.ToTimestamp
+	}
+	return 0
+}
+
+func (x *QueryEventsRequest) GetAfterEventId() string {
+	if x != nil {
+		return x.AfterEventId
+	}
+	return ""
+}
+
+func (x *QueryEventsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// next_after_event_id is only set when there are more matching events
+type QueryEventsResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Events           []*ClientEventData     `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	NextAfterEventId string                 `protobuf:"bytes,2,opt,name=next_after_event_id,json=nextAfterEventId,proto3" json:"next_after_event_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *QueryEventsResponse) Reset() {
+	*x = QueryEventsResponse{}
+	mi := &file_metrics_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEventsResponse) ProtoMessage() {}
+
+func (x *QueryEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEventsResponse.ProtoReflect.Descriptor instead.
+func (*QueryEventsResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *QueryEventsResponse) GetEvents() []*ClientEventData {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *QueryEventsResponse) GetNextAfterEventId() string {
+	if x != nil {
+		return x.NextAfterEventId
+	}
+	return ""
+}
+
+// Subscribes to newly stored events like GET /v1/events/stream, resuming after last_event_id when it is set
+type SubscribeEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *EventFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	LastEventId   *uint64                `protobuf:"varint,2,opt,name=last_event_id,json=lastEventId,proto3,oneof" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeEventsRequest) Reset() {
+	*x = SubscribeEventsRequest{}
+	mi := &file_metrics_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeEventsRequest) ProtoMessage() {}
+
+func (x *SubscribeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeEventsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SubscribeEventsRequest) GetFilter() *EventFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *SubscribeEventsRequest) GetLastEventId() uint64 {
+	if x != nil && x.LastEventId != nil {
+		return *x.LastEventId
+	}
+	return 0
+}
+
+// One message of an UploadEventStream call. The first message names the producer and the device type of all its events,
+// every message may carry one event. Offsets are chosen by the producer and must grow with every event, events at or
+// below the committed offset of the producer were already stored and are skipped.
+type UploadStreamMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProducerId    string                 `protobuf:"bytes,1,opt,name=producer_id,json=producerId,proto3" json:"producer_id,omitempty"`
+	DeviceType    string                 `protobuf:"bytes,2,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Event         *ClientEventData       `protobuf:"bytes,4,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadStreamMessage) Reset() {
+	*x = UploadStreamMessage{}
+	mi := &file_metrics_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadStreamMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadStreamMessage) ProtoMessage() {}
+
+func (x *UploadStreamMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadStreamMessage.ProtoReflect.Descriptor instead.
+func (*UploadStreamMessage) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *UploadStreamMessage) GetProducerId() string {
+	if x != nil {
+		return x.ProducerId
+	}
+	return ""
+}
+
+func (x *UploadStreamMessage) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+func (x *UploadStreamMessage) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *UploadStreamMessage) GetEvent() *ClientEventData {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// Sent when UploadEventStream opens and after every batch of events it stored. A producer resuming after a disconnect
+// sends its events after committed_offset again.
+type UploadCheckpoint struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProducerId      string                 `protobuf:"bytes,1,opt,name=producer_id,json=producerId,proto3" json:"producer_id,omitempty"`
+	CommittedOffset *uint64                `protobuf:"varint,2,opt,name=committed_offset,json=committedOffset,proto3,oneof" json:"committed_offset,omitempty"`
+	//Events stored and duplicates skipped since the previous checkpoint
+	Events        *int32 `protobuf:"varint,3,opt,name=events,proto3,oneof" json:"events,omitempty"`
+	Skipped       *int32 `protobuf:"varint,4,opt,name=skipped,proto3,oneof" json:"skipped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadCheckpoint) Reset() {
+	*x = UploadCheckpoint{}
+	mi := &file_metrics_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadCheckpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadCheckpoint) ProtoMessage() {}
+
+func (x *UploadCheckpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadCheckpoint.ProtoReflect.Descriptor instead.
+func (*UploadCheckpoint) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *UploadCheckpoint) GetProducerId() string {
+	if x != nil {
+		return x.ProducerId
+	}
+	return ""
+}
+
+func (x *UploadCheckpoint) GetCommittedOffset() uint64 {
+	if x != nil && x.CommittedOffset != nil {
+		return *x.CommittedOffset
+	}
+	return 0
+}
+
+func (x *UploadCheckpoint) GetEvents() int32 {
+	if x != nil && x.Events != nil {
+		return *x.Events
+	}
+	return 0
+}
+
+func (x *UploadCheckpoint) GetSkipped() int32 {
+	if x != nil && x.Skipped != nil {
+		return *x.Skipped
+	}
+	return 0
+}
+
+// Aggregates the measurements called name of the stored events matching filter with timestamps in
+// [from_timestamp, to_timestamp), in buckets of bucket_seconds and one series per distinct value of the group_by
+// dimensions ("event_type", "device_type" or "kv:<key>")
+type MeasurementQueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Filter        *EventFilter           `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	FromTimestamp *int64                 `protobuf:"varint,3,opt,name=from_timestamp,json=fromTimestamp,proto3,oneof" json:"from_timestamp,omitempty"`
+	ToTimestamp   *int64                 `protobuf:"varint,4,opt,name=to_timestamp,json=toTimestamp,proto3,oneof" json:"to_timestamp,omitempty"`
+	BucketSeconds int64                  `protobuf:"varint,5,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"`
+	GroupBy       []string               `protobuf:"bytes,6,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MeasurementQueryRequest) Reset() {
+	*x = MeasurementQueryRequest{}
+	mi := &file_metrics_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MeasurementQueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MeasurementQueryRequest) ProtoMessage() {}
+
+func (x *MeasurementQueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MeasurementQueryRequest.ProtoReflect.Descriptor instead.
+func (*MeasurementQueryRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *MeasurementQueryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MeasurementQueryRequest) GetFilter() *EventFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *MeasurementQueryRequest) GetFromTimestamp() int64 {
+	if x != nil && x.FromTimestamp != nil {
+		return *x.FromTimestamp
+	}
+	return 0
+}
+
+func (x *MeasurementQueryRequest) GetToTimestamp() int64 {
+	if x != nil && x.ToTimestamp != nil {
+		return *x.ToTimestamp
+	}
+	return 0
+}
+
+func (x *MeasurementQueryRequest) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *MeasurementQueryRequest) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+// Statistics of the measurements in [start_timestamp, start_timestamp + bucket_seconds). Percentiles of client
+// histograms are interpolated within their buckets.
+type MeasurementBucket struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	StartTimestamp int64                  `protobuf:"varint,1,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	Count          uint64                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Sum            float64                `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min            float64                `protobuf:"fixed64,4,opt,name=min,proto3" json:"min,omitempty"`
+	Max            float64                `protobuf:"fixed64,5,opt,name=max,proto3" json:"max,omitempty"`
+	Avg            float64                `protobuf:"fixed64,6,opt,name=avg,proto3" json:"avg,omitempty"`
+	P50            float64                `protobuf:"fixed64,7,opt,name=p50,proto3" json:"p50,omitempty"`
+	P95            float64                `protobuf:"fixed64,8,opt,name=p95,proto3" json:"p95,omitempty"`
+	P99            float64                `protobuf:"fixed64,9,opt,name=p99,proto3" json:"p99,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MeasurementBucket) Reset() {
+	*x = MeasurementBucket{}
+	mi := &file_metrics_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MeasurementBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MeasurementBucket) ProtoMessage() {}
+
+func (x *MeasurementBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MeasurementBucket.ProtoReflect.Descriptor instead.
+func (*MeasurementBucket) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *MeasurementBucket) GetStartTimestamp() int64 {
+	if x != nil {
+		return x.StartTimestamp
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetAvg() float64 {
+	if x != nil {
+		return x.Avg
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetP50() float64 {
+	if x != nil {
+		return x.P50
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetP95() float64 {
+	if x != nil {
+		return x.P95
+	}
+	return 0
+}
+
+func (x *MeasurementBucket) GetP99() float64 {
+	if x != nil {
+		return x.P99
+	}
+	return 0
+}
+
+// The buckets of one group, group holds a pair per group_by dimension. Measurements of another unit or kind are
+// never aggregated together, they make a series of their own.
+type MeasurementSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         []*KeyValuePair        `protobuf:"bytes,1,rep,name=group,proto3" json:"group,omitempty"`
+	Unit          string                 `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	Buckets       []*MeasurementBucket   `protobuf:"bytes,3,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Kind          MeasurementKind        `protobuf:"varint,4,opt,name=kind,proto3,enum=meowtrics.v1.MeasurementKind" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MeasurementSeries) Reset() {
+	*x = MeasurementSeries{}
+	mi := &file_metrics_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MeasurementSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MeasurementSeries) ProtoMessage() {}
+
+func (x *MeasurementSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MeasurementSeries.ProtoReflect.Descriptor instead.
+func (*MeasurementSeries) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *MeasurementSeries) GetGroup() []*KeyValuePair {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+func (x *MeasurementSeries) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *MeasurementSeries) GetBuckets() []*MeasurementBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *MeasurementSeries) GetKind() MeasurementKind {
+	if x != nil {
+		return x.Kind
+	}
+	return MeasurementKind_MEASUREMENT_KIND_UNSPECIFIED
+}
+
+type MeasurementQueryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	BucketSeconds int64                  `protobuf:"varint,2,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"`
+	Series        []*MeasurementSeries   `protobuf:"bytes,3,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MeasurementQueryResponse) Reset() {
+	*x = MeasurementQueryResponse{}
+	mi := &file_metrics_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MeasurementQueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MeasurementQueryResponse) ProtoMessage() {}
+
+func (x *MeasurementQueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MeasurementQueryResponse.ProtoReflect.Descriptor instead.
+func (*MeasurementQueryResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *MeasurementQueryResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MeasurementQueryResponse) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *MeasurementQueryResponse) GetSeries() []*MeasurementSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+// Estimated number of distinct values of a kv pair key in one rollup bucket
+type DistinctCountBucket struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	StartTimestamp int64                  `protobuf:"varint,1,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	Estimate       uint64                 `protobuf:"varint,2,opt,name=estimate,proto3" json:"estimate,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DistinctCountBucket) Reset() {
+	*x = DistinctCountBucket{}
+	mi := &file_metrics_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistinctCountBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistinctCountBucket) ProtoMessage() {}
+
+func (x *DistinctCountBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistinctCountBucket.ProtoReflect.Descriptor instead.
+func (*DistinctCountBucket) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *DistinctCountBucket) GetStartTimestamp() int64 {
+	if x != nil {
+		return x.StartTimestamp
+	}
+	return 0
+}
+
+func (x *DistinctCountBucket) GetEstimate() uint64 {
+	if x != nil {
+		return x.Estimate
+	}
+	return 0
+}
+
+// The message returned by the distinct count sketch endpoint. total_estimate counts the distinct values over all
+// buckets, values seen in several buckets count once. relative_standard_error is the standard error of every estimate
+// relative to the true count, about 95% of the estimates are within twice of it.
+type DistinctCountResponse struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Key                   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	BucketSeconds         int64                  `protobuf:"varint,2,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"`
+	Buckets               []*DistinctCountBucket `protobuf:"bytes,3,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	TotalEstimate         uint64                 `protobuf:"varint,4,opt,name=total_estimate,json=totalEstimate,proto3" json:"total_estimate,omitempty"`
+	RelativeStandardError float64                `protobuf:"fixed64,5,opt,name=relative_standard_error,json=relativeStandardError,proto3" json:"relative_standard_error,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *DistinctCountResponse) Reset() {
+	*x = DistinctCountResponse{}
+	mi := &file_metrics_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistinctCountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistinctCountResponse) ProtoMessage() {}
+
+func (x *DistinctCountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistinctCountResponse.ProtoReflect.Descriptor instead.
+func (*DistinctCountResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *DistinctCountResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *DistinctCountResponse) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *DistinctCountResponse) GetBuckets() []*DistinctCountBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *DistinctCountResponse) GetTotalEstimate() uint64 {
+	if x != nil {
+		return x.TotalEstimate
+	}
+	return 0
+}
+
+func (x *DistinctCountResponse) GetRelativeStandardError() float64 {
+	if x != nil {
+		return x.RelativeStandardError
+	}
+	return 0
+}
+
+// Estimated value at a quantile, rank_error is the expected error of its rank as a fraction of the count
+type QuantileEstimate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quantile      float64                `protobuf:"fixed64,1,opt,name=quantile,proto3" json:"quantile,omitempty"`
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	RankError     float64                `protobuf:"fixed64,3,opt,name=rank_error,json=rankError,proto3" json:"rank_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuantileEstimate) Reset() {
+	*x = QuantileEstimate{}
+	mi := &file_metrics_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuantileEstimate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuantileEstimate) ProtoMessage() {}
+
+func (x *QuantileEstimate) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuantileEstimate.ProtoReflect.Descriptor instead.
+func (*QuantileEstimate) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *QuantileEstimate) GetQuantile() float64 {
+	if x != nil {
+		return x.Quantile
+	}
+	return 0
+}
+
+func (x *QuantileEstimate) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *QuantileEstimate) GetRankError() float64 {
+	if x != nil {
+		return x.RankError
+	}
+	return 0
+}
+
+type QuantileBucket struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	StartTimestamp int64                  `protobuf:"varint,1,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	Count          uint64                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Quantiles      []*QuantileEstimate    `protobuf:"bytes,3,rep,name=quantiles,proto3" json:"quantiles,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *QuantileBucket) Reset() {
+	*x = QuantileBucket{}
+	mi := &file_metrics_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuantileBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuantileBucket) ProtoMessage() {}
+
+func (x *QuantileBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuantileBucket.ProtoReflect.Descriptor instead.
+func (*QuantileBucket) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *QuantileBucket) GetStartTimestamp() int64 {
+	if x != nil {
+		return x.StartTimestamp
+	}
+	return 0
+}
+
+func (x *QuantileBucket) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *QuantileBucket) GetQuantiles() []*QuantileEstimate {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+// The message returned by the quantile sketch endpoint, total holds the quantiles over all buckets
+type QuantileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	BucketSeconds int64                  `protobuf:"varint,2,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"`
+	Buckets       []*QuantileBucket      `protobuf:"bytes,3,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	TotalCount    uint64                 `protobuf:"varint,4,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	Total         []*QuantileEstimate    `protobuf:"bytes,5,rep,name=total,proto3" json:"total,omitempty"`
+	Unit          string                 `protobuf:"bytes,6,opt,name=unit,proto3" json:"unit,omitempty"`
+	Kind          MeasurementKind        `protobuf:"varint,7,opt,name=kind,proto3,enum=meowtrics.v1.MeasurementKind" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuantileResponse) Reset() {
+	*x = QuantileResponse{}
+	mi := &file_metrics_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuantileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuantileResponse) ProtoMessage() {}
+
+func (x *QuantileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuantileResponse.ProtoReflect.Descriptor instead.
+func (*QuantileResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *QuantileResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *QuantileResponse) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *QuantileResponse) GetBuckets() []*QuantileBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *QuantileResponse) GetTotalCount() uint64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *QuantileResponse) GetTotal() []*QuantileEstimate {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *QuantileResponse) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *QuantileResponse) GetKind() MeasurementKind {
+	if x != nil {
+		return x.Kind
+	}
+	return MeasurementKind_MEASUREMENT_KIND_UNSPECIFIED
+}
+
+// Event counts per time bucket, or the count, sum, min and max of the measurement called measurement when it is set.
+// The server answers it from the coarsest rollup tier whose buckets line up with bucket_seconds and the time range and
+// that keeps every kv pair key of the filter and group_by, falling back to the stored events.
+type RollupQueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *EventFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	FromTimestamp *int64                 `protobuf:"varint,2,opt,name=from_timestamp,json=fromTimestamp,proto3,oneof" json:"from_timestamp,omitempty"`
+	ToTimestamp   *int64                 `protobuf:"varint,3,opt,name=to_timestamp,json=toTimestamp,proto3,oneof" json:"to_timestamp,omitempty"`
+	BucketSeconds int64                  `protobuf:"varint,4,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"`
+	GroupBy       []string               `protobuf:"bytes,5,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	Measurement   string                 `protobuf:"bytes,6,opt,name=measurement,proto3" json:"measurement,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollupQueryRequest) Reset() {
+	*x = RollupQueryRequest{}
+	mi := &file_metrics_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollupQueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollupQueryRequest) ProtoMessage() {}
+
+func (x *RollupQueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollupQueryRequest.ProtoReflect.Descriptor instead.
+func (*RollupQueryRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *RollupQueryRequest) GetFilter() *EventFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *RollupQueryRequest) GetFromTimestamp() int64 {
+	if x != nil && x.FromTimestamp != nil {
+		return *x.FromTimestamp
+	}
+	return 0
+}
+
+func (x *RollupQueryRequest) GetToTimestamp() int64 {
+	if x != nil && x.ToTimestamp != nil {
+		return *x.ToTimestamp
+	}
+	return 0
+}
+
+func (x *RollupQueryRequest) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *RollupQueryRequest) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+func (x *RollupQueryRequest) GetMeasurement() string {
+	if x != nil {
+		return x.Measurement
+	}
+	return ""
+}
+
+// count is the number of events, or of measurement values when the query names a measurement
+type RollupBucket struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	StartTimestamp int64                  `protobuf:"varint,1,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	Count          uint64                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Sum            float64                `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min            float64                `protobuf:"fixed64,4,opt,name=min,proto3" json:"min,omitempty"`
+	Max            float64                `protobuf:"fixed64,5,opt,name=max,proto3" json:"max,omitempty"`
+	Avg            float64                `protobuf:"fixed64,6,opt,name=avg,proto3" json:"avg,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RollupBucket) Reset() {
+	*x = RollupBucket{}
+	mi := &file_metrics_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollupBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollupBucket) ProtoMessage() {}
+
+func (x *RollupBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollupBucket.ProtoReflect.Descriptor instead.
+func (*RollupBucket) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *RollupBucket) GetStartTimestamp() int64 {
+	if x != nil {
+		return x.StartTimestamp
+	}
+	return 0
+}
+
+func (x *RollupBucket) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *RollupBucket) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *RollupBucket) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *RollupBucket) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *RollupBucket) GetAvg() float64 {
+	if x != nil {
+		return x.Avg
+	}
+	return 0
+}
+
+// The buckets of one group, group holds a pair per group_by dimension. Series of measurement queries are split by the
+// unit and kind of the measurement like MeasurementSeries, event count queries leave them empty.
+type RollupSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         []*KeyValuePair        `protobuf:"bytes,1,rep,name=group,proto3" json:"group,omitempty"`
+	Buckets       []*RollupBucket        `protobuf:"bytes,2,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Unit          string                 `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	Kind          MeasurementKind        `protobuf:"varint,4,opt,name=kind,proto3,enum=meowtrics.v1.MeasurementKind" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollupSeries) Reset() {
+	*x = RollupSeries{}
+	mi := &file_metrics_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollupSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollupSeries) ProtoMessage() {}
+
+func (x *RollupSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollupSeries.ProtoReflect.Descriptor instead.
+func (*RollupSeries) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *RollupSeries) GetGroup() []*KeyValuePair {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+func (x *RollupSeries) GetBuckets() []*RollupBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *RollupSeries) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *RollupSeries) GetKind() MeasurementKind {
+	if x != nil {
+		return x.Kind
+	}
+	return MeasurementKind_MEASUREMENT_KIND_UNSPECIFIED
+}
+
+// tier is the one that answered the query: minute, hour, day or raw for the stored events
+type RollupQueryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tier          string                 `protobuf:"bytes,1,opt,name=tier,proto3" json:"tier,omitempty"`
+	BucketSeconds int64                  `protobuf:"varint,2,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"`
+	Series        []*RollupSeries        `protobuf:"bytes,3,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollupQueryResponse) Reset() {
+	*x = RollupQueryResponse{}
+	mi := &file_metrics_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollupQueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollupQueryResponse) ProtoMessage() {}
+
+func (x *RollupQueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollupQueryResponse.ProtoReflect.Descriptor instead.
+func (*RollupQueryResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *RollupQueryResponse) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
+func (x *RollupQueryResponse) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *RollupQueryResponse) GetSeries() []*RollupSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+// Ordered funnel over the stored events. Events are tied together by the value of their correlation_key kv pair, e.g.
+// user_id. A value enters the funnel with a first step event in [from_timestamp, to_timestamp) and reaches every further
+// step with a later event of that step within window_seconds of entering. Only events matching filter are used.
+type FunnelRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Steps          []ClientEventType      `protobuf:"varint,1,rep,packed,name=steps,proto3,enum=meowtrics.v1.ClientEventType" json:"steps,omitempty"`
+	CorrelationKey string                 `protobuf:"bytes,2,opt,name=correlation_key,json=correlationKey,proto3" json:"correlation_key,omitempty"`
+	WindowSeconds  int64                  `protobuf:"varint,3,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	FromTimestamp  *int64                 `protobuf:"varint,4,opt,name=from_timestamp,json=fromTimestamp,proto3,oneof" json:"from_timestamp,omitempty"`
+	ToTimestamp    *int64                 `protobuf:"varint,5,opt,name=to_timestamp,json=toTimestamp,proto3,oneof" json:"to_timestamp,omitempty"`
+	Filter         *EventFilter           `protobuf:"bytes,6,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FunnelRequest) Reset() {
+	*x = FunnelRequest{}
+	mi := &file_metrics_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FunnelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FunnelRequest) ProtoMessage() {}
+
+func (x *FunnelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FunnelRequest.ProtoReflect.Descriptor instead.
+func (*FunnelRequest) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *FunnelRequest) GetSteps() []ClientEventType {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *FunnelRequest) GetCorrelationKey() string {
+	if x != nil {
+		return x.CorrelationKey
+	}
+	return ""
+}
+
+func (x *FunnelRequest) GetWindowSeconds() int64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+func (x *FunnelRequest) GetFromTimestamp() int64 {
+	if x != nil && x.FromTimestamp != nil {
+		return *x.FromTimestamp
+	}
+	return 0
+}
+
+func (x *FunnelRequest) GetToTimestamp() int64 {
+	if x != nil && x.ToTimestamp != nil {
+		return *x.ToTimestamp
+	}
+	return 0
+}
+
+func (x *FunnelRequest) GetFilter() *EventFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+// count is the number of correlation values that reached the step. conversion_rate is relative to the step before,
+// overall_conversion_rate to the first step, both are 0 when nothing reached the step they are relative to.
+type FunnelStep struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	EventType             ClientEventType        `protobuf:"varint,1,opt,name=event_type,json=eventType,proto3,enum=meowtrics.v1.ClientEventType" json:"event_type,omitempty"`
+	Count                 uint64                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	ConversionRate        float64                `protobuf:"fixed64,3,opt,name=conversion_rate,json=conversionRate,proto3" json:"conversion_rate,omitempty"`
+	OverallConversionRate float64                `protobuf:"fixed64,4,opt,name=overall_conversion_rate,json=overallConversionRate,proto3" json:"overall_conversion_rate,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *FunnelStep) Reset() {
+	*x = FunnelStep{}
+	mi := &file_metrics_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FunnelStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FunnelStep) ProtoMessage() {}
+
+func (x *FunnelStep) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FunnelStep.ProtoReflect.Descriptor instead.
+func (*FunnelStep) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *FunnelStep) GetEventType() ClientEventType {
+	if x != nil {
+		return x.EventType
+	}
+	return ClientEventType_UNSPECIFIED
+}
+
+func (x *FunnelStep) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *FunnelStep) GetConversionRate() float64 {
+	if x != nil {
+		return x.ConversionRate
+	}
+	return 0
+}
+
+func (x *FunnelStep) GetOverallConversionRate() float64 {
+	if x != nil {
+		return x.OverallConversionRate
+	}
+	return 0
+}
+
+type FunnelResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CorrelationKey string                 `protobuf:"bytes,1,opt,name=correlation_key,json=correlationKey,proto3" json:"correlation_key,omitempty"`
+	WindowSeconds  int64                  `protobuf:"varint,2,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	Steps          []*FunnelStep          `protobuf:"bytes,3,rep,name=steps,proto3" json:"steps,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FunnelResponse) Reset() {
+	*x = FunnelResponse{}
+	mi := &file_metrics_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FunnelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FunnelResponse) ProtoMessage() {}
+
+func (x *FunnelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FunnelResponse.ProtoReflect.Descriptor instead.
+func (*FunnelResponse) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *FunnelResponse) GetCorrelationKey() string {
+	if x != nil {
+		return x.CorrelationKey
+	}
+	return ""
+}
+
+func (x *FunnelResponse) GetWindowSeconds() int64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+func (x *FunnelResponse) GetSteps() []*FunnelStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+var File_metrics_proto protoreflect.FileDescriptor
+
+const file_metrics_proto_rawDesc = "" +
+	"\n" +
+	"\rmetrics.proto\x12\fmeowtrics.v1\"\xc4\x02\n" +
+	"\x0fClientEventData\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12<\n" +
+	"\n" +
+	"event_type\x18\x02 \x01(\x0e2\x1d.meowtrics.v1.ClientEventTypeR\teventType\x12!\n" +
+	"\ttimestamp\x18\x03 \x01(\x03H\x00R\ttimestamp\x88\x01\x01\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\tR\x04data\x123\n" +
+	"\akv_pair\x18\x05 \x03(\v2\x1a.meowtrics.v1.KeyValuePairR\x06kvPair\x12=\n" +
+	"\fmeasurements\x18\x06 \x03(\v2\x19.meowtrics.v1.MeasurementR\fmeasurements\x12\x1f\n" +
+	"\vdevice_type\x18\a \x01(\tR\n" +
+	"deviceTypeB\f\n" +
+	"\n" +
+	"_timestamp\"\xb5\x01\n" +
+	"\vMeasurement\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x121\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x1d.meowtrics.v1.MeasurementKindR\x04kind\x12\x12\n" +
+	"\x04unit\x18\x03 \x01(\tR\x04unit\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x125\n" +
+	"\thistogram\x18\x05 \x01(\v2\x17.meowtrics.v1.HistogramR\thistogram\"\xa3\x01\n" +
+	"\tHistogram\x12!\n" +
+	"\fupper_bounds\x18\x01 \x03(\x01R\vupperBounds\x12#\n" +
+	"\rbucket_counts\x18\x02 \x03(\x04R\fbucketCounts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x15\n" +
+	"\x03min\x18\x04 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x05 \x01(\x01H\x01R\x03max\x88\x01\x01B\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"\x91\x01\n" +
+	"\x18ClientEventUploadRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1f\n" +
+	"\vdevice_type\x18\x02 \x01(\tR\n" +
+	"deviceType\x125\n" +
+	"\x06events\x18\x03 \x03(\v2\x1d.meowtrics.v1.ClientEventDataR\x06events\"\x84\x01\n" +
+	"\fKeyValuePair\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x19\n" +
+	"\x05value\x18\x02 \x01(\tH\x00R\x05value\x88\x01\x01\x12=\n" +
+	"\vtyped_value\x18\x03 \x01(\v2\x1c.meowtrics.v1.AttributeValueR\n" +
+	"typedValueB\b\n" +
+	"\x06_value\"\x88\x02\n" +
+	"\x0eAttributeValue\x12#\n" +
+	"\fstring_value\x18\x01 \x01(\tH\x00R\vstringValue\x12\x1d\n" +
+	"\tint_value\x18\x02 \x01(\x03H\x00R\bintValue\x12#\n" +
+	"\fdouble_value\x18\x03 \x01(\x01H\x00R\vdoubleValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x04 \x01(\bH\x00R\tboolValue\x12!\n" +
+	"\vbytes_value\x18\x05 \x01(\fH\x00R\n" +
+	"bytesValue\x12A\n" +
+	"\n" +
+	"list_value\x18\x06 \x01(\v2 .meowtrics.v1.AttributeValueListH\x00R\tlistValueB\x06\n" +
+	"\x04kind\"J\n" +
+	"\x12AttributeValueList\x124\n" +
+	"\x06values\x18\x01 \x03(\v2\x1c.meowtrics.v1.AttributeValueR\x06values\"j\n" +
+	"\rErrorResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"b\n" +
+	"\x0fComponentStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12#\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"\x80\x01\n" +
+	"\tHeartBeat\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\tR\ttimestamp\x12=\n" +
+	"\n" +
+	"components\x18\x03 \x03(\v2\x1d.meowtrics.v1.ComponentStatusR\n" +
+	"components\" \n" +
+	"\bLogLevel\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\"1\n" +
+	"\n" +
+	"EventCount\x12\x19\n" +
+	"\x05count\x18\x01 \x01(\x03H\x00R\x05count\x88\x01\x01B\b\n" +
+	"\x06_count\"d\n" +
+	"\x0eEventTypeCount\x12<\n" +
+	"\n" +
+	"event_type\x18\x01 \x01(\x0e2\x1d.meowtrics.v1.ClientEventTypeR\teventType\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"\xba\x01\n" +
+	"\n" +
+	"StoreStats\x12\x1b\n" +
+	"\x06events\x18\x01 \x01(\x03H\x00R\x06events\x88\x01\x01\x12=\n" +
+	"\vevent_types\x18\x02 \x03(\v2\x1c.meowtrics.v1.EventTypeCountR\n" +
+	"eventTypes\x12/\n" +
+	"\x11uptime_in_seconds\x18\x03 \x01(\x03H\x01R\x0fuptimeInSeconds\x88\x01\x01B\t\n" +
+	"\a_eventsB\x14\n" +
+	"\x12_uptime_in_seconds\"\x93\x01\n" +
+	"\x11KeyValueCondition\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x128\n" +
+	"\n" +
+	"comparison\x18\x02 \x01(\x0e2\x18.meowtrics.v1.ComparisonR\n" +
+	"comparison\x122\n" +
+	"\x05value\x18\x03 \x01(\v2\x1c.meowtrics.v1.AttributeValueR\x05value\"\xed\x01\n" +
+	"\vEventFilter\x12>\n" +
+	"\vevent_types\x18\x01 \x03(\x0e2\x1d.meowtrics.v1.ClientEventTypeR\n" +
+	"eventTypes\x12!\n" +
+	"\fdevice_types\x18\x02 \x03(\tR\vdeviceTypes\x125\n" +
+	"\bkv_pairs\x18\x03 \x03(\v2\x1a.meowtrics.v1.KeyValuePairR\akvPairs\x12D\n" +
+	"\rkv_conditions\x18\x04 \x03(\v2\x1f.meowtrics.v1.KeyValueConditionR\fkvConditions\"\xc4\x01\n" +
+	"\x13WebhookSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06secret\x18\x03 \x01(\tR\x06secret\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x121\n" +
+	"\x06filter\x18\x05 \x01(\v2\x19.meowtrics.v1.EventFilterR\x06filter\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\"b\n" +
+	"\x17WebhookSubscriptionList\x12G\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2!.meowtrics.v1.WebhookSubscriptionR\rsubscriptions\"\xae\x01\n" +
+	"\fWebhookEvent\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12\x1f\n" +
+	"\vdevice_type\x18\x03 \x01(\tR\n" +
+	"deviceType\x123\n" +
+	"\x05event\x18\x04 \x01(\v2\x1d.meowtrics.v1.ClientEventDataR\x05event\"\xae\x01\n" +
+	"\n" +
+	"DeadLetter\x126\n" +
+	"\bdelivery\x18\x01 \x01(\v2\x1a.meowtrics.v1.WebhookEventR\bdelivery\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1a\n" +
+	"\battempts\x18\x03 \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x04 \x01(\tR\tlastError\x12\x1b\n" +
+	"\tfailed_at\x18\x05 \x01(\x03R\bfailedAt\"M\n" +
+	"\x0eDeadLetterList\x12;\n" +
+	"\fdead_letters\x18\x01 \x03(\v2\x18.meowtrics.v1.DeadLetterR\vdeadLetters\"\x94\x01\n" +
+	"\fWebhookState\x12G\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2!.meowtrics.v1.WebhookSubscriptionR\rsubscriptions\x12;\n" +
+	"\fdead_letters\x18\x02 \x03(\v2\x18.meowtrics.v1.DeadLetterR\vdeadLetters\"\x9a\x01\n" +
+	"\rStreamedEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1f\n" +
+	"\vdevice_type\x18\x02 \x01(\tR\n" +
+	"deviceType\x123\n" +
+	"\x05event\x18\x03 \x01(\v2\x1d.meowtrics.v1.ClientEventDataR\x05event\x12#\n" +
+	"\rmissed_events\x18\x04 \x01(\bR\fmissedEvents\"f\n" +
+	"\x14UploadEventsResponse\x12\x1b\n" +
+	"\x06events\x18\x01 \x01(\x05H\x00R\x06events\x88\x01\x01\x12\x1b\n" +
+	"\x06queued\x18\x02 \x01(\bH\x01R\x06queued\x88\x01\x01B\t\n" +
+	"\a_eventsB\t\n" +
+	"\a_queued\",\n" +
+	"\x0fGetEventRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\"\xfb\x01\n" +
+	"\x12QueryEventsRequest\x121\n" +
+	"\x06filter\x18\x01 \x01(\v2\x19.meowtrics.v1.EventFilterR\x06filter\x12*\n" +
+	"\x0efrom_timestamp\x18\x02 \x01(\x03H\x00R\rfromTimestamp\x88\x01\x01\x12&\n" +
+	"\fto_timestamp\x18\x03 \x01(\x03H\x01R\vtoTimestamp\x88\x01\x01\x12$\n" +
+	"\x0eafter_event_id\x18\x04 \x01(\tR\fafterEventId\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limitB\x11\n" +
+	"\x0f_from_timestampB\x0f\n" +
+	"\r_to_timestamp\"{\n" +
+	"\x13QueryEventsResponse\x125\n" +
+	"\x06events\x18\x01 \x03(\v2\x1d.meowtrics.v1.ClientEventDataR\x06events\x12-\n" +
+	"\x13next_after_event_id\x18\x02 \x01(\tR\x10nextAfterEventId\"\x86\x01\n" +
+	"\x16SubscribeEventsRequest\x121\n" +
+	"\x06filter\x18\x01 \x01(\v2\x19.meowtrics.v1.EventFilterR\x06filter\x12'\n" +
+	"\rlast_event_id\x18\x02 \x01(\x04H\x00R\vlastEventId\x88\x01\x01B\x10\n" +
+	"\x0e_last_event_id\"\xa4\x01\n" +
+	"\x13UploadStreamMessage\x12\x1f\n" +
+	"\vproducer_id\x18\x01 \x01(\tR\n" +
+	"producerId\x12\x1f\n" +
+	"\vdevice_type\x18\x02 \x01(\tR\n" +
+	"deviceType\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x123\n" +
+	"\x05event\x18\x04 \x01(\v2\x1d.meowtrics.v1.ClientEventDataR\x05event\"\xcb\x01\n" +
+	"\x10UploadCheckpoint\x12\x1f\n" +
+	"\vproducer_id\x18\x01 \x01(\tR\n" +
+	"producerId\x12.\n" +
+	"\x10committed_offset\x18\x02 \x01(\x04H\x00R\x0fcommittedOffset\x88\x01\x01\x12\x1b\n" +
+	"\x06events\x18\x03 \x01(\x05H\x01R\x06events\x88\x01\x01\x12\x1d\n" +
+	"\askipped\x18\x04 \x01(\x05H\x02R\askipped\x88\x01\x01B\x13\n" +
+	"\x11_committed_offsetB\t\n" +
+	"\a_eventsB\n" +
+	"\n" +
+	"\b_skipped\"\x9a\x02\n" +
+	"\x17MeasurementQueryRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x121\n" +
+	"\x06filter\x18\x02 \x01(\v2\x19.meowtrics.v1.EventFilterR\x06filter\x12*\n" +
+	"\x0efrom_timestamp\x18\x03 \x01(\x03H\x00R\rfromTimestamp\x88\x01\x01\x12&\n" +
+	"\fto_timestamp\x18\x04 \x01(\x03H\x01R\vtoTimestamp\x88\x01\x01\x12%\n" +
+	"\x0ebucket_seconds\x18\x05 \x01(\x03R\rbucketSeconds\x12\x19\n" +
+	"\bgroup_by\x18\x06 \x03(\tR\agroupByB\x11\n" +
+	"\x0f_from_timestampB\x0f\n" +
+	"\r_to_timestamp\"\xd0\x01\n" +
+	"\x11MeasurementBucket\x12'\n" +
+	"\x0fstart_timestamp\x18\x01 \x01(\x03R\x0estartTimestamp\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x04R\x05count\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x10\n" +
+	"\x03min\x18\x04 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x05 \x01(\x01R\x03max\x12\x10\n" +
+	"\x03avg\x18\x06 \x01(\x01R\x03avg\x12\x10\n" +
+	"\x03p50\x18\a \x01(\x01R\x03p50\x12\x10\n" +
+	"\x03p95\x18\b \x01(\x01R\x03p95\x12\x10\n" +
+	"\x03p99\x18\t \x01(\x01R\x03p99\"\xc7\x01\n" +
+	"\x11MeasurementSeries\x120\n" +
+	"\x05group\x18\x01 \x03(\v2\x1a.meowtrics.v1.KeyValuePairR\x05group\x12\x12\n" +
+	"\x04unit\x18\x02 \x01(\tR\x04unit\x129\n" +
+	"\abuckets\x18\x03 \x03(\v2\x1f.meowtrics.v1.MeasurementBucketR\abuckets\x121\n" +
+	"\x04kind\x18\x04 \x01(\x0e2\x1d.meowtrics.v1.MeasurementKindR\x04kind\"\x8e\x01\n" +
+	"\x18MeasurementQueryResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12%\n" +
+	"\x0ebucket_seconds\x18\x02 \x01(\x03R\rbucketSeconds\x127\n" +
+	"\x06series\x18\x03 \x03(\v2\x1f.meowtrics.v1.MeasurementSeriesR\x06series\"Z\n" +
+	"\x13DistinctCountBucket\x12'\n" +
+	"\x0fstart_timestamp\x18\x01 \x01(\x03R\x0estartTimestamp\x12\x1a\n" +
+	"\bestimate\x18\x02 \x01(\x04R\bestimate\"\xec\x01\n" +
+	"\x15DistinctCountResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12%\n" +
+	"\x0ebucket_seconds\x18\x02 \x01(\x03R\rbucketSeconds\x12;\n" +
+	"\abuckets\x18\x03 \x03(\v2!.meowtrics.v1.DistinctCountBucketR\abuckets\x12%\n" +
+	"\x0etotal_estimate\x18\x04 \x01(\x04R\rtotalEstimate\x126\n" +
+	"\x17relative_standard_error\x18\x05 \x01(\x01R\x15relativeStandardError\"c\n" +
+	"\x10QuantileEstimate\x12\x1a\n" +
+	"\bquantile\x18\x01 \x01(\x01R\bquantile\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x12\x1d\n" +
+	"\n" +
+	"rank_error\x18\x03 \x01(\x01R\trankError\"\x8d\x01\n" +
+	"\x0eQuantileBucket\x12'\n" +
+	"\x0fstart_timestamp\x18\x01 \x01(\x03R\x0estartTimestamp\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x04R\x05count\x12<\n" +
+	"\tquantiles\x18\x03 \x03(\v2\x1e.meowtrics.v1.QuantileEstimateR\tquantiles\"\xa5\x02\n" +
+	"\x10QuantileResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12%\n" +
+	"\x0ebucket_seconds\x18\x02 \x01(\x03R\rbucketSeconds\x126\n" +
+	"\abuckets\x18\x03 \x03(\v2\x1c.meowtrics.v1.QuantileBucketR\abuckets\x12\x1f\n" +
+	"\vtotal_count\x18\x04 \x01(\x04R\n" +
+	"totalCount\x124\n" +
+	"\x05total\x18\x05 \x03(\v2\x1e.meowtrics.v1.QuantileEstimateR\x05total\x12\x12\n" +
+	"\x04unit\x18\x06 \x01(\tR\x04unit\x121\n" +
+	"\x04kind\x18\a \x01(\x0e2\x1d.meowtrics.v1.MeasurementKindR\x04kind\"\xa3\x02\n" +
+	"\x12RollupQueryRequest\x121\n" +
+	"\x06filter\x18\x01 \x01(\v2\x19.meowtrics.v1.EventFilterR\x06filter\x12*\n" +
+	"\x0efrom_timestamp\x18\x02 \x01(\x03H\x00R\rfromTimestamp\x88\x01\x01\x12&\n" +
+	"\fto_timestamp\x18\x03 \x01(\x03H\x01R\vtoTimestamp\x88\x01\x01\x12%\n" +
+	"\x0ebucket_seconds\x18\x04 \x01(\x03R\rbucketSeconds\x12\x19\n" +
+	"\bgroup_by\x18\x05 \x03(\tR\agroupBy\x12 \n" +
+	"\vmeasurement\x18\x06 \x01(\tR\vmeasurementB\x11\n" +
+	"\x0f_from_timestampB\x0f\n" +
+	"\r_to_timestamp\"\x95\x01\n" +
+	"\fRollupBucket\x12'\n" +
+	"\x0fstart_timestamp\x18\x01 \x01(\x03R\x0estartTimestamp\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x04R\x05count\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x10\n" +
+	"\x03min\x18\x04 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x05 \x01(\x01R\x03max\x12\x10\n" +
+	"\x03avg\x18\x06 \x01(\x01R\x03avg\"\xbd\x01\n" +
+	"\fRollupSeries\x120\n" +
+	"\x05group\x18\x01 \x03(\v2\x1a.meowtrics.v1.KeyValuePairR\x05group\x124\n" +
+	"\abuckets\x18\x02 \x03(\v2\x1a.meowtrics.v1.RollupBucketR\abuckets\x12\x12\n" +
+	"\x04unit\x18\x03 \x01(\tR\x04unit\x121\n" +
+	"\x04kind\x18\x04 \x01(\x0e2\x1d.meowtrics.v1.MeasurementKindR\x04kind\"\x84\x01\n" +
+	"\x13RollupQueryResponse\x12\x12\n" +
+	"\x04tier\x18\x01 \x01(\tR\x04tier\x12%\n" +
+	"\x0ebucket_seconds\x18\x02 \x01(\x03R\rbucketSeconds\x122\n" +
+	"\x06series\x18\x03 \x03(\v2\x1a.meowtrics.v1.RollupSeriesR\x06series\"\xbf\x02\n" +
+	"\rFunnelRequest\x123\n" +
+	"\x05steps\x18\x01 \x03(\x0e2\x1d.meowtrics.v1.ClientEventTypeR\x05steps\x12'\n" +
+	"\x0fcorrelation_key\x18\x02 \x01(\tR\x0ecorrelationKey\x12%\n" +
+	"\x0ewindow_seconds\x18\x03 \x01(\x03R\rwindowSeconds\x12*\n" +
+	"\x0efrom_timestamp\x18\x04 \x01(\x03H\x00R\rfromTimestamp\x88\x01\x01\x12&\n" +
+	"\fto_timestamp\x18\x05 \x01(\x03H\x01R\vtoTimestamp\x88\x01\x01\x121\n" +
+	"\x06filter\x18\x06 \x01(\v2\x19.meowtrics.v1.EventFilterR\x06filterB\x11\n" +
+	"\x0f_from_timestampB\x0f\n" +
+	"\r_to_timestamp\"\xc1\x01\n" +
+	"\n" +
+	"FunnelStep\x12<\n" +
+	"\n" +
+	"event_type\x18\x01 \x01(\x0e2\x1d.meowtrics.v1.ClientEventTypeR\teventType\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x04R\x05count\x12'\n" +
+	"\x0fconversion_rate\x18\x03 \x01(\x01R\x0econversionRate\x126\n" +
+	"\x17overall_conversion_rate\x18\x04 \x01(\x01R\x15overallConversionRate\"\x90\x01\n" +
+	"\x0eFunnelResponse\x12'\n" +
+	"\x0fcorrelation_key\x18\x01 \x01(\tR\x0ecorrelationKey\x12%\n" +
+	"\x0ewindow_seconds\x18\x02 \x01(\x03R\rwindowSeconds\x12.\n" +
+	"\x05steps\x18\x03 \x03(\v2\x18.meowtrics.v1.FunnelStepR\x05steps*D\n" +
+	"\x0fClientEventType\x12\x0f\n" +
+	"\vUNSPECIFIED\x10\x00\x12\v\n" +
+	"\aUNKNOWN\x10\x01\x12\x13\n" +
+	"\x0fUSER_REGISTERED\x10\x02*\x8a\x01\n" +
+	"\x0fMeasurementKind\x12 \n" +
+	"\x1cMEASUREMENT_KIND_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18MEASUREMENT_KIND_COUNTER\x10\x01\x12\x1a\n" +
+	"\x16MEASUREMENT_KIND_GAUGE\x10\x02\x12\x1b\n" +
+	"\x17MEASUREMENT_KIND_TIMING\x10\x03*\xc4\x01\n" +
+	"\n" +
+	"Comparison\x12\x1a\n" +
+	"\x16COMPARISON_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10COMPARISON_EQUAL\x10\x01\x12\x18\n" +
+	"\x14COMPARISON_NOT_EQUAL\x10\x02\x12\x13\n" +
+	"\x0fCOMPARISON_LESS\x10\x03\x12\x1c\n" +
+	"\x18COMPARISON_LESS_OR_EQUAL\x10\x04\x12\x16\n" +
+	"\x12COMPARISON_GREATER\x10\x05\x12\x1f\n" +
+	"\x1bCOMPARISON_GREATER_OR_EQUAL\x10\x062\xbc\x05\n" +
+	"\tMeowtrics\x12Z\n" +
+	"\fUploadEvents\x12&.meowtrics.v1.ClientEventUploadRequest\x1a\".meowtrics.v1.UploadEventsResponse\x12H\n" +
+	"\bGetEvent\x12\x1d.meowtrics.v1.GetEventRequest\x1a\x1d.meowtrics.v1.ClientEventData\x12R\n" +
+	"\vQueryEvents\x12 .meowtrics.v1.QueryEventsRequest\x1a!.meowtrics.v1.QueryEventsResponse\x12b\n" +
+	"\x11QueryMeasurements\x12%.meowtrics.v1.MeasurementQueryRequest\x1a&.meowtrics.v1.MeasurementQueryResponse\x12S\n" +
+	"\fQueryRollups\x12 .meowtrics.v1.RollupQueryRequest\x1a!.meowtrics.v1.RollupQueryResponse\x12H\n" +
+	"\vQueryFunnel\x12\x1b.meowtrics.v1.FunnelRequest\x1a\x1c.meowtrics.v1.FunnelResponse\x12V\n" +
+	"\x0fSubscribeEvents\x12$.meowtrics.v1.SubscribeEventsRequest\x1a\x1b.meowtrics.v1.StreamedEvent0\x01\x12Z\n" +
+	"\x11UploadEventStream\x12!.meowtrics.v1.UploadStreamMessage\x1a\x1e.meowtrics.v1.UploadCheckpoint(\x010\x01B\x11Z\x0fmeowtrics/modelb\x06proto3"
+
+var (
+	file_metrics_proto_rawDescOnce sync.Once
+	file_metrics_proto_rawDescData []byte
+)
+
+func file_metrics_proto_rawDescGZIP() []byte {
+	file_metrics_proto_rawDescOnce.Do(func() {
+		file_metrics_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_metrics_proto_rawDesc), len(file_metrics_proto_rawDesc)))
+	})
+	return file_metrics_proto_rawDescData
+}
+
+var file_metrics_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 46)
+var file_metrics_proto_goTypes = []any{
+	(ClientEventType)(0),             // 0: meowtrics.v1.ClientEventType
+	(MeasurementKind)(0),             // 1: meowtrics.v1.MeasurementKind
+	(Comparison)(0),                  // 2: meowtrics.v1.Comparison
+	(*ClientEventData)(nil),          // 3: meowtrics.v1.ClientEventData
+	(*Measurement)(nil),              // 4: meowtrics.v1.Measurement
+	(*Histogram)(nil),                // 5: meowtrics.v1.Histogram
+	(*ClientEventUploadRequest)(nil), // 6: meowtrics.v1.ClientEventUploadRequest
+	(*KeyValuePair)(nil),             // 7: meowtrics.v1.KeyValuePair
+	(*AttributeValue)(nil),           // 8: meowtrics.v1.AttributeValue
+	(*AttributeValueList)(nil),       // 9: meowtrics.v1.AttributeValueList
+	(*ErrorResponse)(nil),            // 10: meowtrics.v1.ErrorResponse
+	(*ComponentStatus)(nil),          // 11: meowtrics.v1.ComponentStatus
+	(*HeartBeat)(nil),                // 12: meowtrics.v1.HeartBeat
+	(*LogLevel)(nil),                 // 13: meowtrics.v1.LogLevel
+	(*EventCount)(nil),               // 14: meowtrics.v1.EventCount
+	(*EventTypeCount)(nil),           // 15: meowtrics.v1.EventTypeCount
+	(*StoreStats)(nil),               // 16: meowtrics.v1.StoreStats
+	(*KeyValueCondition)(nil),        // 17: meowtrics.v1.KeyValueCondition
+	(*EventFilter)(nil),              // 18: meowtrics.v1.EventFilter
+	(*WebhookSubscription)(nil),      // 19: meowtrics.v1.WebhookSubscription
+	(*WebhookSubscriptionList)(nil),  // 20: meowtrics.v1.WebhookSubscriptionList
+	(*WebhookEvent)(nil),             // 21: meowtrics.v1.WebhookEvent
+	(*DeadLetter)(nil),               // 22: meowtrics.v1.DeadLetter
+	(*DeadLetterList)(nil),           // 23: meowtrics.v1.DeadLetterList
+	(*WebhookState)(nil),             // 24: meowtrics.v1.WebhookState
+	(*StreamedEvent)(nil),            // 25: meowtrics.v1.StreamedEvent
+	(*UploadEventsResponse)(nil),     // 26: meowtrics.v1.UploadEventsResponse
+	(*GetEventRequest)(nil),          // 27: meowtrics.v1.GetEventRequest
+	(*QueryEventsRequest)(nil),       // 28: meowtrics.v1.QueryEventsRequest
+	(*QueryEventsResponse)(nil),      // 29: meowtrics.v1.QueryEventsResponse
+	(*SubscribeEventsRequest)(nil),   // 30: meowtrics.v1.SubscribeEventsRequest
+	(*UploadStreamMessage)(nil),      // 31: meowtrics.v1.UploadStreamMessage
+	(*UploadCheckpoint)(nil),         // 32: meowtrics.v1.UploadCheckpoint
+	(*MeasurementQueryRequest)(nil),  // 33: meowtrics.v1.MeasurementQueryRequest
+	(*MeasurementBucket)(nil),        // 34: meowtrics.v1.MeasurementBucket
+	(*MeasurementSeries)(nil),        // 35: meowtrics.v1.MeasurementSeries
+	(*MeasurementQueryResponse)(nil), // 36: meowtrics.v1.MeasurementQueryResponse
+	(*DistinctCountBucket)(nil),      // 37: meowtrics.v1.DistinctCountBucket
+	(*DistinctCountResponse)(nil),    // 38: meowtrics.v1.DistinctCountResponse
+	(*QuantileEstimate)(nil),         // 39: meowtrics.v1.QuantileEstimate
+	(*QuantileBucket)(nil),           // 40: meowtrics.v1.QuantileBucket
+	(*QuantileResponse)(nil),         // 41: meowtrics.v1.QuantileResponse
+	(*RollupQueryRequest)(nil),       // 42: meowtrics.v1.RollupQueryRequest
+	(*RollupBucket)(nil),             // 43: meowtrics.v1.RollupBucket
+	(*RollupSeries)(nil),             // 44: meowtrics.v1.RollupSeries
+	(*RollupQueryResponse)(nil),      // 45: meowtrics.v1.RollupQueryResponse
+	(*FunnelRequest)(nil),            // 46: meowtrics.v1.FunnelRequest
+	(*FunnelStep)(nil),               // 47: meowtrics.v1.FunnelStep
+	(*FunnelResponse)(nil),           // 48: meowtrics.v1.FunnelResponse
+}
+var file_metrics_proto_depIdxs = []int32{
+	0,  // 0: meowtrics.v1.ClientEventData.event_type:type_name -> meowtrics.v1.ClientEventType
+	7,  // 1: meowtrics.v1.ClientEventData.kv_pair:type_name -> meowtrics.v1.KeyValuePair
+	4,  // 2: meowtrics.v1.ClientEventData.measurements:type_name -> meowtrics.v1.Measurement
+	1,  // 3: meowtrics.v1.Measurement.kind:type_name -> meowtrics.v1.MeasurementKind
+	5,  // 4: meowtrics.v1.Measurement.histogram:type_name -> meowtrics.v1.Histogram
+	3,  // 5: meowtrics.v1.ClientEventUploadRequest.events:type_name -> meowtrics.v1.ClientEventData
+	8,  // 6: meowtrics.v1.KeyValuePair.typed_value:type_name -> meowtrics.v1.AttributeValue
+	9,  // 7: meowtrics.v1.AttributeValue.list_value:type_name -> meowtrics.v1.AttributeValueList
+	8,  // 8: meowtrics.v1.AttributeValueList.values:type_name -> meowtrics.v1.AttributeValue
+	11, // 9: meowtrics.v1.HeartBeat.components:type_name -> meowtrics.v1.ComponentStatus
+	0,  // 10: meowtrics.v1.EventTypeCount.event_type:type_name -> meowtrics.v1.ClientEventType
+	15, // 11: meowtrics.v1.StoreStats.event_types:type_name -> meowtrics.v1.EventTypeCount
+	2,  // 12: meowtrics.v1.KeyValueCondition.comparison:type_name -> meowtrics.v1.Comparison
+	8,  // 13: meowtrics.v1.KeyValueCondition.value:type_name -> meowtrics.v1.AttributeValue
+	0,  // 14: meowtrics.v1.EventFilter.event_types:type_name -> meowtrics.v1.ClientEventType
+	7,  // 15: meowtrics.v1.EventFilter.kv_pairs:type_name -> meowtrics.v1.KeyValuePair
+	17, // 16: meowtrics.v1.EventFilter.kv_conditions:type_name -> meowtrics.v1.KeyValueCondition
+	18, // 17: meowtrics.v1.WebhookSubscription.filter:type_name -> meowtrics.v1.EventFilter
+	19, // 18: meowtrics.v1.WebhookSubscriptionList.subscriptions:type_name -> meowtrics.v1.WebhookSubscription
+	3,  // 19: meowtrics.v1.WebhookEvent.event:type_name -> meowtrics.v1.ClientEventData
+	21, // 20: meowtrics.v1.DeadLetter.delivery:type_name -> meowtrics.v1.WebhookEvent
+	22, // 21: meowtrics.v1.DeadLetterList.dead_letters:type_name -> meowtrics.v1.DeadLetter
+	19, // 22: meowtrics.v1.WebhookState.subscriptions:type_name -> meowtrics.v1.WebhookSubscription
+	22, // 23: meowtrics.v1.WebhookState.dead_letters:type_name -> meowtrics.v1.DeadLetter
+	3,  // 24: meowtrics.v1.StreamedEvent.event:type_name -> meowtrics.v1.ClientEventData
+	18, // 25: meowtrics.v1.QueryEventsRequest.filter:type_name -> meowtrics.v1.EventFilter
+	3,  // 26: meowtrics.v1.QueryEventsResponse.events:type_name -> meowtrics.v1.ClientEventData
+	18, // 27: meowtrics.v1.SubscribeEventsRequest.filter:type_name -> meowtrics.v1.EventFilter
+	3,  // 28: meowtrics.v1.UploadStreamMessage.event:type_name -> meowtrics.v1.ClientEventData
+	18, // 29: meowtrics.v1.MeasurementQueryRequest.filter:type_name -> meowtrics.v1.EventFilter
+	7,  // 30: meowtrics.v1.MeasurementSeries.group:type_name -> meowtrics.v1.KeyValuePair
+	34, // 31: meowtrics.v1.MeasurementSeries.buckets:type_name -> meowtrics.v1.MeasurementBucket
+	1,  // 32: meowtrics.v1.MeasurementSeries.kind:type_name -> meowtrics.v1.MeasurementKind
+	35, // 33: meowtrics.v1.MeasurementQueryResponse.series:type_name -> meowtrics.v1.MeasurementSeries
+	37, // 34: meowtrics.v1.DistinctCountResponse.buckets:type_name -> meowtrics.v1.DistinctCountBucket
+	39, // 35: meowtrics.v1.QuantileBucket.quantiles:type_name -> meowtrics.v1.QuantileEstimate
+	40, // 36: meowtrics.v1.QuantileResponse.buckets:type_name -> meowtrics.v1.QuantileBucket
+	39, // 37: meowtrics.v1.QuantileResponse.total:type_name -> meowtrics.v1.QuantileEstimate
+	1,  // 38: meowtrics.v1.QuantileResponse.kind:type_name -> meowtrics.v1.MeasurementKind
+	18, // 39: meowtrics.v1.RollupQueryRequest.filter:type_name -> meowtrics.v1.EventFilter
+	7,  // 40: meowtrics.v1.RollupSeries.group:type_name -> meowtrics.v1.KeyValuePair
+	43, // 41: meowtrics.v1.RollupSeries.buckets:type_name -> meowtrics.v1.RollupBucket
+	1,  // 42: meowtrics.v1.RollupSeries.kind:type_name -> meowtrics.v1.MeasurementKind
+	44, // 43: meowtrics.v1.RollupQueryResponse.series:type_name -> meowtrics.v1.RollupSeries
+	0,  // 44: meowtrics.v1.FunnelRequest.steps:type_name -> meowtrics.v1.ClientEventType
+	18, // 45: meowtrics.v1.FunnelRequest.filter:type_name -> meowtrics.v1.EventFilter
+	0,  // 46: meowtrics.v1.FunnelStep.event_type:type_name -> meowtrics.v1.ClientEventType
+	47, // 47: meowtrics.v1.FunnelResponse.steps:type_name -> meowtrics.v1.FunnelStep
+	6,  // 48: meowtrics.v1.Meowtrics.UploadEvents:input_type -> meowtrics.v1.ClientEventUploadRequest
+	27, // 49: meowtrics.v1.Meowtrics.GetEvent:input_type -> meowtrics.v1.GetEventRequest
+	28, // 50: meowtrics.v1.Meowtrics.QueryEvents:input_type -> meowtrics.v1.QueryEventsRequest
+	33, // 51: meowtrics.v1.Meowtrics.QueryMeasurements:input_type -> meowtrics.v1.MeasurementQueryRequest
+	42, // 52: meowtrics.v1.Meowtrics.QueryRollups:input_type -> meowtrics.v1.RollupQueryRequest
+	46, // 53: meowtrics.v1.Meowtrics.QueryFunnel:input_type -> meowtrics.v1.FunnelRequest
+	30, // 54: meowtrics.v1.Meowtrics.SubscribeEvents:input_type -> meowtrics.v1.SubscribeEventsRequest
+	31, // 55: meowtrics.v1.Meowtrics.UploadEventStream:input_type -> meowtrics.v1.UploadStreamMessage
+	26, // 56: meowtrics.v1.Meowtrics.UploadEvents:output_type -> meowtrics.v1.UploadEventsResponse
+	3,  // 57: meowtrics.v1.Meowtrics.GetEvent:output_type -> meowtrics.v1.ClientEventData
+	29, // 58: meowtrics.v1.Meowtrics.QueryEvents:output_type -> meowtrics.v1.QueryEventsResponse
+	36, // 59: meowtrics.v1.Meowtrics.QueryMeasurements:output_type -> meowtrics.v1.MeasurementQueryResponse
+	45, // 60: meowtrics.v1.Meowtrics.QueryRollups:output_type -> meowtrics.v1.RollupQueryResponse
+	48, // 61: meowtrics.v1.Meowtrics.QueryFunnel:output_type -> meowtrics.v1.FunnelResponse
+	25, // 62: meowtrics.v1.Meowtrics.SubscribeEvents:output_type -> meowtrics.v1.StreamedEvent
+	32, // 63: meowtrics.v1.Meowtrics.UploadEventStream:output_type -> meowtrics.v1.UploadCheckpoint
+	56, // [56:64] is the sub-list for method output_type
+	48, // [48:56] is the sub-list for method input_type
+	48, // [48:48] is the sub-list for extension type_name
+	48, // [48:48] is the sub-list for extension extendee
+	0,  // [0:48] is the sub-list for field type_name
+}
+
+func init() { file_metrics_proto_init() }
+func file_metrics_proto_init() {
+	if File_metrics_proto != nil {
+		return
+	}
+	file_metrics_proto_msgTypes[0].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[2].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[4].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[5].OneofWrappers = []any{
+		(*AttributeValue_StringValue)(nil),
+		(*AttributeValue_IntValue)(nil),
+		(*AttributeValue_DoubleValue)(nil),
+		(*AttributeValue_BoolValue)(nil),
+		(*AttributeValue_BytesValue)(nil),
+		(*AttributeValue_ListValue)(nil),
+	}
+	file_metrics_proto_msgTypes[11].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[13].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[23].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[25].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[27].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[29].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[30].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[39].OneofWrappers = []any{}
+	file_metrics_proto_msgTypes[43].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metrics_proto_rawDesc), len(file_metrics_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   46,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_metrics_proto_goTypes,
+		DependencyIndexes: file_metrics_proto_depIdxs,
+		EnumInfos:         file_metrics_proto_enumTypes,
+		MessageInfos:      file_metrics_proto_msgTypes,
+	}.Build()
+	File_metrics_proto = out.File
+	file_metrics_proto_goTypes = nil
+	file_metrics_proto_depIdxs = nil
 }
@@ -0,0 +1,391 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: metrics.proto
+
+package model
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Meowtrics_UploadEvents_FullMethodName      = "/meowtrics.v1.Meowtrics/UploadEvents"
+	Meowtrics_GetEvent_FullMethodName          = "/meowtrics.v1.Meowtrics/GetEvent"
+	Meowtrics_QueryEvents_FullMethodName       = "/meowtrics.v1.Meowtrics/QueryEvents"
+	Meowtrics_QueryMeasurements_FullMethodName = "/meowtrics.v1.Meowtrics/QueryMeasurements"
+	Meowtrics_QueryRollups_FullMethodName      = "/meowtrics.v1.Meowtrics/QueryRollups"
+	Meowtrics_QueryFunnel_FullMethodName       = "/meowtrics.v1.Meowtrics/QueryFunnel"
+	Meowtrics_SubscribeEvents_FullMethodName   = "/meowtrics.v1.Meowtrics/SubscribeEvents"
+	Meowtrics_UploadEventStream_FullMethodName = "/meowtrics.v1.Meowtrics/UploadEventStream"
+)
+
+// MeowtricsClient is the client API for Meowtrics service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The gRPC API, served on grpcPort next to the HTTP API
+type MeowtricsClient interface {
+	UploadEvents(ctx context.Context, in *ClientEventUploadRequest, opts ...grpc.CallOption) (*UploadEventsResponse, error)
+	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*ClientEventData, error)
+	QueryEvents(ctx context.Context, in *QueryEventsRequest, opts ...grpc.CallOption) (*QueryEventsResponse, error)
+	QueryMeasurements(ctx context.Context, in *MeasurementQueryRequest, opts ...grpc.CallOption) (*MeasurementQueryResponse, error)
+	QueryRollups(ctx context.Context, in *RollupQueryRequest, opts ...grpc.CallOption) (*RollupQueryResponse, error)
+	QueryFunnel(ctx context.Context, in *FunnelRequest, opts ...grpc.CallOption) (*FunnelResponse, error)
+	SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamedEvent], error)
+	//Takes an unbounded stream of events from one producer, answered with a checkpoint for every stored batch
+	UploadEventStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UploadStreamMessage, UploadCheckpoint], error)
+}
+
+type meowtricsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMeowtricsClient(cc grpc.ClientConnInterface) MeowtricsClient {
+	return &meowtricsClient{cc}
+}
+
+func (c *meowtricsClient) UploadEvents(ctx context.Context, in *ClientEventUploadRequest, opts ...grpc.CallOption) (*UploadEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadEventsResponse)
+	err := c.cc.Invoke(ctx, Meowtrics_UploadEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meowtricsClient) GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*ClientEventData, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClientEventData)
+	err := c.cc.Invoke(ctx, Meowtrics_GetEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meowtricsClient) QueryEvents(ctx context.Context, in *QueryEventsRequest, opts ...grpc.CallOption) (*QueryEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryEventsResponse)
+	err := c.cc.Invoke(ctx, Meowtrics_QueryEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meowtricsClient) QueryMeasurements(ctx context.Context, in *MeasurementQueryRequest, opts ...grpc.CallOption) (*MeasurementQueryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MeasurementQueryResponse)
+	err := c.cc.Invoke(ctx, Meowtrics_QueryMeasurements_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meowtricsClient) QueryRollups(ctx context.Context, in *RollupQueryRequest, opts ...grpc.CallOption) (*RollupQueryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RollupQueryResponse)
+	err := c.cc.Invoke(ctx, Meowtrics_QueryRollups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meowtricsClient) QueryFunnel(ctx context.Context, in *FunnelRequest, opts ...grpc.CallOption) (*FunnelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FunnelResponse)
+	err := c.cc.Invoke(ctx, Meowtrics_QueryFunnel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meowtricsClient) SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamedEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Meowtrics_ServiceDesc.Streams[0], Meowtrics_SubscribeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeEventsRequest, StreamedEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Meowtrics_SubscribeEventsClient = grpc.ServerStreamingClient[StreamedEvent]
+
+func (c *meowtricsClient) UploadEventStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UploadStreamMessage, UploadCheckpoint], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Meowtrics_ServiceDesc.Streams[1], Meowtrics_UploadEventStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadStreamMessage, UploadCheckpoint]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Meowtrics_UploadEventStreamClient = grpc.BidiStreamingClient[UploadStreamMessage, UploadCheckpoint]
+
+// MeowtricsServer is the server API for Meowtrics service.
+// All implementations must embed UnimplementedMeowtricsServer
+// for forward compatibility.
+//
+// The gRPC API, served on grpcPort next to the HTTP API
+type MeowtricsServer interface {
+	UploadEvents(context.Context, *ClientEventUploadRequest) (*UploadEventsResponse, error)
+	GetEvent(context.Context, *GetEventRequest) (*ClientEventData, error)
+	QueryEvents(context.Context, *QueryEventsRequest) (*QueryEventsResponse, error)
+	QueryMeasurements(context.Context, *MeasurementQueryRequest) (*MeasurementQueryResponse, error)
+	QueryRollups(context.Context, *RollupQueryRequest) (*RollupQueryResponse, error)
+	QueryFunnel(context.Context, *FunnelRequest) (*FunnelResponse, error)
+	SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[StreamedEvent]) error
+	//Takes an unbounded stream of events from one producer, answered with a checkpoint for every stored batch
+	UploadEventStream(grpc.BidiStreamingServer[UploadStreamMessage, UploadCheckpoint]) error
+	mustEmbedUnimplementedMeowtricsServer()
+}
+
+// UnimplementedMeowtricsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMeowtricsServer struct{}
+
+func (UnimplementedMeowtricsServer) UploadEvents(context.Context, *ClientEventUploadRequest) (*UploadEventsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UploadEvents not implemented")
+}
+func (UnimplementedMeowtricsServer) GetEvent(context.Context, *GetEventRequest) (*ClientEventData, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetEvent not implemented")
+}
+func (UnimplementedMeowtricsServer) QueryEvents(context.Context, *QueryEventsRequest) (*QueryEventsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryEvents not implemented")
+}
+func (UnimplementedMeowtricsServer) QueryMeasurements(context.Context, *MeasurementQueryRequest) (*MeasurementQueryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryMeasurements not implemented")
+}
+func (UnimplementedMeowtricsServer) QueryRollups(context.Context, *RollupQueryRequest) (*RollupQueryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryRollups not implemented")
+}
+func (UnimplementedMeowtricsServer) QueryFunnel(context.Context, *FunnelRequest) (*FunnelResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryFunnel not implemented")
+}
+func (UnimplementedMeowtricsServer) SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[StreamedEvent]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
+func (UnimplementedMeowtricsServer) UploadEventStream(grpc.BidiStreamingServer[UploadStreamMessage, UploadCheckpoint]) error {
+	return status.Error(codes.Unimplemented, "method UploadEventStream not implemented")
+}
+func (UnimplementedMeowtricsServer) mustEmbedUnimplementedMeowtricsServer() {}
+func (UnimplementedMeowtricsServer) testEmbeddedByValue()                   {}
+
+// UnsafeMeowtricsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MeowtricsServer will
+// result in compilation errors.
+type UnsafeMeowtricsServer interface {
+	mustEmbedUnimplementedMeowtricsServer()
+}
+
+func RegisterMeowtricsServer(s grpc.ServiceRegistrar, srv MeowtricsServer) {
+	// If the following call panics, it indicates UnimplementedMeowtricsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Meowtrics_ServiceDesc, srv)
+}
+
+func _Meowtrics_UploadEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientEventUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeowtricsServer).UploadEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meowtrics_UploadEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeowtricsServer).UploadEvents(ctx, req.(*ClientEventUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Meowtrics_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeowtricsServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meowtrics_GetEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeowtricsServer).GetEvent(ctx, req.(*GetEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Meowtrics_QueryEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeowtricsServer).QueryEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meowtrics_QueryEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeowtricsServer).QueryEvents(ctx, req.(*QueryEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Meowtrics_QueryMeasurements_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MeasurementQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeowtricsServer).QueryMeasurements(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meowtrics_QueryMeasurements_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeowtricsServer).QueryMeasurements(ctx, req.(*MeasurementQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Meowtrics_QueryRollups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollupQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeowtricsServer).QueryRollups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meowtrics_QueryRollups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeowtricsServer).QueryRollups(ctx, req.(*RollupQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Meowtrics_QueryFunnel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FunnelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeowtricsServer).QueryFunnel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meowtrics_QueryFunnel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeowtricsServer).QueryFunnel(ctx, req.(*FunnelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Meowtrics_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MeowtricsServer).SubscribeEvents(m, &grpc.GenericServerStream[SubscribeEventsRequest, StreamedEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Meowtrics_SubscribeEventsServer = grpc.ServerStreamingServer[StreamedEvent]
+
+func _Meowtrics_UploadEventStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MeowtricsServer).UploadEventStream(&grpc.GenericServerStream[UploadStreamMessage, UploadCheckpoint]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Meowtrics_UploadEventStreamServer = grpc.BidiStreamingServer[UploadStreamMessage, UploadCheckpoint]
+
+// Meowtrics_ServiceDesc is the grpc.ServiceDesc for Meowtrics service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Meowtrics_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "meowtrics.v1.Meowtrics",
+	HandlerType: (*MeowtricsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UploadEvents",
+			Handler:    _Meowtrics_UploadEvents_Handler,
+		},
+		{
+			MethodName: "GetEvent",
+			Handler:    _Meowtrics_GetEvent_Handler,
+		},
+		{
+			MethodName: "QueryEvents",
+			Handler:    _Meowtrics_QueryEvents_Handler,
+		},
+		{
+			MethodName: "QueryMeasurements",
+			Handler:    _Meowtrics_QueryMeasurements_Handler,
+		},
+		{
+			MethodName: "QueryRollups",
+			Handler:    _Meowtrics_QueryRollups_Handler,
+		},
+		{
+			MethodName: "QueryFunnel",
+			Handler:    _Meowtrics_QueryFunnel_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _Meowtrics_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadEventStream",
+			Handler:       _Meowtrics_UploadEventStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "metrics.proto",
+}
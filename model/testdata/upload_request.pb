@@ -0,0 +1,5 @@
+
+9f2c1eandroid0
+evt-1д"signup via email*
+countryNZ
+evt-2д
\ No newline at end of file
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/context"
+)
+
+const (
+	REQUEST_ID_HEADER   = "X-Request-ID"
+	maxRequestIdLength  = 128
+	notAvailableLogText = "-"
+)
+
+//Per request state shared between the access log middleware and the handlers. The middleware keeps its own
+//pointer, so the state survives the router clearing the request context before the access log line is written.
+type requestState struct {
+	requestId       string
+	uploadRequestId string
+	logger          *log.Entry
+}
+
+//Negroni middleware assigning or propagating X-Request-ID and writing one structured access log line per request.
+//The X-Request-ID is logged as httpRequestId, requestId stays the request_id of the upload request as in processor.go
+type AccessLogger struct {
+	logger *log.Logger
+}
+
+func NewAccessLogger(logger *log.Logger) *AccessLogger {
+	return &AccessLogger{logger: logger}
+}
+
+func (al *AccessLogger) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+
+	requestId := req.Header.Get(REQUEST_ID_HEADER)
+	if !isValidRequestId(requestId) {
+		requestId = newRequestId()
+	}
+	req.Header.Set(REQUEST_ID_HEADER, requestId)
+	w.Header().Set(REQUEST_ID_HEADER, requestId)
+
+	state := &requestState{requestId: requestId, logger: al.logger.WithFields(log.Fields{"httpRequestId": requestId})}
+	context.Set(req, requestStateKey, state)
+
+	next(w, req)
+
+	status, size := http.StatusOK, 0
+	if res, ok := w.(negroni.ResponseWriter); ok {
+		if res.Status() != 0 {
+			status = res.Status()
+		}
+		size = res.Size()
+	}
+
+	uploadRequestId := state.uploadRequestId
+	if uploadRequestId == "" {
+		uploadRequestId = notAvailableLogText
+	}
+
+	al.logger.WithFields(log.Fields{
+		"type":          "access",
+		"httpRequestId": requestId,
+		"method":        req.Method,
+		"path":          req.URL.Path,
+		"remoteAddr":    req.RemoteAddr,
+		"status":        status,
+		"bytes":         size,
+		"latencyMs":     float64(time.Since(start).Nanoseconds()) / float64(time.Millisecond),
+		"contentType":   req.Header.Get("Content-Type"),
+		"requestId":     uploadRequestId,
+	}).Infoln("Request completed")
+}
+
+//Only printable ASCII ids are propagated, anything else would end up verbatim in the logs
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < 0x21 || requestId[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+//Returns the request scoped logger, falling back to fallback for requests that bypassed the access log middleware
+func requestLogger(req *http.Request, fallback *log.Logger) *log.Entry {
+	if state, ok := context.Get(req, requestStateKey).(*requestState); ok {
+		return state.logger
+	}
+	return log.NewEntry(fallback)
+}
+
+//Records the upload's request_id for the access log line
+func setUploadRequestId(req *http.Request, uploadRequestId string) {
+	if state, ok := context.Get(req, requestStateKey).(*requestState); ok {
+		state.uploadRequestId = uploadRequestId
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/negroni"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestAccessLogger() (*negroni.Negroni, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.Out = &buf
+	logger.Formatter = new(log.JSONFormatter)
+
+	n := negroni.New(NewAccessLogger(logger))
+	n.UseHandler(testServer.router)
+	return n, &buf
+}
+
+//Decodes one JSON log line per entry
+func parseLogLines(buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			panic("Error unmarshalling log line: " + err.Error())
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func TestAccessLogger_GeneratesRequestId(t *testing.T) {
+	n, buf := generateTestAccessLogger()
+
+	req, _ := http.NewRequest("GET", "/heartbeat", nil)
+	w := httptest.NewRecorder()
+	n.ServeHTTP(w, req)
+
+	requestId := w.Header().Get(REQUEST_ID_HEADER)
+	assert.Equal(t, 32, len(requestId), "Generated request id should be returned")
+
+	lines := parseLogLines(buf)
+	assert.Equal(t, 1, len(lines), "One access log line should be written")
+	assert.Equal(t, requestId, lines[0]["httpRequestId"], "Access log should have the request id")
+	assert.Equal(t, float64(http.StatusOK), lines[0]["status"], "Access log should have the status")
+}
+
+func TestAccessLogger_PropagatesRequestId(t *testing.T) {
+	n, _ := generateTestAccessLogger()
+
+	req, _ := http.NewRequest("GET", "/heartbeat", nil)
+	req.Header.Set(REQUEST_ID_HEADER, "meow-123")
+	w := httptest.NewRecorder()
+	n.ServeHTTP(w, req)
+	assert.Equal(t, "meow-123", w.Header().Get(REQUEST_ID_HEADER), "Valid request id should be propagated")
+
+	req, _ = http.NewRequest("GET", "/heartbeat", nil)
+	req.Header.Set(REQUEST_ID_HEADER, "meow 123\n")
+	w = httptest.NewRecorder()
+	n.ServeHTTP(w, req)
+	assert.NotEqual(t, "meow 123\n", w.Header().Get(REQUEST_ID_HEADER), "Invalid request id should be replaced")
+}
+
+func TestAccessLogger_UploadRequestId(t *testing.T) {
+	n, buf := generateTestAccessLogger()
+	uploadReq := generateTestClientEventUploadRequest_Valid()
+	jsonReq, err := model.MarshalJSON(uploadReq)
+	if err != nil {
+		panic("Cannot marshal data. Error: " + err.Error())
+	}
+
+	req, _ := http.NewRequest("POST", "/v1/events", strings.NewReader(string(jsonReq)))
+	req.Header.Set("Content-Type", APPLICATION_JSON)
+	req.Header.Set(REQUEST_ID_HEADER, "meow-456")
+	n.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := parseLogLines(buf)
+	access := lines[len(lines)-1]
+	assert.Equal(t, "access", access["type"], "Last line should be the access log line")
+	assert.Equal(t, "testRequestId", access["requestId"], "Access log should have the upload request_id")
+	assert.Equal(t, APPLICATION_JSON, access["contentType"], "Access log should have the content type")
+
+	for _, line := range lines {
+		assert.Equal(t, "meow-456", line["httpRequestId"], "Every log line should have the request id")
+	}
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"meowtrics/model"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+//Decides whether a request may use the admin endpoints
+type AuthFunc func(req *http.Request) bool
+
+//Guarded since a config reload can change it while requests are served
+type adminKey struct {
+	mutex sync.RWMutex
+	key   string
+}
+
+func (ak *adminKey) set(key string) {
+	ak.mutex.Lock()
+	ak.key = key
+	ak.mutex.Unlock()
+}
+
+func (ak *adminKey) get() string {
+	ak.mutex.RLock()
+	defer ak.mutex.RUnlock()
+	return ak.key
+}
+
+//Client certificate subjects let through by the admin or gRPC auth, guarded like adminKey
+type certSubjects struct {
+	mutex    sync.RWMutex
+	subjects map[string]bool
+}
+
+func (cs *certSubjects) set(subjects []string) {
+	allowed := make(map[string]bool)
+	for _, subject := range subjects {
+		allowed[subject] = true
+	}
+	cs.mutex.Lock()
+	cs.subjects = allowed
+	cs.mutex.Unlock()
+}
+
+func (cs *certSubjects) allowed(subject string) bool {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	return subject != "" && cs.subjects[subject]
+}
+
+func (cs *certSubjects) empty() bool {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	return len(cs.subjects) == 0
+}
+
+//Default admin auth: a verified client certificate with a subject in adminCertSubjects, or the adminApiKey from the
+//config in the X-API-Key header. No admin access without either of them.
+func (s *Server) checkAdminApiKey(req *http.Request) bool {
+	if s.adminCertSubjects.allowed(CertificateSubject(req)) {
+		return true
+	}
+	key := s.adminApiKey.get()
+	apiKey := req.Header.Get(API_KEY_HEADER)
+	return key != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1
+}
+
+//Admin endpoints are only reachable for requests accepted by the server's AuthFunc, see WithAuth
+func (s *Server) AdminAuthHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !s.auth(req) {
+			s.RequestLogger(req).WithFields(log.Fields{"method": "AdminAuthHandler", "error": UnauthorizedError.Error(), "path": req.URL.Path}).Warningln("Rejected admin request")
+
+			writeJSON(w, http.StatusUnauthorized, &model.ErrorResponse{Code: Unauthorized, ErrorMessage: "Admin API key is missing or invalid"})
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+//GET returns the current log level of the server's logger, PUT changes it without a restart
+func (s *Server) LogLevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "PUT" {
+			logLevel := new(model.LogLevel)
+			if err := s.decodeJSONBody(req, logLevel); err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: MalformedRequest, ErrorMessage: "Request body contains malformed JSON", Description: err.Error()})
+				return
+			}
+
+			level, err := log.ParseLevel(logLevel.GetLevel())
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Unknown log level", Description: "Log levels: debug, info, warning, error, fatal, panic"})
+				return
+			}
+
+			s.RequestLogger(req).WithFields(log.Fields{"method": "LogLevelHandler", "from": s.logLevel.get().String(), "to": level.String()}).Warningln("Changing log level")
+			s.logLevel.set(level)
+		}
+
+		writeJSON(w, http.StatusOK, &model.LogLevel{Level: s.logLevel.get().String()})
+	})
+}
+
+//Streams every stored event as newline delimited JSON
+func (s *Server) ExportEventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		events := s.store.AllEvents()
+		s.RequestLogger(req).WithFields(log.Fields{"method": "ExportEventsHandler", "events": len(events)}).Infoln("Exporting events")
+
+		w.Header().Set("Content-Type", APPLICATION_NDJSON)
+		w.WriteHeader(http.StatusOK)
+		for _, event := range events {
+			data, err := model.MarshalJSON(event)
+			if err == nil {
+				_, err = w.Write(append(data, '\n'))
+			}
+			if err != nil {
+				s.RequestLogger(req).WithFields(log.Fields{"method": "ExportEventsHandler", "error": err.Error()}).Errorln("Error writing export")
+				return
+			}
+		}
+	})
+}
+
+//Stores newline delimited JSON events as written by the export endpoint. All events are validated before the first is
+//stored, a storage error keeps the events stored before it
+func (s *Server) ImportEventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var events []*model.ClientEventData
+		decoder := json.NewDecoder(req.Body)
+		for decoder.More() {
+			var line json.RawMessage
+			event := new(model.ClientEventData)
+			if err := decoder.Decode(&line); err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: MalformedRequest, ErrorMessage: "Request body contains malformed JSON"})
+				return
+			}
+			if err := s.unmarshalJSON(line, event); err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: MalformedRequest, ErrorMessage: "Request body contains malformed JSON", Description: "Event " + strconv.Itoa(len(events)+1) + ": " + err.Error()})
+				return
+			}
+			if event.GetEventId() == "" {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Missing event_id", Description: "Event " + strconv.Itoa(len(events)+1) + " has no event_id"})
+				return
+			}
+			if err := validateEventFields(event); err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Missing required field", Description: "Event " + strconv.Itoa(len(events)+1) + ": " + err.Error()})
+				return
+			}
+			if _, err := validateKvPairs([]*model.ClientEventData{event}); err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid kv pair", Description: "Event " + strconv.Itoa(len(events)+1) + ": " + err.Error()})
+				return
+			}
+			if _, err := validateMeasurements([]*model.ClientEventData{event}); err != nil {
+				writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid measurement", Description: "Event " + strconv.Itoa(len(events)+1) + ": " + err.Error()})
+				return
+			}
+			events = append(events, event)
+		}
+
+		//Events replacing a stored one are already in the rollups and sketches
+		var added []*model.ClientEventData
+		for _, event := range events {
+			setRequiredFields(event)
+			_, err := s.store.RetrieveEvent(event.GetEventId())
+			replaced := err == nil
+			if err := s.store.StoreEvent(event); err != nil {
+				s.RequestLogger(req).WithFields(log.Fields{"method": "ImportEventsHandler", "error": err.Error()}).Errorln("Error storing imported event")
+
+				s.observeStoredEvents(added)
+				writeJSON(w, http.StatusInternalServerError, &model.ErrorResponse{Code: Fatal, ErrorMessage: "Error storing events, aborting"})
+				return
+			}
+			if !replaced {
+				added = append(added, event)
+			}
+		}
+		s.observeStoredEvents(added)
+		s.RequestLogger(req).WithFields(log.Fields{"method": "ImportEventsHandler", "events": len(events)}).Infoln("Imported events")
+
+		writeJSON(w, http.StatusOK, &model.EventCount{Count: proto.Int64(int64(len(events)))})
+	})
+}
+
+func (s *Server) CompactEventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count := int64(s.store.Compact())
+		s.RequestLogger(req).WithFields(log.Fields{"method": "CompactEventsHandler", "events": count}).Infoln("Compacted datastore")
+		writeJSON(w, http.StatusOK, &model.EventCount{Count: &count})
+	})
+}
+
+func (s *Server) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		counts := s.store.CountEventsByType()
+		stats := new(model.StoreStats)
+
+		var eventTypes []int
+		for eventType := range counts {
+			eventTypes = append(eventTypes, int(eventType))
+		}
+		sort.Ints(eventTypes)
+
+		var total int64
+		for _, value := range eventTypes {
+			eventType := model.ClientEventType(value)
+			count := int64(counts[eventType])
+			stats.EventTypes = append(stats.EventTypes, &model.EventTypeCount{EventType: eventType, Count: count})
+			total += count
+		}
+		uptime := int64(time.Since(s.startedAt).Seconds())
+		stats.Events = &total
+		stats.UptimeInSeconds = &uptime
+
+		writeJSON(w, http.StatusOK, stats)
+	})
+}
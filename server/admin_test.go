@@ -0,0 +1,112 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuthHandler(t *testing.T) {
+	defer testServer.adminApiKey.set("")
+	handler := testServer.AdminAuthHandler(testServer.HeartBeatHandler())
+
+	req, _ := http.NewRequest("GET", "/admin/loglevel", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Admin API should be disabled without adminApiKey")
+
+	testServer.adminApiKey.set("testAdminKey")
+	req.Header.Set(API_KEY_HEADER, "wrongKey")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Wrong API key should be rejected")
+
+	req.Header.Set(API_KEY_HEADER, "testAdminKey")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Admin API key should be accepted")
+}
+
+func TestAdminAuthHandler_CertSubjects(t *testing.T) {
+	s := newTestServer(t)
+	s.adminCertSubjects.set([]string{"CN=ops,O=Meowtrics"})
+	handler := s.AdminAuthHandler(s.HeartBeatHandler())
+
+	req, _ := http.NewRequest("GET", "/admin/loglevel", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "ops", Organization: []string{"Meowtrics"}}}}}}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Allowed certificate subjects should be accepted without an API key")
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "meow-client"}}}}}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Other certificate subjects should be rejected")
+}
+
+func TestImportEventsHandler_InvalidMeasurement(t *testing.T) {
+	store := NewMemoryStore()
+	s := newTestServer(t, WithStore(store))
+
+	body := `{"event_id":"1","event_type":"UNKNOWN","timestamp":"1500000000"}
+{"event_id":"2","event_type":"UNKNOWN","timestamp":"1500000000","measurements":[{"value":1}]}
+`
+	req, _ := http.NewRequest("POST", "/admin/events", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.ImportEventsHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Events with an invalid measurement should be rejected")
+	assert.Contains(t, w.Body.String(), "Event 2: measurement name is required")
+	_, err := store.RetrieveEvent("1")
+	assert.NotNil(t, err, "No event should be stored when one is invalid")
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	defer testServer.logLevel.set(testServer.logLevel.get())
+
+	req, _ := http.NewRequest("PUT", "/admin/loglevel", strings.NewReader(`{"level": "debug"}`))
+	w := httptest.NewRecorder()
+	testServer.LogLevelHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Valid log level should be accepted")
+	assert.Equal(t, log.DebugLevel, testServer.logLevel.get(), "Log level should be changed")
+
+	logLevel := new(model.LogLevel)
+	err := model.UnmarshalJSON(w.Body.Bytes(), logLevel)
+	if err != nil {
+		panic("Error unmarshalling json response: " + err.Error())
+	}
+	assert.Equal(t, "debug", logLevel.GetLevel(), "Response should have the new level")
+
+	req, _ = http.NewRequest("PUT", "/admin/loglevel", strings.NewReader(`{"level": "meow"}`))
+	w = httptest.NewRecorder()
+	testServer.LogLevelHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Unknown log level should be rejected")
+	assert.Equal(t, log.DebugLevel, testServer.logLevel.get(), "Log level should not be changed")
+}
+
+//Run with -race, the level is changed while requests are logged
+func TestLogLevelHandler_ConcurrentLogging(t *testing.T) {
+	s := newTestServer(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			s.logger.WithFields(log.Fields{"method": "TestLogLevelHandler_ConcurrentLogging"}).Debugln("logging")
+		}
+	}()
+	for _, level := range []string{"debug", "warning", "info"} {
+		req, _ := http.NewRequest("PUT", "/admin/loglevel", strings.NewReader(`{"level": "`+level+`"}`))
+		w := httptest.NewRecorder()
+		s.LogLevelHandler().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	<-done
+	assert.Equal(t, log.InfoLevel, s.logLevel.get())
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"meowtrics/model"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+//Value of a kv pair, the typed_value when set and the string value of old clients otherwise
+func kvPairAttribute(pair *model.KeyValuePair) *model.AttributeValue {
+	if typed := pair.GetTypedValue(); typed.GetKind() != nil {
+		return typed
+	}
+	return stringAttribute(pair.GetValue())
+}
+
+func stringAttribute(value string) *model.AttributeValue {
+	return &model.AttributeValue{Kind: &model.AttributeValue_StringValue{StringValue: value}}
+}
+
+//Finds the value of the first kv pair of an event with the given key
+func eventAttribute(event *model.ClientEventData, key string) (*model.AttributeValue, bool) {
+	for _, pair := range event.GetKvPair() {
+		if pair.GetKey() == key {
+			return kvPairAttribute(pair), true
+		}
+	}
+	return nil, false
+}
+
+//Decimal numbers as old clients stringify them, hex, Inf and NaN are no numbers
+var decimalNumber = regexp.MustCompile(`^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+//Numeric value of int and double values. Strings holding a finite decimal number count as numbers too, so values
+//stringified by old clients can still be compared with typed ones and summed.
+func attributeNumber(value *model.AttributeValue) (float64, bool) {
+	switch kind := value.GetKind().(type) {
+	case *model.AttributeValue_IntValue:
+		return float64(kind.IntValue), true
+	case *model.AttributeValue_DoubleValue:
+		return kind.DoubleValue, true
+	case *model.AttributeValue_StringValue:
+		text := strings.TrimSpace(kind.StringValue)
+		if !decimalNumber.MatchString(text) {
+			return 0, false
+		}
+		//Fails for numbers out of the float64 range
+		number, err := strconv.ParseFloat(text, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func isTypedNumber(value *model.AttributeValue) bool {
+	switch value.GetKind().(type) {
+	case *model.AttributeValue_IntValue, *model.AttributeValue_DoubleValue:
+		return true
+	}
+	return false
+}
+
+//Rejects typed double values that are NaN or infinite, returns the index of the first event with one
+func validateKvPairs(events []*model.ClientEventData) (int, error) {
+	for i, event := range events {
+		for _, pair := range event.GetKvPair() {
+			if !attributeFinite(pair.GetTypedValue()) {
+				return i, errors.New("kv pair " + pair.GetKey() + " has a double value that is not a finite number")
+			}
+		}
+	}
+	return -1, nil
+}
+
+func attributeFinite(value *model.AttributeValue) bool {
+	switch kind := value.GetKind().(type) {
+	case *model.AttributeValue_DoubleValue:
+		return isFinite(kind.DoubleValue)
+	case *model.AttributeValue_ListValue:
+		for _, element := range kind.ListValue.GetValues() {
+			if !attributeFinite(element) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//String form of a value as used for metric labels and string comparisons. Bytes are base64 encoded like in JSON and
+//list elements are joined with commas.
+func attributeString(value *model.AttributeValue) string {
+	switch kind := value.GetKind().(type) {
+	case *model.AttributeValue_StringValue:
+		return kind.StringValue
+	case *model.AttributeValue_IntValue:
+		return strconv.FormatInt(kind.IntValue, 10)
+	case *model.AttributeValue_DoubleValue:
+		return formatValue(kind.DoubleValue)
+	case *model.AttributeValue_BoolValue:
+		return strconv.FormatBool(kind.BoolValue)
+	case *model.AttributeValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(kind.BytesValue)
+	case *model.AttributeValue_ListValue:
+		elements := make([]string, len(kind.ListValue.GetValues()))
+		for i, element := range kind.ListValue.GetValues() {
+			elements[i] = attributeString(element)
+		}
+		return strings.Join(elements, ",")
+	}
+	return ""
+}
+
+//Orders two numeric values, integers are compared exactly. At least one of them has to be a typed int or double, two
+//strings are never compared as numbers so "02134" stays different from "2134". The second result is false if the
+//values can't be compared.
+func compareAttributes(a *model.AttributeValue, b *model.AttributeValue) (int, bool) {
+	aInt, aIsInt := a.GetKind().(*model.AttributeValue_IntValue)
+	bInt, bIsInt := b.GetKind().(*model.AttributeValue_IntValue)
+	if aIsInt && bIsInt {
+		switch {
+		case aInt.IntValue < bInt.IntValue:
+			return -1, true
+		case aInt.IntValue > bInt.IntValue:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	if !isTypedNumber(a) && !isTypedNumber(b) {
+		return 0, false
+	}
+	aNumber, aOk := attributeNumber(a)
+	bNumber, bOk := attributeNumber(b)
+	if !aOk || !bOk {
+		return 0, false
+	}
+	switch {
+	case aNumber < bNumber:
+		return -1, true
+	case aNumber > bNumber:
+		return 1, true
+	}
+	return 0, true
+}
+
+//Typed numbers are equal to numbers by value and lists element by element, anything else by its string form so a typed
+//true still equals the "true" of an old client and bytes equal their base64 string
+func attributesEqual(a *model.AttributeValue, b *model.AttributeValue) bool {
+	if order, ok := compareAttributes(a, b); ok {
+		return order == 0
+	}
+	if a.GetListValue() != nil || b.GetListValue() != nil {
+		return proto.Equal(a, b)
+	}
+	return attributeString(a) == attributeString(b)
+}
+
+//Checks the kv pair of an event against a filter condition, events without the key never match
+func kvConditionMatches(condition *model.KeyValueCondition, event *model.ClientEventData) bool {
+	value, ok := eventAttribute(event, condition.GetKey())
+	if !ok {
+		return false
+	}
+
+	switch condition.GetComparison() {
+	case model.Comparison_COMPARISON_UNSPECIFIED, model.Comparison_COMPARISON_EQUAL:
+		return attributesEqual(value, condition.GetValue())
+	case model.Comparison_COMPARISON_NOT_EQUAL:
+		return !attributesEqual(value, condition.GetValue())
+	}
+
+	order, ok := compareAttributes(value, condition.GetValue())
+	if !ok {
+		return false
+	}
+	switch condition.GetComparison() {
+	case model.Comparison_COMPARISON_LESS:
+		return order < 0
+	case model.Comparison_COMPARISON_LESS_OR_EQUAL:
+		return order <= 0
+	case model.Comparison_COMPARISON_GREATER:
+		return order > 0
+	case model.Comparison_COMPARISON_GREATER_OR_EQUAL:
+		return order >= 0
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"math"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestKvPairAttribute_StringCompatibility(t *testing.T) {
+	assert.Equal(t, "NZ", kvPairAttribute(&model.KeyValuePair{Key: "country", Value: proto.String("NZ")}).GetStringValue(), "String pairs should keep their value")
+
+	typed := &model.KeyValuePair{Key: "count", Value: proto.String("ignored"), TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 7}}}
+	assert.Equal(t, int64(7), kvPairAttribute(typed).GetIntValue(), "The typed value should take precedence")
+}
+
+func TestAttributeString(t *testing.T) {
+	list := &model.AttributeValue{Kind: &model.AttributeValue_ListValue{ListValue: &model.AttributeValueList{Values: []*model.AttributeValue{
+		{Kind: &model.AttributeValue_IntValue{IntValue: 1}},
+		{Kind: &model.AttributeValue_DoubleValue{DoubleValue: 2.5}},
+		{Kind: &model.AttributeValue_BoolValue{BoolValue: false}},
+		{Kind: &model.AttributeValue_BytesValue{BytesValue: []byte("hi")}},
+	}}}}
+	assert.Equal(t, "1,2.5,false,aGk=", attributeString(list))
+	assert.Equal(t, "", attributeString(nil))
+}
+
+func TestCompareAttributes(t *testing.T) {
+	big := &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 1<<62 + 1}}
+	bigger := &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 1<<62 + 2}}
+	order, ok := compareAttributes(big, bigger)
+	assert.True(t, ok)
+	assert.Equal(t, -1, order, "Integers should be compared exactly")
+
+	order, ok = compareAttributes(&model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: 2.5}}, stringAttribute("2"))
+	assert.True(t, ok)
+	assert.Equal(t, 1, order, "Doubles should compare with stringified numbers")
+
+	_, ok = compareAttributes(stringAttribute("NZ"), stringAttribute("AU"))
+	assert.False(t, ok, "Strings that aren't numbers can't be ordered")
+	_, ok = compareAttributes(stringAttribute("2"), stringAttribute("10"))
+	assert.False(t, ok, "Two strings should not be compared as numbers")
+
+	typed := &model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: 100}}
+	for _, text := range []string{"Inf", "NaN", "0x64", "1e400", "1_000"} {
+		_, ok = compareAttributes(typed, stringAttribute(text))
+		assert.False(t, ok, "Only finite decimal strings should count as numbers: "+text)
+	}
+	order, ok = compareAttributes(typed, stringAttribute(" 1e2"))
+	assert.True(t, ok)
+	assert.Equal(t, 0, order)
+}
+
+func TestAttributesEqual_Strings(t *testing.T) {
+	assert.False(t, attributesEqual(stringAttribute("02134"), stringAttribute("2134")), "Strings should be compared exactly")
+	assert.False(t, attributesEqual(stringAttribute("1e2"), stringAttribute("100")))
+	assert.True(t, attributesEqual(stringAttribute("NZ"), stringAttribute("NZ")))
+	assert.True(t, attributesEqual(&model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 2134}}, stringAttribute("02134")),
+		"Typed numbers should equal stringified numbers by value")
+}
+
+func TestAttributesEqual_Lists(t *testing.T) {
+	list := func(values ...int64) *model.AttributeValue {
+		elements := make([]*model.AttributeValue, len(values))
+		for i, value := range values {
+			elements[i] = &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: value}}
+		}
+		return &model.AttributeValue{Kind: &model.AttributeValue_ListValue{ListValue: &model.AttributeValueList{Values: elements}}}
+	}
+	assert.True(t, attributesEqual(list(1, 2), list(1, 2)))
+	assert.False(t, attributesEqual(list(1, 2), list(2, 1)), "List order should matter")
+	assert.False(t, attributesEqual(list(1, 2), stringAttribute("1,2")), "Lists should only equal lists")
+}
+
+func TestValidateKvPairs(t *testing.T) {
+	double := func(value float64) *model.AttributeValue {
+		return &model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: value}}
+	}
+	valid := &model.ClientEventData{KvPair: []*model.KeyValuePair{{Key: "ratio", TypedValue: double(0.5)}, {Key: "country", Value: proto.String("NaN")}}}
+	_, err := validateKvPairs([]*model.ClientEventData{valid})
+	assert.NoError(t, err, "Finite doubles and strings should be valid")
+
+	nested := &model.AttributeValue{Kind: &model.AttributeValue_ListValue{ListValue: &model.AttributeValueList{Values: []*model.AttributeValue{double(1), double(math.Inf(-1))}}}}
+	for _, value := range []*model.AttributeValue{double(math.NaN()), double(math.Inf(1)), nested} {
+		index, err := validateKvPairs([]*model.ClientEventData{valid, {KvPair: []*model.KeyValuePair{{Key: "ratio", TypedValue: value}}}})
+		assert.Error(t, err, "Non-finite doubles should be rejected")
+		assert.Equal(t, 1, index)
+	}
+}
+
+func TestCreateEventHandler_NonFiniteKvPair(t *testing.T) {
+	s := newTestServer(t, WithStore(NewMemoryStore()))
+	req, _ := http.NewRequest("POST", "/v1/events", strings.NewReader(`{"request_id": "r", "device_type": "android", "events": [{"event_id": "1", "event_type": "UNKNOWN", "timestamp": "1452548890", "kv_pair": [{"key": "amount", "typed_value": {"double_value": "Infinity"}}]}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Non-finite doubles should be rejected")
+	assert.Equal(t, 0, s.store.CountEvents())
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"meowtrics/model"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+)
+
+const cliUsage = `Usage: meowtrics <command> [flags]
+
+Commands:
+  serve             start the server (default), takes the config flags
+  config print      print the effective config with secrets hidden
+  config validate   check the config and exit
+  export            write every stored event of a running server as newline delimited JSON
+  import            load newline delimited JSON events into a running server
+  compact           rebuild the datastore of a running server to give back memory
+  stats             print event counts and uptime of a running server
+  keys create       generate a random API key
+
+Run meowtrics <command> -h for the flags of a command.
+`
+
+//Runs the meowtrics command in args and returns the exit code: 0 on success, 1 on failure and 2 on usage errors
+func RunCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+		if (command == "config" || command == "keys") && len(args) > 0 {
+			command, args = command+" "+args[0], args[1:]
+		}
+	}
+
+	var err error
+	switch command {
+	case "serve":
+		err = serveCommand(args)
+	case "config print", "config validate":
+		err = configCommand(command, args, stdout)
+	case "export":
+		err = exportCommand(args, stdout, stderr)
+	case "import":
+		err = importCommand(args, stdin, stdout, stderr)
+	case "compact":
+		err = compactCommand(args, stdout, stderr)
+	case "stats":
+		err = statsCommand(args, stdout, stderr)
+	case "keys create":
+		err = keysCreateCommand(args, stdout, stderr)
+	case "help":
+		fmt.Fprint(stdout, cliUsage)
+		return 0
+	default:
+		fmt.Fprint(stderr, "Unknown command: "+command+"\n\n"+cliUsage)
+		return 2
+	}
+
+	if err == flag.ErrHelp {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+//Reads the config file, the MEOWTRICS_* environment variables and the command line flags in args.
+//Problems are returned instead of logged since the logger isn't configured yet.
+func loadConfig(args []string) (*Config, error) {
+	flags, err := flagProperties("meowtrics", args)
+	if err != nil {
+		return nil, err
+	}
+	overrides := []appProperties{flags, envProperties(os.Environ())}
+
+	bootstrapLogger := log.New()
+	bootstrapLogger.Out = ioutil.Discard
+	if err := LoadAppProperties(configFileName, DeploymentConfigDefaultPath, bootstrapLogger); err != nil {
+		return nil, errors.New("Error reading app properties: " + err.Error())
+	}
+
+	config, err := LoadConfig(effectiveProperties(overrides, viperProperties{}))
+	if err != nil {
+		return nil, err
+	}
+	config.fileName = viper.ConfigFileUsed()
+	config.overrides = overrides
+	return config, nil
+}
+
+func serveCommand(args []string) error {
+	config, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+	server, err := NewServer(WithConfig(config))
+	if err != nil {
+		return err
+	}
+	return server.ListenAndServe()
+}
+
+func configCommand(command string, args []string, stdout io.Writer) error {
+	config, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+	if command == "config print" {
+		return config.Print(stdout)
+	}
+	fmt.Fprintln(stdout, "Config is valid")
+	return nil
+}
+
+func keysCreateCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	length := flags.Int("bytes", 32, "random bytes in the key, the key is hex encoded")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *length < 16 {
+		return errors.New("Keys need at least 16 random bytes")
+	}
+
+	b := make([]byte, *length)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, hex.EncodeToString(b))
+	return nil
+}
+
+func exportCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := flags.String("out", "", "file to write the events to, defaults to stdout")
+	client, err := newAdminClient(flags, args, stderr)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.do("GET", "/admin/events", nil, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	w := stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+func importCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	in := flags.String("in", "", "file with newline delimited JSON events, defaults to stdin")
+	client, err := newAdminClient(flags, args, stderr)
+	if err != nil {
+		return err
+	}
+
+	r := stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	count := new(model.EventCount)
+	if err := client.doJSON("POST", "/admin/events", r, APPLICATION_NDJSON, count); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "Imported "+strconv.FormatInt(count.GetCount(), 10)+" events")
+	return nil
+}
+
+func compactCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	client, err := newAdminClient(flag.NewFlagSet("compact", flag.ContinueOnError), args, stderr)
+	if err != nil {
+		return err
+	}
+
+	count := new(model.EventCount)
+	if err := client.doJSON("POST", "/admin/compact", nil, "", count); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "Compacted datastore holds "+strconv.FormatInt(count.GetCount(), 10)+" events")
+	return nil
+}
+
+func statsCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	client, err := newAdminClient(flag.NewFlagSet("stats", flag.ContinueOnError), args, stderr)
+	if err != nil {
+		return err
+	}
+
+	stats := new(model.StoreStats)
+	if err := client.doJSON("GET", "/admin/stats", nil, "", stats); err != nil {
+		return err
+	}
+	options := model.JSONMarshalOptions
+	options.Multiline, options.Indent = true, "    "
+	data, err := options.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(append(data, '\n'))
+	return err
+}
+
+//Client for the admin API of a running server, by default the one described by the local config
+type adminClient struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+//Adds the -url and -apiKey flags to flags and parses args
+func newAdminClient(flags *flag.FlagSet, args []string, stderr io.Writer) (*adminClient, error) {
+	config, err := loadConfig(nil)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if config.TLS.Enabled {
+		scheme = "https"
+	}
+
+	flags.SetOutput(stderr)
+	url := flags.String("url", scheme+"://localhost:"+strconv.Itoa(config.AppPort), "address of the running server")
+	apiKey := flags.String("apiKey", config.AdminApiKey, "admin API key, defaults to adminApiKey from the config")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	return &adminClient{url: strings.TrimRight(*url, "/"), apiKey: *apiKey, client: http.DefaultClient}, nil
+}
+
+//Sends the request and turns error responses into errors, the caller closes the response body
+func (ac *adminClient) do(method string, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, ac.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(API_KEY_HEADER, ac.apiKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := ac.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		errResp := new(model.ErrorResponse)
+		data, err := ioutil.ReadAll(res.Body)
+		if err != nil || model.UnmarshalJSON(data, errResp) != nil || errResp.GetCode() == "" {
+			return nil, errors.New(method + " " + path + ": " + res.Status)
+		}
+		return nil, errors.New(errResp.GetCode() + ": " + errResp.GetErrorMessage())
+	}
+	return res, nil
+}
+
+func (ac *adminClient) doJSON(method string, path string, body io.Reader, contentType string, response proto.Message) error {
+	res, err := ac.do(method, path, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return model.UnmarshalJSON(data, response)
+}
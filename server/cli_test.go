@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"meowtrics/model"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runTestCommand(args ...string) (int, string, string) {
+	return runTestCommandWithInput("", args...)
+}
+
+func runTestCommandWithInput(input string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := RunCommand(args, strings.NewReader(input), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunCommand_Usage(t *testing.T) {
+	code, _, stderr := runTestCommand("purr")
+	assert.Equal(t, 2, code, "Unknown commands should be a usage error")
+	assert.Contains(t, stderr, "Unknown command: purr", "Unknown command should be reported")
+
+	code, stdout, _ := runTestCommand("help")
+	assert.Equal(t, 0, code, "Help should succeed")
+	assert.Contains(t, stdout, "keys create", "Help should list the commands")
+}
+
+func TestRunCommand_ConfigValidate(t *testing.T) {
+	code, stdout, _ := runTestCommand("config", "validate")
+	assert.Equal(t, 0, code, "Config file should be valid")
+	assert.Contains(t, stdout, "Config is valid")
+
+	code, _, stderr := runTestCommand("config", "validate", "-appPort", "0")
+	assert.Equal(t, 1, code, "Invalid config should fail")
+	assert.Contains(t, stderr, "appPort", "Invalid setting should be reported")
+}
+
+func TestRunCommand_KeysCreate(t *testing.T) {
+	code, first, _ := runTestCommand("keys", "create")
+	assert.Equal(t, 0, code, "Error creating key")
+	assert.Equal(t, 65, len(first), "Key should be 32 hex encoded bytes and a newline")
+
+	_, second, _ := runTestCommand("keys", "create")
+	assert.NotEqual(t, first, second, "Keys should be random")
+
+	code, _, _ = runTestCommand("keys", "create", "-bytes", "4")
+	assert.Equal(t, 1, code, "Short keys should be rejected")
+}
+
+func TestRunCommand_AdminCommands(t *testing.T) {
+	defer testServer.adminApiKey.set(testServer.adminApiKey.get())
+	testServer.adminApiKey.set("testAdminKey")
+	ts := httptest.NewServer(testServer.Handler())
+	defer ts.Close()
+
+	resetTestStore()
+	first := generateTestUserRegisteredEvent("CA")
+	second := generateTestUserRegisteredEvent("IN")
+	second.EventId = "124"
+	testStore.StoreEvent(first)
+	testStore.StoreEvent(second)
+
+	code, export, stderr := runTestCommand("export", "-url", ts.URL, "-apiKey", "testAdminKey")
+	assert.Equal(t, 0, code, "Error exporting: "+stderr)
+	assert.Equal(t, 2, strings.Count(export, "\n"), "Every event should be exported on its own line")
+
+	code, _, stderr = runTestCommand("export", "-url", ts.URL, "-apiKey", "wrongKey")
+	assert.Equal(t, 1, code, "Wrong admin API key should fail")
+	assert.Contains(t, stderr, Unauthorized, "Error code should be reported")
+
+	resetTestStore()
+	code, stdout, stderr := runTestCommandWithInput(export, "import", "-url", ts.URL, "-apiKey", "testAdminKey")
+	assert.Equal(t, 0, code, "Error importing: "+stderr)
+	assert.Contains(t, stdout, "Imported 2 events")
+	_, err := testStore.RetrieveEvent("124")
+	assert.NoError(t, err, "Imported event should be stored")
+
+	code, _, _ = runTestCommandWithInput(`{"event_type": 1}`, "import", "-url", ts.URL, "-apiKey", "testAdminKey")
+	assert.Equal(t, 1, code, "Events without event_id should be rejected")
+
+	code, stdout, _ = runTestCommand("compact", "-url", ts.URL, "-apiKey", "testAdminKey")
+	assert.Equal(t, 0, code, "Error compacting")
+	assert.Contains(t, stdout, "holds 2 events")
+
+	code, stdout, _ = runTestCommand("stats", "-url", ts.URL, "-apiKey", "testAdminKey")
+	assert.Equal(t, 0, code, "Error reading stats")
+	stats := new(model.StoreStats)
+	assert.NoError(t, model.UnmarshalJSON([]byte(stdout), stats), "Stats should be JSON")
+	assert.Equal(t, int64(2), stats.GetEvents(), "Stats should count the stored events")
+	assert.Equal(t, model.ClientEventType_USER_REGISTERED, stats.GetEventTypes()[0].GetEventType(), "Stats should count per event type")
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"meowtrics/server"
+	"os"
+)
+
+func main() {
+	os.Exit(server.RunCommand(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,513 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/spf13/cast"
+)
+
+const (
+	ENV_PREFIX       = "MEOWTRICS_"
+	hiddenSecretText = "******"
+)
+
+//Every scalar setting of meowtricsConfig.json with its default. Each one can be overridden by a MEOWTRICS_* environment
+//variable, e.g. MEOWTRICS_APP_PORT for appPort, and by a command line flag with the same name as the key, e.g. -appPort.
+type configProperty struct {
+	key          string
+	defaultValue string
+	usage        string
+	secret       bool
+}
+
+var configProperties = []configProperty{
+	{key: "appPort", defaultValue: "3003", usage: "port to listen on"},
+	{key: "grpcPort", defaultValue: "0", usage: "port the gRPC API listens on, 0 disables it"},
+	{key: "grpcUploadCheckpointEvents", defaultValue: "500", usage: "events an upload stream stores and acknowledges at a time"},
+	{key: "grpcUploadCheckpointIntervalInMs", defaultValue: "1000", usage: "longest time events of an upload stream wait before they are stored and acknowledged"},
+	{key: "grpcUploadOffsetFile", defaultValue: "", usage: "file keeping the committed offsets of upload stream producers across restarts, empty keeps them in memory only"},
+	{key: "appGracefulShutdownTimeinSeconds", defaultValue: "10", usage: "time given to in flight requests on shutdown"},
+	{key: "jsonLenient", defaultValue: "true", usage: "also accept enums as numbers and 64 bit integers as JSON numbers in JSON requests"},
+	{key: "configReloadPollIntervalInSeconds", defaultValue: "5", usage: "how often the config file is checked for changes"},
+	{key: "ingestionAsync", defaultValue: "false", usage: "queue uploads and answer 202 Accepted instead of storing them before answering"},
+	{key: "ingestionQueueSize", defaultValue: "1000", usage: "upload requests the async ingestion queue holds before answering 503"},
+	{key: "ingestionWorkers", defaultValue: "4", usage: "workers writing queued uploads to the datastore"},
+	{key: "ingestionBatchSize", defaultValue: "100", usage: "events a worker writes at a time"},
+	{key: "ingestionRetryAfterInSeconds", defaultValue: "1", usage: "Retry-After sent with 503 when the ingestion queue is full"},
+	{key: "ingestionQueueDir", defaultValue: "", usage: "directory keeping queued uploads on disk until they are stored, empty keeps them in memory only"},
+	{key: "ingestionQueueSegmentSizeInMB", defaultValue: "64", usage: "size of the ingestion queue segment files"},
+	{key: "ingestionQueueSync", defaultValue: "true", usage: "fsync every queued upload before answering 202"},
+	{key: "webhookFile", defaultValue: "", usage: "file keeping webhook subscriptions and dead letters across restarts, empty keeps them in memory only"},
+	{key: "webhookWorkers", defaultValue: "2", usage: "workers posting events to webhooks"},
+	{key: "webhookQueueSize", defaultValue: "1000", usage: "webhook deliveries waiting for a worker before new ones are dead lettered"},
+	{key: "webhookTimeoutInSeconds", defaultValue: "10", usage: "time a webhook has to answer a delivery"},
+	{key: "webhookMaxAttempts", defaultValue: "5", usage: "attempts at a webhook delivery before it is dead lettered"},
+	{key: "webhookInitialBackoffInMs", defaultValue: "500", usage: "wait before the first retry of a failed webhook delivery, doubled for every further retry"},
+	{key: "webhookMaxBackoffInSeconds", defaultValue: "60", usage: "longest wait between webhook delivery retries"},
+	{key: "webhookDeadLetterLimit", defaultValue: "1000", usage: "dead letters kept, the oldest ones are dropped first"},
+	{key: "streamBufferSize", defaultValue: "256", usage: "events buffered per event stream subscriber before it is disconnected as too slow"},
+	{key: "streamReplaySize", defaultValue: "1000", usage: "latest events kept for event stream clients resuming with Last-Event-ID"},
+	{key: "streamMaxSubscribers", defaultValue: "100", usage: "concurrent event stream subscribers, 0 allows any number"},
+	{key: "streamKeepAliveInSeconds", defaultValue: "15", usage: "how often idle event streams get a keep-alive"},
+	{key: "streamWebSocketEnabled", defaultValue: "false", usage: "also serve the event stream over WebSocket"},
+	{key: "sketchBucketSeconds", defaultValue: "3600", usage: "width of the time buckets of the distinct count and quantile sketches"},
+	{key: "sketchRetentionInHours", defaultValue: "168", usage: "how long sketch buckets are kept"},
+	{key: "sketchDistinctKeys", defaultValue: "user_id", usage: "comma separated list of kv pair keys whose distinct values are counted"},
+	{key: "sketchQuantileValues", defaultValue: "", usage: "comma separated list of measurement names and kv:<key> values whose quantiles are sketched"},
+	{key: "sketchHllPrecision", defaultValue: "14", usage: "HyperLogLog precision, 2^precision bytes per sketch with a relative standard error of 1.04/sqrt(2^precision)"},
+	{key: "sketchTdigestCompression", defaultValue: "100", usage: "t-digest compression, higher values are more accurate and use more memory"},
+	{key: "metricsDeviceTypes", defaultValue: "android,ios,web", usage: "comma separated list of device types labelled in the ingestion metrics, others are counted as \"other\""},
+	{key: "rollupDimensionKeys", defaultValue: "", usage: "comma separated list of kv pair keys the minute, hour and day rollups are kept per, each value adds a rollup per bucket"},
+	{key: "rollupMinuteRetentionInHours", defaultValue: "24", usage: "how long minute rollups are kept"},
+	{key: "rollupHourRetentionInDays", defaultValue: "31", usage: "how long hour rollups are kept"},
+	{key: "rollupDayRetentionInDays", defaultValue: "730", usage: "how long day rollups are kept"},
+	{key: "rawEventRetentionInHours", defaultValue: "0", usage: "how long stored events are kept, 0 keeps them forever"},
+	{key: "shutdownFlushTimeoutInSeconds", defaultValue: "10", usage: "time given to flushing the ingestion queues on shutdown"},
+	{key: "shutdownStoreTimeoutInSeconds", defaultValue: "10", usage: "time given to flushing and closing the datastore on shutdown"},
+	{key: "shutdownWorkersTimeoutInSeconds", defaultValue: "5", usage: "time given to stopping the background workers on shutdown"},
+	{key: "readinessDrainDelayInSeconds", defaultValue: "0", usage: "time /readyz reports not ready on shutdown before connections are closed"},
+	{key: "readinessMinFreeDiskInMB", defaultValue: "100", usage: "free disk space below which /readyz reports the datastore disk as failing"},
+	{key: "rateLimitIpRequestsPerSecond", defaultValue: "0", usage: "requests per second per client IP, 0 disables the limit"},
+	{key: "rateLimitIpBurst", defaultValue: "0", usage: "request burst per client IP"},
+	{key: "rateLimitIpEventsPerMinute", defaultValue: "0", usage: "uploaded events per minute per client IP, 0 disables the limit"},
+	{key: "rateLimitApiKeyRequestsPerSecond", defaultValue: "0", usage: "requests per second per API key, 0 disables the limit"},
+	{key: "rateLimitApiKeyBurst", defaultValue: "0", usage: "request burst per API key"},
+	{key: "rateLimitApiKeyEventsPerMinute", defaultValue: "0", usage: "uploaded events per minute per API key, 0 disables the limit"},
+	{key: "rateLimitTenantRequestsPerSecond", defaultValue: "0", usage: "requests per second per tenant, 0 disables the limit"},
+	{key: "rateLimitTenantBurst", defaultValue: "0", usage: "request burst per tenant"},
+	{key: "rateLimitTenantEventsPerMinute", defaultValue: "0", usage: "uploaded events per minute per tenant, 0 disables the limit"},
+	{key: "tlsEnabled", defaultValue: "false", usage: "serve over TLS"},
+	{key: "tlsCertFile", defaultValue: "", usage: "TLS certificate file"},
+	{key: "tlsKeyFile", defaultValue: "", usage: "TLS private key file"},
+	{key: "tlsClientCAFile", defaultValue: "", usage: "CA bundle for client certificates, turns on mutual TLS"},
+	{key: "tlsCertReloadIntervalInSeconds", defaultValue: "60", usage: "how often the certificate files are checked for changes"},
+	{key: "logLevel", defaultValue: "info", usage: "debug, info, warning, error, fatal or panic"},
+	{key: "logFormat", defaultValue: "json", usage: "json or text"},
+	{key: "logOutputs", defaultValue: "file", usage: "comma separated list of file, stdout and stderr"},
+	{key: "logFileName", defaultValue: "log-meowtrics.log", usage: "log file"},
+	{key: "logMaxSizeInMB", defaultValue: "0", usage: "rotate the log file past this size, 0 disables size rotation"},
+	{key: "logRotateIntervalInHours", defaultValue: "0", usage: "rotate the log file after this many hours, 0 disables age rotation"},
+	{key: "logMaxBackups", defaultValue: "0", usage: "rotated log files to keep, 0 keeps all"},
+	{key: "logCompress", defaultValue: "false", usage: "gzip rotated log files"},
+	{key: "adminApiKey", defaultValue: "", usage: "API key for the admin endpoints, empty disables them", secret: true},
+	{key: "grpcApiKey", defaultValue: "", usage: "API key for the gRPC API, empty lets every call through", secret: true},
+	{key: "adminCertSubjects", defaultValue: "", usage: "semicolon separated list of verified client certificate subjects allowed to use the admin endpoints, e.g. CN=ops,O=Meowtrics"},
+	{key: "grpcCertSubjects", defaultValue: "", usage: "semicolon separated list of verified client certificate subjects allowed to call the gRPC API"},
+}
+
+//Async ingestion, uploads are stored by a worker pool after the client got 202 Accepted
+type IngestionSettings struct {
+	Async               bool
+	QueueSize           int
+	Workers             int
+	BatchSize           int
+	RetryAfterInSeconds int
+	QueueDir            string
+	SegmentSizeInMB     int
+	Sync                bool
+}
+
+//Timeouts of the shutdown stages after the in flight requests, which get AppGracefulShutdownTimeinSeconds
+type ShutdownSettings struct {
+	FlushTimeoutInSeconds   int
+	StoreTimeoutInSeconds   int
+	WorkersTimeoutInSeconds int
+}
+
+type ReadinessSettings struct {
+	DrainDelayInSeconds int
+	MinFreeDiskInMB     int
+}
+
+type TLSSettings struct {
+	Enabled                     bool
+	CertFile                    string
+	KeyFile                     string
+	ClientCAFile                string
+	CertReloadIntervalInSeconds int
+}
+
+//Typed app config, built from the effective properties by LoadConfig
+type Config struct {
+	AppPort                           int
+	GRPCPort                          int
+	AppGracefulShutdownTimeinSeconds  int
+	ConfigReloadPollIntervalInSeconds int
+	JSONLenient                       bool
+	Ingestion                         IngestionSettings
+	Webhooks                          WebhookSettings
+	Stream                            StreamSettings
+	UploadStream                      UploadStreamSettings
+	Sketches                          SketchSettings
+	Rollups                           RollupSettings
+	Shutdown                          ShutdownSettings
+	RateLimits                        map[string]RateLimit
+	TLS                               TLSSettings
+	Readiness                         ReadinessSettings
+	Log                               LogConfig
+	AdminApiKey                       string
+	GRPCApiKey                        string
+	AdminCertSubjects                 []string
+	GRPCCertSubjects                  []string
+	EventMetricRules                  []EventMetricRule
+	MetricsDeviceTypes                []string
+
+	props appProperties
+	//Set by loadConfig: the config file to watch for changes and the environment and flag overrides to keep on reloads
+	fileName  string
+	overrides []appProperties
+}
+
+//Collects every invalid setting so that a bad config is reported in one go
+type configErrors []string
+
+func (ce *configErrors) add(key string, message string) {
+	*ce = append(*ce, key+": "+message)
+}
+
+func (ce configErrors) err() error {
+	if len(ce) == 0 {
+		return nil
+	}
+	return errors.New("Invalid config: " + strings.Join(ce, "; "))
+}
+
+func (ce *configErrors) getInt(props appProperties, key string, min int) int {
+	value, err := cast.ToIntE(strings.TrimSpace(props.GetString(key)))
+	if err != nil {
+		ce.add(key, "not an integer")
+	} else if value < min {
+		ce.add(key, "must be at least "+cast.ToString(min))
+	}
+	return value
+}
+
+func (ce *configErrors) getFloat64(props appProperties, key string) float64 {
+	value, err := cast.ToFloat64E(strings.TrimSpace(props.GetString(key)))
+	if err != nil {
+		ce.add(key, "not a number")
+	} else if value < 0 {
+		ce.add(key, "must not be negative")
+	}
+	return value
+}
+
+func (ce *configErrors) getBool(props appProperties, key string) bool {
+	switch strings.ToLower(strings.TrimSpace(props.GetString(key))) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	}
+	ce.add(key, "not true or false")
+	return false
+}
+
+//Parses and validates the effective properties
+func LoadConfig(props appProperties) (*Config, error) {
+	var ce configErrors
+
+	config := &Config{
+		AppPort:                           ce.getInt(props, "appPort", 1),
+		GRPCPort:                          ce.getInt(props, "grpcPort", 0),
+		AppGracefulShutdownTimeinSeconds:  ce.getInt(props, "appGracefulShutdownTimeinSeconds", 0),
+		ConfigReloadPollIntervalInSeconds: ce.getInt(props, "configReloadPollIntervalInSeconds", 1),
+		JSONLenient:                       ce.getBool(props, "jsonLenient"),
+		Ingestion: IngestionSettings{
+			Async:               ce.getBool(props, "ingestionAsync"),
+			QueueSize:           ce.getInt(props, "ingestionQueueSize", 1),
+			Workers:             ce.getInt(props, "ingestionWorkers", 1),
+			BatchSize:           ce.getInt(props, "ingestionBatchSize", 1),
+			RetryAfterInSeconds: ce.getInt(props, "ingestionRetryAfterInSeconds", 1),
+			QueueDir:            props.GetString("ingestionQueueDir"),
+			SegmentSizeInMB:     ce.getInt(props, "ingestionQueueSegmentSizeInMB", 1),
+			Sync:                ce.getBool(props, "ingestionQueueSync"),
+		},
+		Webhooks: WebhookSettings{
+			File:                props.GetString("webhookFile"),
+			Workers:             ce.getInt(props, "webhookWorkers", 1),
+			QueueSize:           ce.getInt(props, "webhookQueueSize", 1),
+			TimeoutInSeconds:    ce.getInt(props, "webhookTimeoutInSeconds", 1),
+			MaxAttempts:         ce.getInt(props, "webhookMaxAttempts", 1),
+			InitialBackoffInMs:  ce.getInt(props, "webhookInitialBackoffInMs", 0),
+			MaxBackoffInSeconds: ce.getInt(props, "webhookMaxBackoffInSeconds", 0),
+			DeadLetterLimit:     ce.getInt(props, "webhookDeadLetterLimit", 1),
+		},
+		Stream: StreamSettings{
+			BufferSize:         ce.getInt(props, "streamBufferSize", 1),
+			ReplaySize:         ce.getInt(props, "streamReplaySize", 0),
+			MaxSubscribers:     ce.getInt(props, "streamMaxSubscribers", 0),
+			KeepAliveInSeconds: ce.getInt(props, "streamKeepAliveInSeconds", 1),
+			WebSocketEnabled:   ce.getBool(props, "streamWebSocketEnabled"),
+		},
+		UploadStream: UploadStreamSettings{
+			CheckpointEvents:       ce.getInt(props, "grpcUploadCheckpointEvents", 1),
+			CheckpointIntervalInMs: ce.getInt(props, "grpcUploadCheckpointIntervalInMs", 1),
+			OffsetFile:             props.GetString("grpcUploadOffsetFile"),
+		},
+		Sketches: SketchSettings{
+			BucketSeconds:      ce.getInt(props, "sketchBucketSeconds", 1),
+			RetentionInHours:   ce.getInt(props, "sketchRetentionInHours", 1),
+			HLLPrecision:       ce.getInt(props, "sketchHllPrecision", minHyperLogLogPrecision),
+			TDigestCompression: ce.getInt(props, "sketchTdigestCompression", 10),
+		},
+		Rollups: RollupSettings{
+			RawRetentionInHours:    ce.getInt(props, "rawEventRetentionInHours", 0),
+			MinuteRetentionInHours: ce.getInt(props, "rollupMinuteRetentionInHours", 1),
+			HourRetentionInDays:    ce.getInt(props, "rollupHourRetentionInDays", 1),
+			DayRetentionInDays:     ce.getInt(props, "rollupDayRetentionInDays", 1),
+		},
+		Shutdown: ShutdownSettings{
+			FlushTimeoutInSeconds:   ce.getInt(props, "shutdownFlushTimeoutInSeconds", 0),
+			StoreTimeoutInSeconds:   ce.getInt(props, "shutdownStoreTimeoutInSeconds", 0),
+			WorkersTimeoutInSeconds: ce.getInt(props, "shutdownWorkersTimeoutInSeconds", 0),
+		},
+		RateLimits: make(map[string]RateLimit),
+		TLS: TLSSettings{
+			Enabled:                     ce.getBool(props, "tlsEnabled"),
+			CertFile:                    props.GetString("tlsCertFile"),
+			KeyFile:                     props.GetString("tlsKeyFile"),
+			ClientCAFile:                props.GetString("tlsClientCAFile"),
+			CertReloadIntervalInSeconds: ce.getInt(props, "tlsCertReloadIntervalInSeconds", 0),
+		},
+		Readiness: ReadinessSettings{
+			DrainDelayInSeconds: ce.getInt(props, "readinessDrainDelayInSeconds", 0),
+			MinFreeDiskInMB:     ce.getInt(props, "readinessMinFreeDiskInMB", 0),
+		},
+		Log:         LoadLogConfig(props),
+		AdminApiKey: props.GetString("adminApiKey"),
+		GRPCApiKey:  props.GetString("grpcApiKey"),
+		props:       props,
+	}
+	if config.AppPort > 65535 {
+		ce.add("appPort", "must be at most 65535")
+	}
+	if config.GRPCPort > 65535 {
+		ce.add("grpcPort", "must be at most 65535")
+	} else if config.GRPCPort != 0 && config.GRPCPort == config.AppPort {
+		ce.add("grpcPort", "must differ from appPort")
+	}
+
+	for _, kind := range []string{"ip", "apiKey", "tenant"} {
+		prefix := "rateLimit" + strings.ToUpper(kind[:1]) + kind[1:]
+		limit := RateLimit{
+			RequestsPerSecond: ce.getFloat64(props, prefix+"RequestsPerSecond"),
+			Burst:             ce.getFloat64(props, prefix+"Burst"),
+			EventsPerMinute:   ce.getFloat64(props, prefix+"EventsPerMinute"),
+		}
+		if limit.Burst < limit.RequestsPerSecond {
+			limit.Burst = limit.RequestsPerSecond
+		}
+		config.RateLimits[kind] = limit
+	}
+
+	if config.Sketches.HLLPrecision > maxHyperLogLogPrecision {
+		ce.add("sketchHllPrecision", "must be at most "+cast.ToString(maxHyperLogLogPrecision))
+	}
+	for _, key := range strings.Split(props.GetString("sketchDistinctKeys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			config.Sketches.DistinctKeys = append(config.Sketches.DistinctKeys, key)
+		}
+	}
+	for _, value := range strings.Split(props.GetString("sketchQuantileValues"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			config.Sketches.QuantileValues = append(config.Sketches.QuantileValues, value)
+		}
+	}
+	config.AdminCertSubjects = splitCertSubjects(props.GetString("adminCertSubjects"))
+	config.GRPCCertSubjects = splitCertSubjects(props.GetString("grpcCertSubjects"))
+	for _, deviceType := range strings.Split(props.GetString("metricsDeviceTypes"), ",") {
+		if deviceType = strings.TrimSpace(deviceType); deviceType != "" {
+			config.MetricsDeviceTypes = append(config.MetricsDeviceTypes, deviceType)
+		}
+	}
+	for _, key := range strings.Split(props.GetString("rollupDimensionKeys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			config.Rollups.DimensionKeys = append(config.Rollups.DimensionKeys, key)
+		}
+	}
+
+	if config.TLS.Enabled && (config.TLS.CertFile == "" || config.TLS.KeyFile == "") {
+		ce.add("tlsCertFile", MissingCertificateError.Error())
+	}
+
+	if _, err := log.ParseLevel(config.Log.Level); err != nil {
+		ce.add("logLevel", err.Error())
+	}
+	if config.Log.Format != "json" && config.Log.Format != "text" {
+		ce.add("logFormat", "must be json or text")
+	}
+	for _, output := range config.Log.Outputs {
+		if output != "file" && output != "stdout" && output != "stderr" {
+			ce.add("logOutputs", UnknownLogOutputError.Error()+" "+output)
+		}
+	}
+	config.Log.MaxSizeInMB = ce.getInt(props, "logMaxSizeInMB", 0)
+	config.Log.RotateIntervalInHours = ce.getInt(props, "logRotateIntervalInHours", 0)
+	config.Log.MaxBackups = ce.getInt(props, "logMaxBackups", 0)
+	config.Log.Compress = ce.getBool(props, "logCompress")
+
+	if props.IsSet("eventMetricRules") {
+		if err := props.MarshalKey("eventMetricRules", &config.EventMetricRules); err != nil {
+			ce.add("eventMetricRules", err.Error())
+		} else if _, err := NewEventMetrics(config.EventMetricRules); err != nil {
+			ce.add("eventMetricRules", err.Error())
+		}
+	}
+
+	if err := ce.err(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+//Writes the effective config as JSON in the format of meowtricsConfig.json, secrets are hidden
+func (c *Config) Print(w io.Writer) error {
+	settings := make(map[string]interface{})
+	for _, property := range configProperties {
+		value := c.props.GetString(property.key)
+		if property.secret && value != "" {
+			value = hiddenSecretText
+		}
+		settings[property.key] = value
+	}
+	settings["eventMetricRules"] = c.EventMetricRules
+
+	data, err := json.MarshalIndent(settings, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//Config built from the defaults alone
+func DefaultConfig() *Config {
+	config, err := LoadConfig(defaultProperties())
+	if err != nil {
+		panic("Invalid default config: " + err.Error())
+	}
+	return config
+}
+
+//Certificate subjects are separated by semicolons since they hold commas themselves
+func splitCertSubjects(value string) []string {
+	var subjects []string
+	for _, subject := range strings.Split(value, ";") {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects
+}
+
+//Layers the overrides and the defaults around the config file properties
+func effectiveProperties(overrides []appProperties, file appProperties) appProperties {
+	layers := append(layeredProperties{}, overrides...)
+	return append(layers, file, defaultProperties())
+}
+
+func defaultProperties() mapProperties {
+	settings := make(map[string]interface{})
+	for _, property := range configProperties {
+		settings[property.key] = property.defaultValue
+	}
+	return newMapProperties(settings)
+}
+
+//Maps MEOWTRICS_* variables to config keys, appPort is read from MEOWTRICS_APP_PORT
+func envProperties(environ []string) mapProperties {
+	env := make(map[string]string)
+	for _, variable := range environ {
+		if i := strings.Index(variable, "="); i > 0 {
+			env[variable[:i]] = variable[i+1:]
+		}
+	}
+
+	settings := make(map[string]interface{})
+	for _, property := range configProperties {
+		if value, ok := env[configEnvName(property.key)]; ok {
+			settings[property.key] = value
+		}
+	}
+	return newMapProperties(settings)
+}
+
+//Environment variable of a config key, a run of capitals is one word: tlsClientCAFile is MEOWTRICS_TLS_CLIENT_CA_FILE
+func configEnvName(key string) string {
+	runes := []rune(key)
+	var name []rune
+	for i, c := range runes {
+		if i > 0 && unicode.IsUpper(c) {
+			acronym := unicode.IsUpper(runes[i-1]) && (i+1 == len(runes) || unicode.IsUpper(runes[i+1]))
+			if !acronym {
+				name = append(name, '_')
+			}
+		}
+		name = append(name, unicode.ToUpper(c))
+	}
+	return ENV_PREFIX + string(name)
+}
+
+//Parses one flag per config key, only flags given on the command line override the config file
+func flagProperties(name string, args []string) (mapProperties, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	for _, property := range configProperties {
+		flags.String(property.key, "", property.usage+" (env "+configEnvName(property.key)+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		printConfigUsage(os.Stderr, flags)
+		return nil, err
+	}
+
+	settings := make(map[string]interface{})
+	flags.Visit(func(f *flag.Flag) {
+		settings[f.Name] = f.Value.String()
+	})
+	return newMapProperties(settings), nil
+}
+
+func printConfigUsage(w io.Writer, flags *flag.FlagSet) {
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+	flags.SetOutput(ioutil.Discard)
+}
+
+//Properties looked up layer by layer, the first layer that has a key wins
+type layeredProperties []appProperties
+
+func (l layeredProperties) layer(key string) appProperties {
+	for _, props := range l {
+		if props.IsSet(key) {
+			return props
+		}
+	}
+	return nil
+}
+
+func (l layeredProperties) GetString(key string) string {
+	if props := l.layer(key); props != nil {
+		return props.GetString(key)
+	}
+	return ""
+}
+
+func (l layeredProperties) GetInt(key string) int         { return cast.ToInt(l.GetString(key)) }
+func (l layeredProperties) GetFloat64(key string) float64 { return cast.ToFloat64(l.GetString(key)) }
+func (l layeredProperties) GetBool(key string) bool       { return cast.ToBool(l.GetString(key)) }
+func (l layeredProperties) IsSet(key string) bool         { return l.layer(key) != nil }
+func (l layeredProperties) MarshalKey(key string, rawVal interface{}) error {
+	if props := l.layer(key); props != nil {
+		return props.MarshalKey(key, rawVal)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	config, err := LoadConfig(effectiveProperties(nil, newMapProperties(nil)))
+	assert.NoError(t, err, "Defaults should be valid")
+	assert.Equal(t, 3003, config.AppPort, "appPort should default to 3003")
+	assert.Equal(t, 10, config.AppGracefulShutdownTimeinSeconds, "appGracefulShutdownTimeinSeconds should default to 10")
+	assert.Equal(t, "info", config.Log.Level, "logLevel should default to info")
+	assert.Equal(t, []string{"file"}, config.Log.Outputs, "logOutputs should default to file")
+}
+
+func TestLoadConfig_InvalidValues(t *testing.T) {
+	props := newMapProperties(map[string]interface{}{
+		"appPort":                          "meow",
+		"appGracefulShutdownTimeinSeconds": "-1",
+		"rateLimitIpBurst":                 "lots",
+		"tlsEnabled":                       "yes",
+		"logFormat":                        "xml",
+		"sketchHllPrecision":               "20",
+	})
+	_, err := LoadConfig(effectiveProperties(nil, props))
+	assert.Error(t, err, "Invalid values should be rejected")
+	for _, key := range []string{"appPort", "appGracefulShutdownTimeinSeconds", "rateLimitIpBurst", "tlsEnabled", "logFormat", "sketchHllPrecision"} {
+		assert.Contains(t, err.Error(), key+":", "Every invalid setting should be reported")
+	}
+}
+
+func TestLoadConfig_Overrides(t *testing.T) {
+	flags, err := flagProperties("meowtrics", []string{"-appPort", "4004"})
+	assert.NoError(t, err, "Error parsing flags")
+	env := envProperties([]string{"MEOWTRICS_APP_PORT=5005", "MEOWTRICS_LOG_LEVEL=debug", "HOME=/root"})
+	overrides := []appProperties{flags, env}
+
+	file := newMapProperties(map[string]interface{}{"appPort": "6006", "logLevel": "warning", "logFormat": "text", "sketchDistinctKeys": "user_id, session_id", "sketchQuantileValues": "app_start,kv:amount",
+		"rollupDimensionKeys": "country,", "adminCertSubjects": "CN=ops,O=Meowtrics; CN=backup"})
+	config, err := LoadConfig(effectiveProperties(overrides, file))
+	assert.NoError(t, err, "Error loading config")
+	assert.Equal(t, 4004, config.AppPort, "Flags should override the environment")
+	assert.Equal(t, "debug", config.Log.Level, "Environment should override the config file")
+	assert.Equal(t, []string{"user_id", "session_id"}, config.Sketches.DistinctKeys, "Distinct keys should be split and trimmed")
+	assert.Equal(t, []string{"app_start", "kv:amount"}, config.Sketches.QuantileValues, "Quantile values should be split")
+	assert.Equal(t, []string{"android", "ios", "web"}, config.MetricsDeviceTypes, "Default device types should be split")
+	assert.Equal(t, []string{"CN=ops,O=Meowtrics", "CN=backup"}, config.AdminCertSubjects, "Certificate subjects should be split at semicolons")
+	assert.Equal(t, []string{"country"}, config.Rollups.DimensionKeys, "Empty dimension keys should be skipped")
+	assert.Equal(t, "text", config.Log.Format, "Config file should override the defaults")
+
+	_, err = flagProperties("meowtrics", []string{"-unknownFlag", "1"})
+	assert.Error(t, err, "Unknown flags should be rejected")
+}
+
+func TestConfig_PrintHidesSecrets(t *testing.T) {
+	file := newMapProperties(map[string]interface{}{"adminApiKey": "supersecret"})
+	config, err := LoadConfig(effectiveProperties(nil, file))
+	assert.NoError(t, err, "Error loading config")
+
+	var buf bytes.Buffer
+	assert.NoError(t, config.Print(&buf), "Error printing config")
+	assert.NotContains(t, buf.String(), "supersecret", "Secrets should be hidden")
+
+	printed := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &printed), "Printed config should be JSON")
+	assert.Equal(t, hiddenSecretText, printed["adminApiKey"], "Secrets should be hidden")
+	assert.Equal(t, "3003", printed["appPort"], "Effective values should be printed")
+}
+
+func TestConfigEnvName(t *testing.T) {
+	assert.Equal(t, "MEOWTRICS_APP_PORT", configEnvName("appPort"))
+	assert.Equal(t, "MEOWTRICS_RATE_LIMIT_IP_REQUESTS_PER_SECOND", configEnvName("rateLimitIpRequestsPerSecond"))
+	assert.Equal(t, "MEOWTRICS_TLS_CLIENT_CA_FILE", configEnvName("tlsClientCAFile"))
+	assert.Equal(t, "MEOWTRICS_LOG_MAX_SIZE_IN_MB", configEnvName("logMaxSizeInMB"))
+	assert.Equal(t, "MEOWTRICS_INGESTION_QUEUE_SEGMENT_SIZE_IN_MB", configEnvName("ingestionQueueSegmentSizeInMB"))
+}
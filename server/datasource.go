@@ -1,30 +1,133 @@
-package main
+package server
 
-import "meowtrics/model"
+import (
+	"meowtrics/model"
+	"sort"
+	"sync"
+)
 
-func StoreEvent(event model.ClientEventData) error {
+//Datastore behind a Server, MemoryStore is used unless another one is passed with WithStore
+type Store interface {
+	//The store keeps event itself, callers must not modify it afterwards
+	StoreEvent(event *model.ClientEventData) error
+	RetrieveEvent(eventId string) (*model.ClientEventData, error)
+	//Every stored event ordered by event id
+	AllEvents() []*model.ClientEventData
+	CountEvents() int
+	CountEventsByType() map[model.ClientEventType]int
+	//Gives back space held by removed events, returns the number of stored events
+	Compact() int
+}
+
+//Optional Store interface for stores that buffer writes, flushed to disk on shutdown before the store is closed
+type StoreFlusher interface {
+	Flush() error
+}
+
+//Optional Store interface for stores that can drop old events, used to enforce the raw event retention
+type StoreExpirer interface {
+	//Removes the events with a timestamp before the given one, returns the number of removed events
+	RemoveEventsBefore(timestamp int64) int
+}
+
+//In memory datastore, events are lost on restart
+type MemoryStore struct {
+	mutex  sync.RWMutex
+	events map[string]*model.ClientEventData
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: make(map[string]*model.ClientEventData)}
+}
+
+func (ms *MemoryStore) StoreEvent(event *model.ClientEventData) error {
 
 	if event.GetEventId() == "" {
 		return InvalidParametersError
 	}
 
-	eventMap[event.GetEventId()] = event
+	ms.mutex.Lock()
+	ms.events[event.GetEventId()] = event
+	ms.mutex.Unlock()
 	return nil
 }
 
-func RetrieveEvent(eventId string) (*model.ClientEventData, error) {
+func (ms *MemoryStore) RetrieveEvent(eventId string) (*model.ClientEventData, error) {
 
 	if eventId == "" {
 		return nil, InvalidParametersError
 	}
 
-	if event, ok := eventMap[eventId]; ok {
-		return &event, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	if event, ok := ms.events[eventId]; ok {
+		return event, nil
 	}
 
 	return nil, RecordNotFoundError
 }
 
+func (ms *MemoryStore) AllEvents() []*model.ClientEventData {
+	ms.mutex.RLock()
+	ids := make([]string, 0, len(ms.events))
+	for id := range ms.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	events := make([]*model.ClientEventData, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, ms.events[id])
+	}
+	ms.mutex.RUnlock()
+	return events
+}
+
+func (ms *MemoryStore) CountEvents() int {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return len(ms.events)
+}
+
+func (ms *MemoryStore) CountEventsByType() map[model.ClientEventType]int {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	counts := make(map[model.ClientEventType]int)
+	for _, event := range ms.events {
+		counts[event.GetEventType()]++
+	}
+	return counts
+}
+
+func (ms *MemoryStore) RemoveEventsBefore(timestamp int64) int {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	removed := 0
+	for id, event := range ms.events {
+		if event.GetTimestamp() < timestamp {
+			delete(ms.events, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+//Copies the events into a new map, maps never shrink so this gives back the memory of removed events
+func (ms *MemoryStore) Compact() int {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	compacted := make(map[string]*model.ClientEventData, len(ms.events))
+	for id, event := range ms.events {
+		compacted[id] = event
+	}
+	ms.events = compacted
+	return len(ms.events)
+}
+
 /*
 func GetDbConnection() (conn *bolt.DB, error){
 	filename := viper.GetString("boltDbFilePath")
@@ -1,42 +1,56 @@
-package main
+package server
 
 import (
-	"meowtrics/model"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestStoreEvent_ExpectedData(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	err := StoreEvent(testEvent)
+	err := testStore.StoreEvent(testEvent)
 	assert.Nil(t, err, "Error is not nil")
 }
 
 func TestStoreEvent_MissingEventId(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	testEvent.EventId = nil
-	err := StoreEvent(testEvent)
+	testEvent.EventId = ""
+	err := testStore.StoreEvent(testEvent)
 	assert.Equal(t, InvalidParametersError, err, "Error should be invalid parameters")
 }
 
 func TestRetrieveEvent(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	err := StoreEvent(testEvent)
+	err := testStore.StoreEvent(testEvent)
 	assert.Nil(t, err, "Error is not nil")
 
-	actualEvent, err := RetrieveEvent(testEvent.GetEventId())
+	actualEvent, err := testStore.RetrieveEvent(testEvent.GetEventId())
 	assert.Nil(t, err, "Error should be nil")
 	assert.Equal(t, testEvent.GetData(), actualEvent.GetData(), "Event data should be equal")
 
-	actualEvent, err = RetrieveEvent("")
+	actualEvent, err = testStore.RetrieveEvent("")
 	assert.Nil(t, actualEvent, "Event should be nil")
 	assert.Equal(t, InvalidParametersError, err, "Error should be invalid parameters")
 
-	actualEvent, err = RetrieveEvent("absentEvent")
+	actualEvent, err = testStore.RetrieveEvent("absentEvent")
 	assert.Nil(t, actualEvent, "Event should be nil")
 	assert.Equal(t, RecordNotFoundError, err, "Error should be record not found")
 }
+
+func TestRemoveEventsBefore(t *testing.T) {
+	store := NewMemoryStore()
+	for i, timestamp := range []int64{100, 200, 300} {
+		event := generateTestClientEvent()
+		event.EventId = strconv.Itoa(i)
+		event.Timestamp = proto.Int64(timestamp)
+		store.StoreEvent(event)
+	}
+	assert.Equal(t, 2, store.RemoveEventsBefore(300), "Events before the timestamp should be removed")
+	assert.Equal(t, 1, store.CountEvents())
+	assert.Equal(t, 0, store.RemoveEventsBefore(300))
+}
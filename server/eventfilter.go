@@ -0,0 +1,129 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+//Reads a filter from repeated event_type, device_type and kv_pair query parameters. Event types are given by name or
+//number, kv pairs as key:value or as a comparison like duration>=100 (operators !=, <, <=, > and >=).
+func parseEventFilter(query url.Values) (*model.EventFilter, error) {
+	filter := new(model.EventFilter)
+	for _, value := range query["event_type"] {
+		eventType, err := parseEventType(value)
+		if err != nil {
+			return nil, errors.New("Unknown event_type " + value)
+		}
+		filter.EventTypes = append(filter.EventTypes, eventType)
+	}
+
+	filter.DeviceTypes = query["device_type"]
+
+	for _, value := range query["kv_pair"] {
+		i := strings.IndexAny(value, ":!<>")
+		if i < 1 {
+			return nil, errors.New("kv_pair must be key:value or a comparison like key>value, got " + value)
+		}
+		if value[i] == ':' {
+			filter.KvPairs = append(filter.KvPairs, &model.KeyValuePair{Key: value[:i], Value: proto.String(value[i+1:])})
+			continue
+		}
+
+		operator := value[i : i+1]
+		if strings.HasPrefix(value[i+1:], "=") {
+			operator = value[i : i+2]
+		}
+		comparison, ok := kvComparisons[operator]
+		if !ok {
+			return nil, errors.New("Unknown comparison in kv_pair " + value)
+		}
+		conditionValue := stringAttribute(value[i+len(operator):])
+		if comparison != model.Comparison_COMPARISON_NOT_EQUAL {
+			conditionValue = orderedAttribute(value[i+len(operator):])
+		}
+		filter.KvConditions = append(filter.KvConditions, &model.KeyValueCondition{
+			Key:        value[:i],
+			Comparison: comparison,
+			Value:      conditionValue,
+		})
+	}
+	return filter, nil
+}
+
+//Value of an ordering comparison, numbers become typed so they are compared with the numbers stringified by old
+//clients. Two strings are never ordered.
+func orderedAttribute(text string) *model.AttributeValue {
+	if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: number}}
+	}
+	if number, ok := attributeNumber(stringAttribute(text)); ok {
+		return &model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: number}}
+	}
+	return stringAttribute(text)
+}
+
+//Event type given by name, in any case, or by number
+func parseEventType(value string) (model.ClientEventType, error) {
+	if eventType, ok := model.ClientEventType_value[strings.ToUpper(value)]; ok {
+		return model.ClientEventType(eventType), nil
+	}
+	number, err := strconv.Atoi(value)
+	if _, known := model.ClientEventType_name[int32(number)]; err != nil || !known {
+		return 0, errors.New("Unknown event type " + value)
+	}
+	return model.ClientEventType(number), nil
+}
+
+var kvComparisons = map[string]model.Comparison{
+	"!=": model.Comparison_COMPARISON_NOT_EQUAL,
+	"<":  model.Comparison_COMPARISON_LESS,
+	"<=": model.Comparison_COMPARISON_LESS_OR_EQUAL,
+	">":  model.Comparison_COMPARISON_GREATER,
+	">=": model.Comparison_COMPARISON_GREATER_OR_EQUAL,
+}
+
+//Checks a stored event against a filter, a nil filter matches every event
+func eventFilterMatches(filter *model.EventFilter, event *model.ClientEventData, deviceType string) bool {
+	if eventTypes := filter.GetEventTypes(); len(eventTypes) > 0 {
+		found := false
+		for _, eventType := range eventTypes {
+			if eventType == event.GetEventType() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if deviceTypes := filter.GetDeviceTypes(); len(deviceTypes) > 0 {
+		found := false
+		for _, value := range deviceTypes {
+			if value == deviceType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, pair := range filter.GetKvPairs() {
+		if value, ok := eventAttribute(event, pair.GetKey()); !ok || !attributesEqual(value, kvPairAttribute(pair)) {
+			return false
+		}
+	}
+	for _, condition := range filter.GetKvConditions() {
+		if !kvConditionMatches(condition, event) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const KV_PAIR_PREFIX = "kv:"
+
+//Distinct values a kv:<key> label takes per rule, later values are counted as otherLabelValue. Clients choose kv pair
+//values, so every new one would otherwise add series for good.
+const (
+	maxEventMetricLabelValues = 100
+	otherLabelValue           = "other"
+)
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	InvalidEventMetricRuleError = errors.New("Invalid event metric rule")
+)
+
+/*
+Rule turning ingested ClientEventData into a labelled series, e.g.
+
+	{"name": "meowtrics_user_registrations_total", "type": "counter", "eventType": "USER_REGISTERED", "labels": ["device_type", "kv:country"]}
+
+Labels can be "event_type", "device_type" or "kv:<key>" for a kv_pair value, each label name only once. Device types
+outside metricsDeviceTypes and kv_pair values past the first maxEventMetricLabelValues are labelled "other". Gauges take their value from
+"kv:<key>" or "timestamp" and keep the value of the latest matching event per label set. Sums add up the numeric
+"kv:<key>" value of matching events and are exposed as a summary with _sum and _count series, events without a numeric
+value are skipped.
+*/
+type EventMetricRule struct {
+	Name      string   `json:"name"`
+	Help      string   `json:"help"`
+	Type      string   `json:"type"`
+	EventType string   `json:"eventType"`
+	Labels    []string `json:"labels"`
+	Value     string   `json:"value,omitempty"`
+}
+
+type eventMetric struct {
+	rule      EventMetricRule
+	eventType *model.ClientEventType
+	counter   *CounterVec
+	gauge     *GaugeVec
+	sum       *SummaryVec
+
+	mutex       sync.Mutex
+	labelValues map[string]map[string]bool
+}
+
+//The value of a kv:<key> label, or "other" once the label has maxEventMetricLabelValues others
+func (m *eventMetric) boundedLabelValue(label string, value string) string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	values := m.labelValues[label]
+	if values[value] {
+		return value
+	}
+	if len(values) >= maxEventMetricLabelValues {
+		return otherLabelValue
+	}
+	values[value] = true
+	return value
+}
+
+//Business metrics derived from ingested events, exposed separately from the server metrics
+type EventMetrics struct {
+	mutex      sync.RWMutex
+	metrics    []*eventMetric
+	collectors []metricCollector
+}
+
+func NewEventMetrics(rules []EventMetricRule) (*EventMetrics, error) {
+	em := new(EventMetrics)
+	names := make(map[string]bool)
+
+	for _, rule := range rules {
+		if !metricNamePattern.MatchString(rule.Name) || names[rule.Name] {
+			return nil, eventMetricRuleError(rule, "name is invalid or used twice")
+		}
+		names[rule.Name] = true
+
+		metric := &eventMetric{rule: rule, labelValues: make(map[string]map[string]bool)}
+		if rule.EventType != "" {
+			value, ok := model.ClientEventType_value[rule.EventType]
+			if !ok {
+				return nil, eventMetricRuleError(rule, "unknown eventType "+rule.EventType)
+			}
+			metric.eventType = model.ClientEventType(value).Enum()
+		}
+
+		labelNames := make([]string, len(rule.Labels))
+		usedLabels := make(map[string]bool)
+		for i, label := range rule.Labels {
+			if label != "event_type" && label != "device_type" && !strings.HasPrefix(label, KV_PAIR_PREFIX) {
+				return nil, eventMetricRuleError(rule, "unknown label "+label+", labels are \"event_type\", \"device_type\" or \"kv:<key>\"")
+			}
+			labelNames[i] = strings.TrimPrefix(label, KV_PAIR_PREFIX)
+			if !labelNamePattern.MatchString(labelNames[i]) {
+				return nil, eventMetricRuleError(rule, "invalid label "+label)
+			}
+			//"kv:device_type" and "device_type" would be the same series label
+			if usedLabels[labelNames[i]] {
+				return nil, eventMetricRuleError(rule, "label "+labelNames[i]+" is used twice")
+			}
+			usedLabels[labelNames[i]] = true
+			if strings.HasPrefix(label, KV_PAIR_PREFIX) {
+				metric.labelValues[label] = make(map[string]bool)
+			}
+		}
+
+		help := rule.Help
+		if help == "" {
+			help = "Derived from ingested events."
+		}
+
+		switch rule.Type {
+		case "counter", "":
+			metric.counter = NewCounterVec(rule.Name, help, labelNames...)
+			em.collectors = append(em.collectors, metric.counter)
+		case "gauge":
+			if rule.Value != "timestamp" && !strings.HasPrefix(rule.Value, KV_PAIR_PREFIX) {
+				return nil, eventMetricRuleError(rule, "gauges need a value of \"timestamp\" or \"kv:<key>\"")
+			}
+			metric.gauge = NewGaugeVec(rule.Name, help, labelNames...)
+			em.collectors = append(em.collectors, metric.gauge)
+		case "sum":
+			if !strings.HasPrefix(rule.Value, KV_PAIR_PREFIX) {
+				return nil, eventMetricRuleError(rule, "sums need a value of \"kv:<key>\"")
+			}
+			metric.sum = NewSummaryVec(rule.Name, help, labelNames...)
+			em.collectors = append(em.collectors, metric.sum)
+		default:
+			return nil, eventMetricRuleError(rule, "unknown type "+rule.Type)
+		}
+
+		em.metrics = append(em.metrics, metric)
+	}
+
+	return em, nil
+}
+
+func eventMetricRuleError(rule EventMetricRule, reason string) error {
+	return errors.New(InvalidEventMetricRuleError.Error() + " " + rule.Name + ": " + reason)
+}
+
+//Swaps in the rules of a reloaded config, series of the previous rules are dropped
+func (em *EventMetrics) Replace(other *EventMetrics) {
+	other.mutex.RLock()
+	metrics, collectors := other.metrics, other.collectors
+	other.mutex.RUnlock()
+
+	em.mutex.Lock()
+	em.metrics, em.collectors = metrics, collectors
+	em.mutex.Unlock()
+}
+
+func (em *EventMetrics) Collectors() []metricCollector {
+	em.mutex.RLock()
+	defer em.mutex.RUnlock()
+	return em.collectors
+}
+
+//Applies every matching rule to a stored event, deviceType is already bounded to the metricsDeviceTypes
+func (em *EventMetrics) Observe(event *model.ClientEventData, deviceType string) {
+	em.mutex.RLock()
+	defer em.mutex.RUnlock()
+
+	for _, metric := range em.metrics {
+		if metric.eventType != nil && *metric.eventType != event.GetEventType() {
+			continue
+		}
+
+		labelValues := make([]string, len(metric.rule.Labels))
+		for i, label := range metric.rule.Labels {
+			labelValues[i] = eventLabelValue(event, deviceType, label)
+			if strings.HasPrefix(label, KV_PAIR_PREFIX) {
+				labelValues[i] = metric.boundedLabelValue(label, labelValues[i])
+			}
+		}
+
+		if metric.counter != nil {
+			metric.counter.Inc(labelValues...)
+			continue
+		}
+
+		value, ok := eventNumericValue(event, metric.rule.Value)
+		if !ok {
+			continue
+		}
+		if metric.sum != nil {
+			metric.sum.Observe(value, labelValues...)
+		} else {
+			metric.gauge.Set(value, labelValues...)
+		}
+	}
+}
+
+func eventLabelValue(event *model.ClientEventData, deviceType string, label string) string {
+	switch {
+	case label == "event_type":
+		return event.GetEventType().String()
+	case label == "device_type":
+		return deviceType
+	case strings.HasPrefix(label, KV_PAIR_PREFIX):
+		value, _ := kvPairValue(event, strings.TrimPrefix(label, KV_PAIR_PREFIX))
+		return value
+	}
+	return ""
+}
+
+func eventNumericValue(event *model.ClientEventData, source string) (float64, bool) {
+	if source == "timestamp" {
+		return float64(event.GetTimestamp()), true
+	}
+
+	value, ok := eventAttribute(event, strings.TrimPrefix(source, KV_PAIR_PREFIX))
+	if !ok {
+		return 0, false
+	}
+	return attributeNumber(value)
+}
+
+func kvPairValue(event *model.ClientEventData, key string) (string, bool) {
+	value, ok := eventAttribute(event, key)
+	if !ok {
+		return "", false
+	}
+	return attributeString(value), true
+}
+
+func (s *Server) EventMetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeMetricsResponse(w, s.eventMetrics.Collectors())
+	})
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"meowtrics/model"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func generateTestUserRegisteredEvent(country string) *model.ClientEventData {
+	event := generateTestClientEvent()
+	event.EventType = model.ClientEventType_USER_REGISTERED
+	event.KvPair = []*model.KeyValuePair{{Key: "country", Value: proto.String(country)}}
+	return event
+}
+
+func TestNewEventMetrics_InvalidRules(t *testing.T) {
+	_, err := NewEventMetrics([]EventMetricRule{{Name: "invalid name"}})
+	assert.Error(t, err, "Invalid metric name should be rejected")
+
+	_, err = NewEventMetrics([]EventMetricRule{{Name: "test_total", EventType: "MEOW"}})
+	assert.Error(t, err, "Unknown event type should be rejected")
+
+	_, err = NewEventMetrics([]EventMetricRule{{Name: "test_total", Labels: []string{"kv:not-a-label"}}})
+	assert.Error(t, err, "Invalid label name should be rejected")
+
+	_, err = NewEventMetrics([]EventMetricRule{{Name: "test_total", Labels: []string{"country"}}})
+	assert.Error(t, err, "Labels other than event_type, device_type and kv:<key> should be rejected")
+
+	_, err = NewEventMetrics([]EventMetricRule{{Name: "test_total", Labels: []string{"device_type", "kv:device_type"}}})
+	assert.Error(t, err, "Labels with the same name should be rejected")
+
+	_, err = NewEventMetrics([]EventMetricRule{{Name: "test_gauge", Type: "gauge"}})
+	assert.Error(t, err, "Gauge without value should be rejected")
+
+	_, err = NewEventMetrics([]EventMetricRule{{Name: "test_sum", Type: "sum", Value: "timestamp"}})
+	assert.Error(t, err, "Sum without a kv pair value should be rejected")
+}
+
+func TestEventMetrics_ObserveSum(t *testing.T) {
+	em, err := NewEventMetrics([]EventMetricRule{{Name: "purchase_amount", Type: "sum", Value: "kv:amount", Labels: []string{"kv:currency"}}})
+	assert.NoError(t, err, "Valid rules should be accepted")
+
+	amounts := []*model.KeyValuePair{
+		{Key: "amount", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 3}}},
+		{Key: "amount", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: 1.5}}},
+		{Key: "amount", Value: proto.String("2.25")},
+		{Key: "amount", Value: proto.String("free")},
+	}
+	for _, amount := range amounts {
+		event := generateTestClientEvent()
+		event.KvPair = []*model.KeyValuePair{amount, {Key: "currency", Value: proto.String("NZD")}}
+		em.Observe(event, "android")
+	}
+
+	assert.Equal(t, 6.75, em.metrics[0].sum.Sum("NZD"), "Typed and stringified numbers should be summed")
+	assert.Equal(t, float64(3), em.metrics[0].sum.Count("NZD"), "Values that aren't numbers should be skipped")
+
+	var buf bytes.Buffer
+	WriteMetrics(&buf, em.collectors)
+	assert.Contains(t, buf.String(), "# TYPE purchase_amount summary\n")
+	assert.Contains(t, buf.String(), "purchase_amount_sum{currency=\"NZD\"} 6.75\n")
+	assert.Contains(t, buf.String(), "purchase_amount_count{currency=\"NZD\"} 3\n")
+}
+
+func TestEventMetrics_TypedLabels(t *testing.T) {
+	em, err := NewEventMetrics([]EventMetricRule{{Name: "sessions_total", Labels: []string{"kv:premium", "kv:build"}}})
+	assert.NoError(t, err, "Valid rules should be accepted")
+
+	event := generateTestClientEvent()
+	event.KvPair = []*model.KeyValuePair{
+		{Key: "premium", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_BoolValue{BoolValue: true}}},
+		{Key: "build", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 1024}}},
+	}
+	em.Observe(event, "android")
+	assert.Equal(t, float64(1), em.metrics[0].counter.Value("true", "1024"), "Typed values should be labelled by their string form")
+}
+
+func TestEventMetrics_Observe(t *testing.T) {
+	em, err := NewEventMetrics([]EventMetricRule{
+		{Name: "registrations_total", EventType: "USER_REGISTERED", Labels: []string{"device_type", "kv:country"}},
+		{Name: "last_event_timestamp", Type: "gauge", Value: "timestamp", Labels: []string{"event_type"}},
+	})
+	assert.NoError(t, err, "Valid rules should be accepted")
+
+	canada := generateTestUserRegisteredEvent("CA")
+	em.Observe(canada, "android")
+	em.Observe(canada, "android")
+	india := generateTestUserRegisteredEvent("IN")
+	em.Observe(india, "iPhone")
+	unknown := generateTestClientEvent()
+	em.Observe(unknown, "android")
+
+	var buf bytes.Buffer
+	WriteMetrics(&buf, em.collectors)
+	assert.Contains(t, buf.String(), "registrations_total{device_type=\"android\",country=\"CA\"} 2\n", "Registrations should be counted per label set")
+	assert.Contains(t, buf.String(), "registrations_total{device_type=\"iPhone\",country=\"IN\"} 1\n", "Registrations should be counted per label set")
+	assert.NotContains(t, buf.String(), "country=\"\"", "Other event types should not be counted")
+	assert.Equal(t, float64(unknown.GetTimestamp()), em.metrics[1].gauge.Value("UNKNOWN"), "Gauge should hold the event timestamp")
+}
+
+func TestEventMetrics_BoundsKvLabelValues(t *testing.T) {
+	em, err := NewEventMetrics([]EventMetricRule{{Name: "registrations_total", Labels: []string{"kv:country"}}})
+	assert.NoError(t, err, "Valid rules should be accepted")
+
+	for i := 0; i < maxEventMetricLabelValues+5; i++ {
+		em.Observe(generateTestUserRegisteredEvent("country"+strconv.Itoa(i)), "android")
+	}
+	em.Observe(generateTestUserRegisteredEvent("country0"), "android")
+	assert.Equal(t, float64(2), em.metrics[0].counter.Value("country0"), "Values seen before should keep their series")
+	assert.Equal(t, float64(5), em.metrics[0].counter.Value("other"), "Values past the limit should be counted as other")
+	assert.Equal(t, float64(0), em.metrics[0].counter.Value("country100"))
+}
+
+func TestEventMetricsHandler_ConfiguredRules(t *testing.T) {
+	config, err := LoadConfig(effectiveProperties(nil, viperProperties{}))
+	assert.NoError(t, err, "Error loading config")
+	s := newTestServer(t, WithConfig(config))
+
+	uploadReq := new(model.ClientEventUploadRequest)
+	event := generateTestUserRegisteredEvent("CA")
+	uploadReq.RequestId = "testRequestId"
+	uploadReq.DeviceType = "testDeviceAndroid"
+	uploadReq.Events = append(uploadReq.Events, event)
+	jsonReq, err := model.MarshalJSON(uploadReq)
+	if err != nil {
+		panic("Cannot marshal data. Error: " + err.Error())
+	}
+
+	test := GeneratePostHandleTester(t, s.CreateEventHandler(), "application/json")
+	test("POST", string(jsonReq))
+
+	w := httptest.NewRecorder()
+	s.EventMetricsHandler().ServeHTTP(w, nil)
+	assert.Contains(t, w.Body.String(), "meowtrics_user_registrations_total{device_type=\"other\",country=\"CA\"} 1\n", "Configured rule should be exposed with the bounded device type")
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//Conversion window of funnels that don't set one, and the most steps a funnel may have
+const (
+	defaultFunnelWindowSeconds = 86400
+	maxFunnelSteps             = 20
+)
+
+//The part of a stored event a funnel needs
+type funnelEvent struct {
+	timestamp int64
+	eventType model.ClientEventType
+}
+
+//Number of steps reached by entering the funnel with events[entry], every step is the first later event of its type
+//within the window. Later steps can't be reached sooner by skipping an event, so taking the first one is optimal.
+func funnelProgress(events []funnelEvent, entry int, steps []model.ClientEventType, windowSeconds int64) int {
+	reached := 1
+	deadline := events[entry].timestamp + windowSeconds
+	for _, event := range events[entry+1:] {
+		if reached == len(steps) || event.timestamp > deadline {
+			break
+		}
+		if event.eventType == steps[reached] {
+			reached++
+		}
+	}
+	return reached
+}
+
+/*
+Counts the correlation values reaching every step of the funnel, computed from the stored events. Every value counts
+once, with the entry into the funnel that gets furthest. Events in the same second are ordered by event id.
+*/
+func (s *Server) queryFunnel(query *model.FunnelRequest) (*model.FunnelResponse, *model.ErrorResponse) {
+	invalid := func(description string) *model.ErrorResponse {
+		return &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid funnel query", Description: description}
+	}
+
+	steps := query.GetSteps()
+	if len(steps) < 2 || len(steps) > maxFunnelSteps {
+		return nil, invalid("a funnel needs between 2 and " + strconv.Itoa(maxFunnelSteps) + " steps")
+	}
+	if query.GetCorrelationKey() == "" {
+		return nil, invalid("correlation_key is required")
+	}
+	windowSeconds := query.GetWindowSeconds()
+	switch {
+	case windowSeconds < 0:
+		return nil, invalid("window_seconds must be positive")
+	case windowSeconds == 0:
+		windowSeconds = defaultFunnelWindowSeconds
+	}
+	if query.FromTimestamp != nil && query.ToTimestamp != nil && query.GetFromTimestamp() >= query.GetToTimestamp() {
+		return nil, invalid("from_timestamp must be before to_timestamp")
+	}
+	if len(query.GetFilter().GetEventTypes()) > 0 {
+		return nil, invalid("the event types of a funnel are its steps, the filter can't have any")
+	}
+	if retentionStart, ok := s.rollups.rawRetentionStart(); ok && query.FromTimestamp != nil && query.GetFromTimestamp() < retentionStart {
+		return nil, invalid("from_timestamp is older than the retention of the raw events")
+	}
+
+	stepTypes := make(map[model.ClientEventType]bool)
+	for _, step := range steps {
+		stepTypes[step] = true
+	}
+	entered := func(timestamp int64) bool {
+		if query.FromTimestamp != nil && timestamp < query.GetFromTimestamp() {
+			return false
+		}
+		return query.ToTimestamp == nil || timestamp < query.GetToTimestamp()
+	}
+
+	//Events before from can't be part of a funnel entered in the time range, events past to plus the window neither
+	correlated := make(map[string][]funnelEvent)
+	for _, event := range s.store.AllEvents() {
+		if !stepTypes[event.GetEventType()] {
+			continue
+		}
+		if query.FromTimestamp != nil && event.GetTimestamp() < query.GetFromTimestamp() {
+			continue
+		}
+		if query.ToTimestamp != nil && event.GetTimestamp() >= query.GetToTimestamp()+windowSeconds {
+			continue
+		}
+		if !eventFilterMatches(query.GetFilter(), event, event.GetDeviceType()) {
+			continue
+		}
+		value, ok := eventAttribute(event, query.GetCorrelationKey())
+		if !ok {
+			continue
+		}
+		key := attributeString(value)
+		correlated[key] = append(correlated[key], funnelEvent{timestamp: event.GetTimestamp(), eventType: event.GetEventType()})
+	}
+
+	counts := make([]uint64, len(steps))
+	for _, events := range correlated {
+		//Stored events come in event id order, which the stable sort keeps for events in the same second
+		sort.SliceStable(events, func(i, j int) bool { return events[i].timestamp < events[j].timestamp })
+		best := 0
+		for i, event := range events {
+			if event.eventType != steps[0] || !entered(event.timestamp) {
+				continue
+			}
+			if reached := funnelProgress(events, i, steps, windowSeconds); reached > best {
+				best = reached
+			}
+			if best == len(steps) {
+				break
+			}
+		}
+		for step := 0; step < best; step++ {
+			counts[step]++
+		}
+	}
+
+	resp := &model.FunnelResponse{CorrelationKey: query.GetCorrelationKey(), WindowSeconds: windowSeconds}
+	for i, step := range steps {
+		funnelStep := &model.FunnelStep{EventType: step, Count: counts[i]}
+		if i == 0 {
+			if counts[0] > 0 {
+				funnelStep.ConversionRate, funnelStep.OverallConversionRate = 1, 1
+			}
+		} else {
+			if counts[i-1] > 0 {
+				funnelStep.ConversionRate = float64(counts[i]) / float64(counts[i-1])
+			}
+			if counts[0] > 0 {
+				funnelStep.OverallConversionRate = float64(counts[i]) / float64(counts[0])
+			}
+		}
+		resp.Steps = append(resp.Steps, funnelStep)
+	}
+	return resp, nil
+}
+
+//Reads a funnel from the repeated step, correlation_key, window_seconds, from and to query parameters plus the
+//device_type and kv_pair filter ones
+func parseFunnelQuery(query url.Values) (*model.FunnelRequest, error) {
+	filter, err := parseEventFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	funnelQuery := &model.FunnelRequest{CorrelationKey: query.Get("correlation_key"), Filter: filter}
+	for _, value := range query["step"] {
+		step, err := parseEventType(value)
+		if err != nil {
+			return nil, errors.New("Unknown step " + value)
+		}
+		funnelQuery.Steps = append(funnelQuery.Steps, step)
+	}
+
+	if funnelQuery.FromTimestamp, err = timestampParameter(query, "from"); err != nil {
+		return nil, err
+	}
+	if funnelQuery.ToTimestamp, err = timestampParameter(query, "to"); err != nil {
+		return nil, err
+	}
+	if value := query.Get("window_seconds"); value != "" {
+		funnelQuery.WindowSeconds, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("window_seconds must be a number, got " + value)
+		}
+	}
+	return funnelQuery, nil
+}
+
+/*
+Per step counts and conversion rates of an ordered funnel of event types, e.g.
+GET /v1/funnels?step=USER_REGISTERED&step=UNKNOWN&correlation_key=user_id&window_seconds=86400
+*/
+func (s *Server) FunnelsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := s.RequestLogger(req).WithFields(log.Fields{"method": "FunnelsHandler"})
+
+		query, err := parseFunnelQuery(req.URL.Query())
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err.Error()}).Infoln("Invalid funnel query")
+			writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid funnel query", Description: err.Error()})
+			return
+		}
+		resp, errResp := s.queryFunnel(query)
+		if errResp != nil {
+			logger.WithFields(log.Fields{"error": errResp.GetDescription()}).Infoln("Invalid funnel query")
+			writeJSON(w, http.StatusBadRequest, errResp)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
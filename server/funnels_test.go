@@ -0,0 +1,144 @@
+package server
+
+import (
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func generateTestFunnelEvent(id string, eventType model.ClientEventType, timestamp int64, userId string) *model.ClientEventData {
+	return &model.ClientEventData{
+		EventId:    id,
+		EventType:  eventType,
+		Timestamp:  proto.Int64(timestamp),
+		DeviceType: "android",
+		KvPair:     []*model.KeyValuePair{{Key: "user_id", Value: proto.String(userId)}},
+	}
+}
+
+//Store holding the events of users going through registration and later events
+func generateTestFunnelStore() *MemoryStore {
+	registered, other := model.ClientEventType_USER_REGISTERED, model.ClientEventType_UNKNOWN
+	events := []*model.ClientEventData{
+		generateTestFunnelEvent("a1", registered, 1000, "u1"),
+		generateTestFunnelEvent("b1", other, 2000, "u1"),
+		generateTestFunnelEvent("c1", other, 3000, "u1"),
+		generateTestFunnelEvent("a2", registered, 1000, "u2"),
+		generateTestFunnelEvent("b2", other, 5000, "u2"),
+		generateTestFunnelEvent("a3", registered, 1000, "u3"),
+		generateTestFunnelEvent("b3", registered, 4000, "u3"),
+		generateTestFunnelEvent("c3", other, 5000, "u3"),
+		generateTestFunnelEvent("a4", other, 500, "u4"),
+		generateTestFunnelEvent("b4", registered, 1000, "u4"),
+		generateTestFunnelEvent("a5", other, 1000, "u5"),
+		generateTestFunnelEvent("a6", registered, 20000, "u6"),
+		generateTestFunnelEvent("b6", other, 20100, "u6"),
+		generateTestFunnelEvent("a8", registered, 1000, "u8"),
+		generateTestFunnelEvent("b8", other, 1000, "u8"),
+		{EventId: "a9", EventType: other, Timestamp: proto.Int64(2000)},
+	}
+	events[1].DeviceType, events[2].DeviceType, events[0].DeviceType = "ios", "ios", "ios"
+	//Typed and string values of the same user should be tied together
+	typed := generateTestFunnelEvent("a7", registered, 1000, "")
+	typed.KvPair[0].TypedValue = &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 7}}
+	events = append(events, typed, generateTestFunnelEvent("b7", other, 1100, "7"))
+
+	store := NewMemoryStore()
+	for _, event := range events {
+		store.StoreEvent(event)
+	}
+	return store
+}
+
+func TestQueryFunnel(t *testing.T) {
+	s := newTestServer(t, WithStore(generateTestFunnelStore()))
+	registered, other := model.ClientEventType_USER_REGISTERED, model.ClientEventType_UNKNOWN
+
+	resp, errResp := s.queryFunnel(&model.FunnelRequest{Steps: []model.ClientEventType{registered, other}, CorrelationKey: "user_id",
+		WindowSeconds: 3600, FromTimestamp: proto.Int64(0), ToTimestamp: proto.Int64(10000)})
+	assert.Nil(t, errResp, "Valid funnel should be accepted")
+	assert.Equal(t, int64(3600), resp.GetWindowSeconds())
+	if assert.Equal(t, 2, len(resp.GetSteps())) {
+		assert.Equal(t, registered, resp.GetSteps()[0].GetEventType())
+		assert.Equal(t, uint64(6), resp.GetSteps()[0].GetCount(), "Users entering in the time range should be counted once")
+		assert.Equal(t, float64(1), resp.GetSteps()[0].GetConversionRate())
+		assert.Equal(t, uint64(4), resp.GetSteps()[1].GetCount(), "Users should only convert in order and within the window")
+		assert.Equal(t, float64(4)/6, resp.GetSteps()[1].GetConversionRate())
+		assert.Equal(t, float64(4)/6, resp.GetSteps()[1].GetOverallConversionRate())
+	}
+
+	resp, errResp = s.queryFunnel(&model.FunnelRequest{Steps: []model.ClientEventType{registered, other, other}, CorrelationKey: "user_id",
+		WindowSeconds: 3600, ToTimestamp: proto.Int64(10000)})
+	assert.Nil(t, errResp)
+	if assert.Equal(t, 3, len(resp.GetSteps())) {
+		assert.Equal(t, uint64(1), resp.GetSteps()[2].GetCount(), "Every step needs its own later event")
+		assert.Equal(t, float64(1)/4, resp.GetSteps()[2].GetConversionRate())
+		assert.Equal(t, float64(1)/6, resp.GetSteps()[2].GetOverallConversionRate())
+	}
+
+	resp, _ = s.queryFunnel(&model.FunnelRequest{Steps: []model.ClientEventType{registered, other}, CorrelationKey: "user_id"})
+	assert.Equal(t, int64(defaultFunnelWindowSeconds), resp.GetWindowSeconds())
+	assert.Equal(t, uint64(7), resp.GetSteps()[0].GetCount(), "Every entry should count without a time range")
+	assert.Equal(t, uint64(6), resp.GetSteps()[1].GetCount(), "Later events within a day should convert by default")
+
+	resp, _ = s.queryFunnel(&model.FunnelRequest{Steps: []model.ClientEventType{registered, other}, CorrelationKey: "user_id",
+		Filter: &model.EventFilter{DeviceTypes: []string{"ios"}}})
+	assert.Equal(t, uint64(1), resp.GetSteps()[0].GetCount(), "Only events matching the filter should be used")
+
+	resp, _ = s.queryFunnel(&model.FunnelRequest{Steps: []model.ClientEventType{registered, other}, CorrelationKey: "session_id"})
+	assert.Equal(t, uint64(0), resp.GetSteps()[0].GetCount())
+	assert.Equal(t, float64(0), resp.GetSteps()[1].GetConversionRate(), "Rates of empty steps should be 0")
+}
+
+func TestQueryFunnel_Invalid(t *testing.T) {
+	s := newTestServer(t, WithStore(NewMemoryStore()))
+	steps := []model.ClientEventType{model.ClientEventType_USER_REGISTERED, model.ClientEventType_UNKNOWN}
+	invalid := []*model.FunnelRequest{
+		{Steps: steps},
+		{Steps: steps[:1], CorrelationKey: "user_id"},
+		{Steps: make([]model.ClientEventType, maxFunnelSteps+1), CorrelationKey: "user_id"},
+		{Steps: steps, CorrelationKey: "user_id", WindowSeconds: -1},
+		{Steps: steps, CorrelationKey: "user_id", FromTimestamp: proto.Int64(2), ToTimestamp: proto.Int64(1)},
+		{Steps: steps, CorrelationKey: "user_id", Filter: &model.EventFilter{EventTypes: steps}},
+	}
+	for _, query := range invalid {
+		_, errResp := s.queryFunnel(query)
+		assert.NotNil(t, errResp, "Invalid funnel should be rejected: "+query.String())
+	}
+
+	s.rollups.SetRetention(RollupSettings{RawRetentionInHours: 1, MinuteRetentionInHours: 1, HourRetentionInDays: 1, DayRetentionInDays: 1})
+	_, errResp := s.queryFunnel(&model.FunnelRequest{Steps: steps, CorrelationKey: "user_id", FromTimestamp: proto.Int64(0)})
+	assert.NotNil(t, errResp, "Funnels starting before the raw event retention should be rejected")
+}
+
+func TestFunnelsHandler(t *testing.T) {
+	s := newTestServer(t, WithStore(generateTestFunnelStore()))
+
+	query := url.Values{"step": {"user_registered", strconv.Itoa(int(model.ClientEventType_UNKNOWN))}, "correlation_key": {"user_id"},
+		"window_seconds": {"3600"}, "to": {"10000"}, "device_type": {"android"}}
+	req, _ := http.NewRequest("GET", "/v1/funnels?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := new(model.FunnelResponse)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), resp), "Response should be a FunnelResponse")
+	assert.Equal(t, "user_id", resp.GetCorrelationKey())
+	if assert.Equal(t, 2, len(resp.GetSteps())) {
+		assert.Equal(t, uint64(5), resp.GetSteps()[0].GetCount())
+		assert.Equal(t, uint64(3), resp.GetSteps()[1].GetCount())
+	}
+
+	for _, invalid := range []string{"step=MEOW&step=UNKNOWN&correlation_key=user_id", "step=USER_REGISTERED&step=UNKNOWN&correlation_key=user_id&window_seconds=day"} {
+		req, _ = http.NewRequest("GET", "/v1/funnels?"+invalid, nil)
+		w = httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid parameters should be rejected: "+invalid)
+	}
+}
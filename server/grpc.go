@@ -0,0 +1,413 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"meowtrics/model"
+	"net"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+//gRPC metadata keys are lower case, the values match the HTTP headers of the same name
+const (
+	grpcApiKeyMetadata    = "x-api-key"
+	grpcRequestIdMetadata = "x-request-id"
+	grpcTenantIdMetadata  = "x-tenant-id"
+)
+
+//Events returned by QueryEvents when the request sets no limit, and the most it returns at a time
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+//Decides whether a call to the gRPC method fullMethod, e.g. /meowtrics.v1.Meowtrics/UploadEvents, may go ahead
+type GRPCAuthFunc func(ctx context.Context, fullMethod string) bool
+
+//Replaces the grpcApiKey check guarding every gRPC method
+func WithGRPCAuth(auth GRPCAuthFunc) Option {
+	return func(s *Server) {
+		s.grpcAuth = auth
+	}
+}
+
+type grpcLoggerKey struct{}
+type grpcRateLimitKeysKey struct{}
+
+//Default gRPC auth: a verified client certificate with a subject in grpcCertSubjects, or the grpcApiKey from the config
+//in the x-api-key metadata. Every call is let through when neither is configured.
+func (s *Server) checkGRPCApiKey(ctx context.Context, fullMethod string) bool {
+	key := s.grpcApiKey.get()
+	if key == "" && s.grpcCertSubjects.empty() {
+		return true
+	}
+	if s.grpcCertSubjects.allowed(grpcCertificateSubject(ctx)) {
+		return true
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md[grpcApiKeyMetadata]
+	return key != "" && len(values) == 1 && subtle.ConstantTimeCompare([]byte(values[0]), []byte(key)) == 1
+}
+
+//Like CertificateSubject for the connection of a gRPC call
+func grpcCertificateSubject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	return verifiedSubject(&info.State)
+}
+
+//The rate limit dimensions of a gRPC call like rateLimitKeys for HTTP, taken from the peer address and the x-api-key
+//and x-tenant-id metadata
+func grpcRateLimitKeys(ctx context.Context) map[string]string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := map[string]string{"ip": "", "apiKey": "", "tenant": ""}
+	if values := md[grpcApiKeyMetadata]; len(values) == 1 {
+		keys["apiKey"] = values[0]
+	}
+	if values := md[grpcTenantIdMetadata]; len(values) == 1 {
+		keys["tenant"] = values[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		ip, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			ip = p.Addr.String()
+		}
+		keys["ip"] = ip
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if identity := certificateIdentity(&info.State); identity != "" {
+				keys["apiKey"] = identity
+			}
+		}
+	}
+	return keys
+}
+
+//Checks the events per minute quota of a call that went through interceptCall, like processEventQuota for HTTP
+func (s *Server) grpcEventQuota(ctx context.Context, count int, logger *log.Entry) error {
+	keys, ok := ctx.Value(grpcRateLimitKeysKey{}).(map[string]string)
+	if !ok {
+		return nil
+	}
+
+	allowed, retryAfter := s.rateLimiter.AllowEvents(keys, count)
+	if allowed {
+		return nil
+	}
+
+	logger.WithFields(log.Fields{"method": "grpcEventQuota", "error": RateLimitedError.Error(), "ip": keys["ip"], "tenant": keys["tenant"], "events": count}).Infoln("Event quota exceeded")
+	return status.Errorf(codes.ResourceExhausted, "Event quota exceeded, retry after %d seconds", retryAfterSeconds(retryAfter))
+}
+
+//The gRPC API from metrics.proto with reflection, backed by the same store, ingestion queue and event stream as the
+//HTTP API. Calls are authorized by the server's GRPCAuthFunc and logged like HTTP requests.
+func (s *Server) newGRPCServer(tlsConfig *tls.Config) *grpc.Server {
+	options := []grpc.ServerOption{grpc.UnaryInterceptor(s.unaryInterceptor), grpc.StreamInterceptor(s.streamInterceptor)}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	srv := grpc.NewServer(options...)
+	service := &grpcService{s: s}
+	model.RegisterMeowtricsServer(srv, service)
+	srv.RegisterService(&legacyMeowtricsServiceDesc, service)
+	reflection.Register(srv)
+	return srv
+}
+
+//The service under its name from before metrics.proto got the meowtrics.v1 package, for clients built against the old
+//name. Its unary calls are logged and counted under the meowtrics.v1 method names.
+var legacyMeowtricsServiceDesc = func() grpc.ServiceDesc {
+	desc := model.Meowtrics_ServiceDesc
+	desc.ServiceName = "model.Meowtrics"
+	return desc
+}()
+
+//Listens on grpcPort and serves the gRPC API in the background, see Start
+func (s *Server) startGRPC(tlsConfig *tls.Config) error {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(s.config.GRPCPort))
+	if err != nil {
+		return err
+	}
+	s.grpcServer = s.newGRPCServer(tlsConfig)
+	go s.grpcServer.Serve(listener)
+
+	s.logger.WithFields(log.Fields{"method": "startGRPC", "addr": listener.Addr().String(), "tls": tlsConfig != nil}).Infoln("gRPC server started")
+	return nil
+}
+
+//Waits for the running calls like the HTTP server does for in flight requests, the ones still running after timeout are cut off
+func stopGRPC(srv *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		srv.Stop()
+	}
+}
+
+//Rate limits and authorizes the call and adds a logger carrying the grpcRequestId and the rate limit keys to its
+//context, logs the call once it finished
+func (s *Server) interceptCall(ctx context.Context, fullMethod string, call func(ctx context.Context) error) error {
+	start := time.Now()
+
+	requestId := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[grpcRequestIdMetadata]) == 1 {
+		requestId = md[grpcRequestIdMetadata][0]
+	}
+	if !isValidRequestId(requestId) {
+		requestId = newRequestId()
+	}
+	logger := s.logger.WithFields(log.Fields{"grpcRequestId": requestId})
+
+	keys := grpcRateLimitKeys(ctx)
+	var err error
+	if ok, retryAfter := s.rateLimiter.AllowRequest(keys); !ok {
+		logger.WithFields(log.Fields{"method": "interceptCall", "error": RateLimitedError.Error(), "grpcMethod": fullMethod, "ip": keys["ip"], "tenant": keys["tenant"]}).Infoln("Request rate limit exceeded")
+		err = status.Errorf(codes.ResourceExhausted, "Request rate limit exceeded, retry after %d seconds", retryAfterSeconds(retryAfter))
+	} else if s.grpcAuth(ctx, fullMethod) {
+		ctx := context.WithValue(context.WithValue(ctx, grpcLoggerKey{}, logger), grpcRateLimitKeysKey{}, keys)
+		err = call(ctx)
+	} else {
+		logger.WithFields(log.Fields{"method": "interceptCall", "error": UnauthorizedError.Error(), "grpcMethod": fullMethod}).Warningln("Rejected gRPC call")
+		err = status.Errorf(codes.Unauthenticated, "API key is missing or invalid")
+	}
+
+	code := status.Code(err).String()
+	elapsed := time.Since(start)
+	s.metrics.grpcRequestsTotal.Inc(fullMethod, code)
+	s.metrics.grpcRequestDuration.Observe(elapsed.Seconds(), fullMethod, code)
+
+	remoteAddr := notAvailableLogText
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	logger.WithFields(log.Fields{
+		"type":       "grpc",
+		"grpcMethod": fullMethod,
+		"remoteAddr": remoteAddr,
+		"code":       code,
+		"latencyMs":  float64(elapsed.Nanoseconds()) / float64(time.Millisecond),
+	}).Infoln("gRPC call completed")
+	return err
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var resp interface{}
+	err := s.interceptCall(ctx, info.FullMethod, func(ctx context.Context) error {
+		var err error
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return s.interceptCall(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+		return handler(srv, &interceptedStream{ServerStream: ss, ctx: ctx})
+	})
+}
+
+//Hands the context built by interceptCall to stream handlers
+type interceptedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (is *interceptedStream) Context() context.Context {
+	return is.ctx
+}
+
+//Returns the call scoped logger added by interceptCall
+func (s *Server) grpcLogger(ctx context.Context) *log.Entry {
+	if logger, ok := ctx.Value(grpcLoggerKey{}).(*log.Entry); ok {
+		return logger
+	}
+	return log.NewEntry(s.logger)
+}
+
+//Turns the error response of the shared processing functions into a gRPC error with the matching code
+func grpcError(code codes.Code, errResp *model.ErrorResponse) error {
+	message := errResp.GetErrorMessage()
+	if errResp.GetDescription() != "" {
+		message += ": " + errResp.GetDescription()
+	}
+	return status.Errorf(code, "%s", message)
+}
+
+//Maps the errors of processUploadRequest to gRPC codes like processJsonPost does to HTTP status codes
+func uploadError(err error, errResp *model.ErrorResponse) error {
+	switch err {
+	case InvalidParametersError:
+		return grpcError(codes.InvalidArgument, errResp)
+	case QueueFullError:
+		return grpcError(codes.ResourceExhausted, errResp)
+	}
+	return grpcError(codes.Internal, errResp)
+}
+
+type grpcService struct {
+	model.UnimplementedMeowtricsServer
+	s *Server
+}
+
+//Validated and stored, or queued with async ingestion, exactly like a POST /v1/events
+func (gs *grpcService) UploadEvents(ctx context.Context, uploadRequest *model.ClientEventUploadRequest) (*model.UploadEventsResponse, error) {
+	if err := gs.s.grpcEventQuota(ctx, len(uploadRequest.GetEvents()), gs.s.grpcLogger(ctx)); err != nil {
+		return nil, err
+	}
+	if err, errResp := gs.s.processUploadRequest(uploadRequest, gs.s.grpcLogger(ctx)); err != nil {
+		return nil, uploadError(err, errResp)
+	}
+
+	return &model.UploadEventsResponse{Events: proto.Int32(int32(len(uploadRequest.GetEvents()))), Queued: proto.Bool(gs.s.ingestion != nil)}, nil
+}
+
+func (gs *grpcService) GetEvent(ctx context.Context, req *model.GetEventRequest) (*model.ClientEventData, error) {
+	event, err := gs.s.store.RetrieveEvent(req.GetEventId())
+	switch err {
+	case nil:
+		return event, nil
+	case RecordNotFoundError:
+		gs.s.grpcLogger(ctx).WithFields(log.Fields{"method": "GetEvent", "id": req.GetEventId(), "error": err.Error()}).Infoln("Record not found")
+		return nil, status.Errorf(codes.NotFound, "No event with id %q", req.GetEventId())
+	case InvalidParametersError:
+		return nil, status.Errorf(codes.InvalidArgument, "event_id is required")
+	}
+	return nil, status.Errorf(codes.Internal, "%s", err.Error())
+}
+
+func (gs *grpcService) QueryMeasurements(ctx context.Context, req *model.MeasurementQueryRequest) (*model.MeasurementQueryResponse, error) {
+	resp, errResp := gs.s.queryMeasurements(req)
+	if errResp != nil {
+		gs.s.grpcLogger(ctx).WithFields(log.Fields{"method": "QueryMeasurements", "error": errResp.GetDescription()}).Infoln("Invalid query")
+		return nil, grpcError(codes.InvalidArgument, errResp)
+	}
+	return resp, nil
+}
+
+func (gs *grpcService) QueryRollups(ctx context.Context, req *model.RollupQueryRequest) (*model.RollupQueryResponse, error) {
+	resp, errResp := gs.s.queryRollups(req)
+	if errResp != nil {
+		gs.s.grpcLogger(ctx).WithFields(log.Fields{"method": "QueryRollups", "error": errResp.GetDescription()}).Infoln("Invalid query")
+		return nil, grpcError(codes.InvalidArgument, errResp)
+	}
+	return resp, nil
+}
+
+func (gs *grpcService) QueryFunnel(ctx context.Context, req *model.FunnelRequest) (*model.FunnelResponse, error) {
+	resp, errResp := gs.s.queryFunnel(req)
+	if errResp != nil {
+		gs.s.grpcLogger(ctx).WithFields(log.Fields{"method": "QueryFunnel", "error": errResp.GetDescription()}).Infoln("Invalid query")
+		return nil, grpcError(codes.InvalidArgument, errResp)
+	}
+	return resp, nil
+}
+
+func (gs *grpcService) QueryEvents(ctx context.Context, req *model.QueryEventsRequest) (*model.QueryEventsResponse, error) {
+	resp, errResp := gs.s.queryEvents(req)
+	if errResp != nil {
+		gs.s.grpcLogger(ctx).WithFields(log.Fields{"method": "QueryEvents", "error": errResp.GetDescription()}).Infoln("Invalid query")
+		return nil, grpcError(codes.InvalidArgument, errResp)
+	}
+	return resp, nil
+}
+
+//Streams newly stored events like GET /v1/events/stream, until the client cancels or the server shuts down
+func (gs *grpcService) SubscribeEvents(req *model.SubscribeEventsRequest, stream model.Meowtrics_SubscribeEventsServer) error {
+	logger := gs.s.grpcLogger(stream.Context()).WithFields(log.Fields{"method": "SubscribeEvents"})
+
+	replay, subscriber, err := gs.s.stream.Subscribe(req.GetFilter(), req.GetLastEventId(), req.LastEventId != nil)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err.Error()}).Warningln("Rejected event stream subscriber")
+		return status.Errorf(codes.Unavailable, "%s", err.Error())
+	}
+	logger.WithFields(log.Fields{"replayed": len(replay)}).Infoln("Event stream opened")
+
+	for _, streamed := range replay {
+		if err := stream.Send(streamed); err != nil {
+			gs.s.stream.Unsubscribe(subscriber, streamDisconnectTransport)
+			return err
+		}
+	}
+	for {
+		select {
+		case streamed, ok := <-subscriber.events:
+			if !ok {
+				logger.WithFields(log.Fields{"error": subscriber.err.Error()}).Infoln("Event stream closed")
+				return status.Errorf(codes.Unavailable, "%s", subscriber.err.Error())
+			}
+			if err := stream.Send(streamed); err != nil {
+				gs.s.stream.Unsubscribe(subscriber, streamDisconnectTransport)
+				return err
+			}
+		case <-stream.Context().Done():
+			gs.s.stream.Unsubscribe(subscriber, streamDisconnectClient)
+			logger.WithFields(log.Fields{"reason": streamDisconnectClient}).Infoln("Event stream closed")
+			return stream.Context().Err()
+		}
+	}
+}
+
+/*
+Pages through the stored events matching the query in event id order. Filters on device types use the device type
+recorded when the event was stored, events stored before it was recorded have none.
+*/
+func (s *Server) queryEvents(query *model.QueryEventsRequest) (*model.QueryEventsResponse, *model.ErrorResponse) {
+	invalid := func(description string) *model.ErrorResponse {
+		return &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid query", Description: description}
+	}
+
+	if query.FromTimestamp != nil && query.ToTimestamp != nil && query.GetFromTimestamp() >= query.GetToTimestamp() {
+		return nil, invalid("from_timestamp must be before to_timestamp")
+	}
+	limit := int(query.GetLimit())
+	switch {
+	case limit < 0 || limit > maxQueryLimit:
+		return nil, invalid("limit must be between 0 and " + strconv.Itoa(maxQueryLimit))
+	case limit == 0:
+		limit = defaultQueryLimit
+	}
+
+	resp := new(model.QueryEventsResponse)
+	for _, event := range s.store.AllEvents() {
+		if query.GetAfterEventId() != "" && event.GetEventId() <= query.GetAfterEventId() {
+			continue
+		}
+		if query.FromTimestamp != nil && event.GetTimestamp() < query.GetFromTimestamp() {
+			continue
+		}
+		if query.ToTimestamp != nil && event.GetTimestamp() >= query.GetToTimestamp() {
+			continue
+		}
+		if !eventFilterMatches(query.GetFilter(), event, event.GetDeviceType()) {
+			continue
+		}
+		if len(resp.Events) == limit {
+			resp.NextAfterEventId = resp.Events[limit-1].GetEventId()
+			break
+		}
+		resp.Events = append(resp.Events, event)
+	}
+	return resp, nil
+}
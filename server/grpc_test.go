@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"meowtrics/model"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+//Serves the gRPC API of s on a random local port, the returned func stops it again
+func newTestGRPCClient(t *testing.T, s *Server) (model.MeowtricsClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error listening: " + err.Error())
+	}
+	srv := s.newGRPCServer(nil)
+	go srv.Serve(listener)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal("Error dialing: " + err.Error())
+	}
+	return model.NewMeowtricsClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func generateTestQueryEvents(count int) *MemoryStore {
+	store := NewMemoryStore()
+	for i := 0; i < count; i++ {
+		event := generateTestClientEvent()
+		event.EventId, event.Timestamp = "event"+strconv.Itoa(i), proto.Int64(int64(1000+i))
+		store.StoreEvent(event)
+	}
+	return store
+}
+
+func TestQueryEvents(t *testing.T) {
+	s := newTestServer(t, WithStore(generateTestQueryEvents(5)))
+
+	from, to, limit := int64(1001), int64(1004), int32(2)
+	resp, errResp := s.queryEvents(&model.QueryEventsRequest{FromTimestamp: &from, ToTimestamp: &to, Limit: limit})
+	assert.Nil(t, errResp, "Valid query should be accepted")
+	if assert.Equal(t, 2, len(resp.GetEvents())) {
+		assert.Equal(t, "event1", resp.GetEvents()[0].GetEventId())
+		assert.Equal(t, "event2", resp.GetEvents()[1].GetEventId())
+	}
+	assert.Equal(t, "event2", resp.GetNextAfterEventId(), "Next page should start after the last event")
+
+	resp, errResp = s.queryEvents(&model.QueryEventsRequest{FromTimestamp: &from, ToTimestamp: &to, Limit: limit, AfterEventId: resp.GetNextAfterEventId()})
+	assert.Nil(t, errResp)
+	if assert.Equal(t, 1, len(resp.GetEvents()), "to_timestamp should be exclusive") {
+		assert.Equal(t, "event3", resp.GetEvents()[0].GetEventId())
+	}
+	assert.Empty(t, resp.GetNextAfterEventId(), "Last page should have no next page")
+
+	eventType := model.ClientEventType_USER_REGISTERED
+	resp, errResp = s.queryEvents(&model.QueryEventsRequest{Filter: &model.EventFilter{EventTypes: []model.ClientEventType{eventType}}})
+	assert.Nil(t, errResp)
+	assert.Equal(t, 0, len(resp.GetEvents()), "Other event types should not match")
+
+	resp, errResp = s.queryEvents(&model.QueryEventsRequest{Filter: &model.EventFilter{DeviceTypes: []string{"ios"}}})
+	assert.Nil(t, errResp)
+	assert.Equal(t, 0, len(resp.GetEvents()), "Events without a device type should not match device types")
+	limit = maxQueryLimit + 1
+	_, errResp = s.queryEvents(&model.QueryEventsRequest{Limit: limit})
+	assert.NotNil(t, errResp, "Limit should be capped")
+}
+
+func TestGRPCService(t *testing.T) {
+	s := newTestServer(t, WithStore(NewMemoryStore()))
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+	ctx := context.Background()
+
+	uploadRequest := generateTestClientEventUploadRequest_Valid()
+	resp, err := client.UploadEvents(ctx, uploadRequest)
+	assert.NoError(t, err, "Error uploading events")
+	assert.Equal(t, int32(1), resp.GetEvents())
+	assert.False(t, resp.GetQueued(), "Events should be stored right away without async ingestion")
+
+	_, err = client.UploadEvents(ctx, generateTestClientEventUploadRequest_Invalid())
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Events without ids should be rejected")
+
+	event, err := client.GetEvent(ctx, &model.GetEventRequest{EventId: "123"})
+	assert.NoError(t, err, "Error getting event")
+	assert.Equal(t, "testTestTestTestTest", event.GetData())
+	_, err = client.GetEvent(ctx, &model.GetEventRequest{EventId: "456"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	query, err := client.QueryEvents(ctx, &model.QueryEventsRequest{})
+	assert.NoError(t, err, "Error querying events")
+	assert.Equal(t, 1, len(query.GetEvents()))
+	query, err = client.QueryEvents(ctx, &model.QueryEventsRequest{Filter: &model.EventFilter{DeviceTypes: []string{uploadRequest.GetDeviceType()}}})
+	assert.NoError(t, err, "Error querying events")
+	assert.Equal(t, 1, len(query.GetEvents()), "Stored events should keep the device type of the upload")
+	_, err = client.QueryEvents(ctx, &model.QueryEventsRequest{Limit: maxQueryLimit + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.QueryMeasurements(ctx, &model.MeasurementQueryRequest{Name: "app_start"})
+	assert.NoError(t, err, "Error querying measurements")
+	_, err = client.QueryMeasurements(ctx, &model.MeasurementQueryRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Measurement queries without a name should be rejected")
+
+	rollups, err := client.QueryRollups(ctx, &model.RollupQueryRequest{BucketSeconds: 86400})
+	assert.NoError(t, err, "Error querying rollups")
+	assert.Equal(t, "day", rollups.GetTier())
+	_, err = client.QueryRollups(ctx, &model.RollupQueryRequest{GroupBy: []string{"country"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Rollup queries with an unknown group_by should be rejected")
+
+	steps := []model.ClientEventType{model.ClientEventType_USER_REGISTERED, model.ClientEventType_UNKNOWN}
+	funnel, err := client.QueryFunnel(ctx, &model.FunnelRequest{Steps: steps, CorrelationKey: "user_id"})
+	assert.NoError(t, err, "Error querying funnel")
+	assert.Equal(t, 2, len(funnel.GetSteps()))
+	_, err = client.QueryFunnel(ctx, &model.FunnelRequest{Steps: steps})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Funnels without a correlation key should be rejected")
+
+	assert.Equal(t, float64(1), s.metrics.grpcRequestsTotal.Value("/meowtrics.v1.Meowtrics/UploadEvents", codes.OK.String()))
+}
+
+func TestGRPCService_SubscribeEvents(t *testing.T) {
+	s := newTestServer(t)
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.SubscribeEvents(ctx, &model.SubscribeEventsRequest{Filter: &model.EventFilter{DeviceTypes: []string{"testDeviceAndroid"}}})
+	assert.NoError(t, err, "Error subscribing")
+	waitForSubscribers(t, s, 1)
+
+	postTestEvent(t, s.Handler())
+	streamed, err := stream.Recv()
+	assert.NoError(t, err, "Error receiving event")
+	assert.Equal(t, uint64(1), streamed.GetId())
+	assert.Equal(t, "123", streamed.GetEvent().GetEventId())
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.stream.Subscribers() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, float64(1), s.metrics.streamDisconnectsTotal.Value(streamDisconnectClient), "Cancelled calls should unsubscribe")
+}
+
+func TestGRPCService_Auth(t *testing.T) {
+	s := newTestServer(t)
+	s.grpcApiKey.set("testGRPCKey")
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+
+	_, err := client.GetEvent(context.Background(), &model.GetEventRequest{EventId: "123"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "Calls without the API key should be rejected")
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(grpcApiKeyMetadata, "testGRPCKey"))
+	_, err = client.GetEvent(ctx, &model.GetEventRequest{EventId: "123"})
+	assert.NotEqual(t, codes.Unauthenticated, status.Code(err), "Calls with the API key should be let through")
+}
+
+func TestGRPCService_RateLimits(t *testing.T) {
+	s := newTestServer(t, WithStore(NewMemoryStore()))
+	s.rateLimiter.SetLimits(map[string]RateLimit{"tenant": {RequestsPerSecond: 0.01, Burst: 2}, "apiKey": {EventsPerMinute: 1}})
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(grpcTenantIdMetadata, "acme", grpcApiKeyMetadata, "testKey"))
+	_, err := client.UploadEvents(ctx, generateTestClientEventUploadRequest_Valid())
+	assert.Nil(t, err, "Calls within the limits should be let through")
+
+	uploadRequest := generateTestClientEventUploadRequest_Valid()
+	uploadRequest.Events[0].EventId = "456"
+	_, err = client.UploadEvents(ctx, uploadRequest)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "Events over the api key quota should be rejected")
+
+	_, err = client.GetEvent(ctx, &model.GetEventRequest{EventId: "123"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "Calls over the tenant request rate should be rejected")
+
+	_, err = client.GetEvent(context.Background(), &model.GetEventRequest{EventId: "123"})
+	assert.Nil(t, err, "Calls of other tenants should be let through")
+}
+
+func TestCheckGRPCApiKey_CertSubjects(t *testing.T) {
+	s := newTestServer(t)
+	s.grpcCertSubjects.set([]string{"CN=ingest"})
+	withCert := func(commonName string) context.Context {
+		state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}}}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+
+	assert.True(t, s.checkGRPCApiKey(withCert("ingest"), "/meowtrics.v1.Meowtrics/UploadEvents"), "Allowed certificate subjects should be let through")
+	assert.False(t, s.checkGRPCApiKey(withCert("other"), "/meowtrics.v1.Meowtrics/UploadEvents"), "Other certificate subjects should be rejected")
+	assert.False(t, s.checkGRPCApiKey(context.Background(), "/meowtrics.v1.Meowtrics/UploadEvents"), "Configured subjects should turn on auth without a grpcApiKey")
+}
+
+func TestNewGRPCServer_LegacyServiceName(t *testing.T) {
+	srv := newTestServer(t).newGRPCServer(nil)
+	services := srv.GetServiceInfo()
+	for _, name := range []string{"meowtrics.v1.Meowtrics", "model.Meowtrics"} {
+		info, ok := services[name]
+		assert.True(t, ok, "Service should be registered as "+name)
+		assert.Equal(t, len(model.Meowtrics_ServiceDesc.Methods)+len(model.Meowtrics_ServiceDesc.Streams), len(info.Methods))
+	}
+}
@@ -1,72 +1,107 @@
-package main
+package server
 
 import (
+	"io/ioutil"
 	"meowtrics/model"
 	"net/http"
 	"time"
 
+	"github.com/gorilla/context"
 	"github.com/gorilla/mux"
 	"github.com/unrolled/render"
+	"google.golang.org/protobuf/proto"
 )
 
+//Stateless, shared by every Server
 var r render.Render
 
+//Writes m with the proto3 JSON mapping, a nil message is written as null
+func writeJSON(w http.ResponseWriter, status int, m proto.Message) {
+	data := []byte("null")
+	if m != nil && m.ProtoReflect().IsValid() {
+		var err error
+		if data, err = model.MarshalJSON(m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", APPLICATION_JSON+"; charset=UTF-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+//Decodes a JSON request body, the integer form of enums and 64 bit integers is only accepted with jsonLenient
+func (s *Server) unmarshalJSON(data []byte, m proto.Message) error {
+	if s.config.JSONLenient {
+		return model.UnmarshalJSON(data, m)
+	}
+	return model.UnmarshalCanonicalJSON(data, m)
+}
+
+func (s *Server) decodeJSONBody(req *http.Request, m proto.Message) error {
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return s.unmarshalJSON(data, m)
+}
+
 const (
 	APPLICATION_PROTOBUF = "application/x-protobuf"
 	APPLICATION_JSON     = "application/json"
+	APPLICATION_NDJSON   = "application/x-ndjson"
 	APPLICATION_ALL      = "*/*"
 )
 
-func HeartBeatHandler() http.Handler {
+func (s *Server) HeartBeatHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		status := "OK"
-		timestamp := time.Now().UTC().String()
-		r.JSON(w, http.StatusOK, model.HeartBeat{Status: &status, Timestamp: &timestamp})
+		writeJSON(w, http.StatusOK, &model.HeartBeat{Status: "OK", Timestamp: time.Now().UTC().String()})
 	})
 }
 
-func NotFoundHandler() http.Handler {
+func (s *Server) NotFoundHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		code := ContentNotFound
-		errorMesage := "Nothing to see here"
-		response := model.ErrorResponse{Code: &code, ErrorMessage: &errorMesage}
-		r.JSON(w, http.StatusNotFound, response)
+		response := &model.ErrorResponse{Code: ContentNotFound, ErrorMessage: "Nothing to see here"}
+		writeJSON(w, http.StatusNotFound, response)
 	})
 }
 
-func CreateEventHandler() http.Handler {
+func (s *Server) CreateEventHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		contentHeader := req.Header.Get("Content-Type")
 		var status int
 		var errResp *model.ErrorResponse
 		switch contentHeader {
 		case APPLICATION_JSON:
-			status, errResp = processJsonPost(req, meowtricsLogger)
+			status, errResp = s.processJsonPost(req, s.RequestLogger(req))
 		case APPLICATION_PROTOBUF:
-			status, errResp = processProtobufPost(req, meowtricsLogger)
+			status, errResp = s.processProtobufPost(req, s.RequestLogger(req))
 		default:
-			status, errResp = processUnsupportedMediaTypePost(req, meowtricsLogger)
+			status, errResp = processUnsupportedMediaTypePost(req, s.RequestLogger(req))
+		}
+		if retryAfter, ok := context.Get(req, retryAfterKey).(time.Duration); ok {
+			setRetryAfterHeader(w, retryAfter)
 		}
 		//Response body to this post request is always returned as JSON because it is human readable in case of errors
-		r.JSON(w, status, errResp)
+		writeJSON(w, status, errResp)
 	})
 }
 
-func RetrieveEventHandler() http.Handler {
+func (s *Server) RetrieveEventHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		acceptHeader := req.Header.Get("Accept")
 		id := mux.Vars(req)["id"]
 		switch acceptHeader {
 		case APPLICATION_PROTOBUF:
-			status, data := processProtobufGet(id, meowtricsLogger)
+			status, data := s.processProtobufGet(id, s.RequestLogger(req))
 			w.Header().Set("Content-Type", APPLICATION_PROTOBUF)
 			r.Data(w, status, data)
 		case APPLICATION_JSON, APPLICATION_ALL, "":
-			status, event := processJsonGet(id, meowtricsLogger)
-			r.JSON(w, status, event)
+			status, event := s.processJsonGet(id, s.RequestLogger(req))
+			writeJSON(w, status, event)
 		default:
-			status, errResp := processUnsupportedMediaTypeGet(req, meowtricsLogger)
-			r.JSON(w, status, errResp)
+			status, errResp := processUnsupportedMediaTypeGet(req, s.RequestLogger(req))
+			writeJSON(w, status, errResp)
 		}
 	})
 }
@@ -1,7 +1,6 @@
-package main
+package server
 
 import (
-	"encoding/json"
 	"meowtrics/model"
 	"net/http"
 	"net/http/httptest"
@@ -9,9 +8,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 //---------------Test Utils ------------------------
@@ -43,7 +42,7 @@ func GeneratePostHandleTester(t *testing.T, handleFunc http.Handler, contentType
 
 //Util function specifically for setting accept header for testing GET requests
 //This returns a func of type GetHandleTester which takes the url, acceptHeader, and the router pointer
-//For this case we are passing in the global getSubrouter which has RetrieveHandler() as the Handle function
+//For this case we are passing in the router of testServer which has RetrieveEventHandler() as the Handle function for GET /v1/events/{id}
 //It also extracts the path variables from the URL
 
 type GetHandleTester func(location string, acceptHeader string, router *mux.Router) *httptest.ResponseRecorder
@@ -64,36 +63,17 @@ func GenerateGetHandleTester(t *testing.T) GetHandleTester {
 	}
 }
 
-func generateTestClientEvent() model.ClientEventData {
-	id := "123"
-	eventType := model.ClientEventType_UNKNOWN
-	timestamp := time.Now().Unix()
-	data := "testTestTestTestTest"
-	return model.ClientEventData{EventId: &id, EventType: &eventType, Timestamp: &timestamp, Data: &data}
+func generateTestClientEvent() *model.ClientEventData {
+	return &model.ClientEventData{EventId: "123", EventType: model.ClientEventType_UNKNOWN, Timestamp: proto.Int64(time.Now().Unix()), Data: "testTestTestTestTest"}
 }
 
-func generateTestClientEventUploadRequest_Valid() model.ClientEventUploadRequest {
-	var mockReq model.ClientEventUploadRequest
-	requestId := "testRequestId"
-	deviceType := "testDeviceAndroid"
-	testEvent := generateTestClientEvent()
-
-	mockReq.RequestId = &requestId
-	mockReq.DeviceType = &deviceType
-	mockReq.Events = append(mockReq.Events, &testEvent)
-	return mockReq
+func generateTestClientEventUploadRequest_Valid() *model.ClientEventUploadRequest {
+	return &model.ClientEventUploadRequest{RequestId: "testRequestId", DeviceType: "testDeviceAndroid", Events: []*model.ClientEventData{generateTestClientEvent()}}
 }
 
-func generateTestClientEventUploadRequest_Invalid() model.ClientEventUploadRequest {
-	var mockReq model.ClientEventUploadRequest
-	requestId := "testRequestId"
-	deviceType := "testDeviceAndroid"
-	testEvent := generateTestClientEvent()
-	testEvent.EventId = nil
-
-	mockReq.RequestId = &requestId
-	mockReq.DeviceType = &deviceType
-	mockReq.Events = append(mockReq.Events, &testEvent)
+func generateTestClientEventUploadRequest_Invalid() *model.ClientEventUploadRequest {
+	mockReq := generateTestClientEventUploadRequest_Valid()
+	mockReq.Events[0].EventId = ""
 	return mockReq
 }
 
@@ -101,7 +81,7 @@ func generateTestClientEventUploadRequest_Invalid() model.ClientEventUploadReque
 
 func TestNotFoundHandler(t *testing.T) {
 
-	test := GeneratePostHandleTester(t, NotFoundHandler(), "application/json")
+	test := GeneratePostHandleTester(t, testServer.NotFoundHandler(), "application/json")
 
 	w := test("GET", "")
 	assert.Equal(t, http.StatusNotFound, w.Code, "Not found handler")
@@ -113,7 +93,7 @@ func TestNotFoundHandler(t *testing.T) {
 
 func TestHeartBeatHandler(t *testing.T) {
 
-	test := GeneratePostHandleTester(t, HeartBeatHandler(), "application/json")
+	test := GeneratePostHandleTester(t, testServer.HeartBeatHandler(), "application/json")
 
 	w := test("GET", "")
 	assert.Equal(t, http.StatusOK, w.Code, "HeartBeat response status")
@@ -123,10 +103,10 @@ func TestHeartBeatHandler(t *testing.T) {
 
 func TestCreateEventHandler_ValidJsonRequest(t *testing.T) {
 
-	eventMap = make(map[string]model.ClientEventData)
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/json")
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/json")
 	uploadReq := generateTestClientEventUploadRequest_Valid()
-	jsonReq, err := json.Marshal(uploadReq)
+	jsonReq, err := model.MarshalJSON(uploadReq)
 	if err != nil {
 		panic("Cannot marshal data. Error: " + err.Error())
 	}
@@ -136,16 +116,16 @@ func TestCreateEventHandler_ValidJsonRequest(t *testing.T) {
 	w := test("POST", string(jsonReq))
 	assert.Equal(t, http.StatusOK, w.Code, "Valid JSON request should be properly posted")
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 }
 
 func TestCreateEventHandler_InvalidJsonRequestWithNoEventId(t *testing.T) {
 
-	eventMap = make(map[string]model.ClientEventData)
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/json")
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/json")
 	uploadReq := generateTestClientEventUploadRequest_Invalid()
-	jsonReq, err := json.Marshal(uploadReq)
+	jsonReq, err := model.MarshalJSON(uploadReq)
 	if err != nil {
 		panic("Cannot marshal data. Error: " + err.Error())
 	}
@@ -153,24 +133,67 @@ func TestCreateEventHandler_InvalidJsonRequestWithNoEventId(t *testing.T) {
 	w := test("POST", string(jsonReq))
 	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid data without eventid should receive an error")
 
-	assert.Equal(t, 0, len(eventMap), "eventMap should be empty")
+	assert.Equal(t, 0, len(testStore.events), "eventMap should be empty")
 }
 
 func TestCreateEventHandler_MalformedJsonData(t *testing.T) {
 
-	eventMap = make(map[string]model.ClientEventData)
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/json")
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/json")
 
 	w := test("POST", "randomString")
 	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid data should receive an error")
 
-	assert.Equal(t, 0, len(eventMap), "eventMap should be empty")
+	assert.Equal(t, 0, len(testStore.events), "eventMap should be empty")
+}
+
+func TestCreateEventHandler_IntegerFormJsonRequest(t *testing.T) {
+	integerForm := `{"request_id": "testRequestId", "device_type": "testDeviceAndroid", "events": [{"event_id": "123", "event_type": 2, "timestamp": 1452548890}]}`
+
+	store := NewMemoryStore()
+	test := GeneratePostHandleTester(t, newTestServer(t, WithStore(store)).CreateEventHandler(), "application/json")
+	w := test("POST", integerForm)
+	assert.Equal(t, http.StatusOK, w.Code, "Integer enums and int64s should be accepted in lenient mode")
+	event, err := store.RetrieveEvent("123")
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.ClientEventType_USER_REGISTERED, event.GetEventType())
+		assert.Equal(t, int64(1452548890), event.GetTimestamp())
+	}
+
+	config := DefaultConfig()
+	config.JSONLenient = false
+	test = GeneratePostHandleTester(t, newTestServer(t, WithConfig(config)).CreateEventHandler(), "application/json")
+	w = test("POST", integerForm)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Only canonical JSON should be accepted without lenient mode")
+	w = test("POST", `{"request_id": "testRequestId", "device_type": "testDeviceAndroid", "events": [{"event_id": "123", "event_type": "USER_REGISTERED", "timestamp": "1452548890"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code, "Canonical JSON should be accepted")
+}
+
+func TestCreateEventHandler_MissingRequiredFields(t *testing.T) {
+	store := NewMemoryStore()
+	test := GeneratePostHandleTester(t, newTestServer(t, WithStore(store)).CreateEventHandler(), "application/json")
+
+	uploads := []string{
+		`{"events": [{"event_id": "x1"}]}`,
+		`{"device_type": "android", "events": [{"event_id": "x1", "event_type": "UNKNOWN", "timestamp": "1452548890"}]}`,
+		`{"request_id": "r", "events": [{"event_id": "x1", "event_type": "UNKNOWN", "timestamp": "1452548890"}]}`,
+		`{"request_id": "r", "device_type": "android", "events": [{"event_id": "x1", "timestamp": "1452548890"}]}`,
+		`{"request_id": "r", "device_type": "android", "events": [{"event_id": "x1", "event_type": "UNKNOWN"}]}`,
+	}
+	for _, upload := range uploads {
+		w := test("POST", upload)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Uploads missing a required field should be rejected: "+upload)
+		errResp := new(model.ErrorResponse)
+		model.UnmarshalJSON(w.Body.Bytes(), errResp)
+		assert.Equal(t, InvalidRequestParameters, errResp.GetCode())
+	}
+	assert.Equal(t, 0, store.CountEvents(), "Rejected uploads should not be stored")
 }
 
 //--------------------------------Unsupported media type-----------
 
 func TestCreateEventHandler_UnsupportedMediaTypePost(t *testing.T) {
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/meow")
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/meow")
 	w := test("POST", "meowtrics")
 	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, "UnsupportedMedia should be the header")
 }
@@ -178,7 +201,7 @@ func TestCreateEventHandler_UnsupportedMediaTypePost(t *testing.T) {
 func TestRetrieveEventHandler_UnsupportedMediaTypeGet(t *testing.T) {
 	testGet := GenerateGetHandleTester(t)
 
-	w := testGet("/v1/events/1", "application/meow", getSubrouter)
+	w := testGet("/v1/events/1", "application/meow", testServer.router)
 	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, "UnsupportedMedia should be the header")
 }
 
@@ -186,10 +209,10 @@ func TestRetrieveEventHandler_UnsupportedMediaTypeGet(t *testing.T) {
 
 func TestCreateEventHandler_ValidProtobufRequest(t *testing.T) {
 
-	eventMap = make(map[string]model.ClientEventData)
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/x-protobuf")
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/x-protobuf")
 	uploadReq := generateTestClientEventUploadRequest_Valid()
-	protoBytes, err := proto.Marshal(&uploadReq)
+	protoBytes, err := proto.Marshal(uploadReq)
 	if err != nil {
 		panic("Cannot marshal data. Error: " + err.Error())
 	}
@@ -197,9 +220,9 @@ func TestCreateEventHandler_ValidProtobufRequest(t *testing.T) {
 	w := test("POST", string(protoBytes))
 	assert.Equal(t, http.StatusOK, w.Code, "Valid protocol buffer request should be properly posted")
 
-	assert.Equal(t, 1, len(eventMap), "eventMap should have one entry")
+	assert.Equal(t, 1, len(testStore.events), "eventMap should have one entry")
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 }
 
@@ -208,10 +231,10 @@ func TestCreateEventHandler_ValidProtobufRequest(t *testing.T) {
 
 func TestCreateEventHandler_InvalidProtobufRequestWithNoEventId(t *testing.T) {
 
-	eventMap = make(map[string]model.ClientEventData)
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/x-protobuf")
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/x-protobuf")
 	uploadReq := generateTestClientEventUploadRequest_Invalid()
-	protoBytes, err := proto.Marshal(&uploadReq)
+	protoBytes, err := proto.Marshal(uploadReq)
 	if err != nil {
 		panic("Cannot marshal data. Error: " + err.Error())
 	}
@@ -219,61 +242,62 @@ func TestCreateEventHandler_InvalidProtobufRequestWithNoEventId(t *testing.T) {
 	w := test("POST", string(protoBytes))
 	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid data without eventid should receive an error")
 
-	assert.Equal(t, 0, len(eventMap), "eventMap should be empty")
+	assert.Equal(t, 0, len(testStore.events), "eventMap should be empty")
 }
 */
 
 func TestCreateEventHandler_MalformedProtobufData(t *testing.T) {
 
-	eventMap = make(map[string]model.ClientEventData)
-	test := GeneratePostHandleTester(t, CreateEventHandler(), "application/x-protobuf")
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/x-protobuf")
 
 	w := test("POST", "randomString")
 	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid data should receive an error")
 
-	assert.Equal(t, 0, len(eventMap), "eventMap should be empty")
+	assert.Equal(t, 0, len(testStore.events), "eventMap should be empty")
 }
 
 //--------------------------------JSON GET tests----------------------------
 
 func TestRetrieveEventHandler_ValidRouteVariable_JSON(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	StoreEvent(testEvent)
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/123", "application/json", getSubrouter)
+	w := testGet("/v1/events/123", "application/json", testServer.router)
 
 	assert.Equal(t, http.StatusOK, w.Code, "Http status should be 200")
 
 	actualEvent := new(model.ClientEventData)
 	//fmt.Println("Response body: " + w.Body.String())
-	err := json.Unmarshal([]byte(w.Body.String()), actualEvent)
+	err := model.UnmarshalJSON([]byte(w.Body.String()), actualEvent)
 	if err != nil {
 		panic("Error unmarshalling json response: " + err.Error())
 	}
 
 	assert.Equal(t, testEvent.GetData(), actualEvent.GetData(), "Event data should be equal")
+	assert.Contains(t, w.Body.String(), `"UNKNOWN"`, "Event types should be written by name")
 }
 
 func TestRetrieveEventHandler_ValidRouteVariable_NoContentType(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	StoreEvent(testEvent)
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/123", "", getSubrouter)
+	w := testGet("/v1/events/123", "", testServer.router)
 
 	assert.Equal(t, http.StatusOK, w.Code, "Http status should be 200")
 
 	actualEvent := new(model.ClientEventData)
-	err := json.Unmarshal([]byte(w.Body.String()), actualEvent)
+	err := model.UnmarshalJSON([]byte(w.Body.String()), actualEvent)
 	if err != nil {
 		panic("Error unmarshalling json response: " + err.Error())
 	}
@@ -282,20 +306,20 @@ func TestRetrieveEventHandler_ValidRouteVariable_NoContentType(t *testing.T) {
 }
 
 func TestRetrieveEventHandler_ValidRouteVariable_GenericContentType(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	StoreEvent(testEvent)
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/123", "*/*", getSubrouter)
+	w := testGet("/v1/events/123", "*/*", testServer.router)
 
 	assert.Equal(t, http.StatusOK, w.Code, "Http status should be 200")
 
 	actualEvent := new(model.ClientEventData)
-	err := json.Unmarshal([]byte(w.Body.String()), actualEvent)
+	err := model.UnmarshalJSON([]byte(w.Body.String()), actualEvent)
 	if err != nil {
 		panic("Error unmarshalling json response: " + err.Error())
 	}
@@ -303,32 +327,56 @@ func TestRetrieveEventHandler_ValidRouteVariable_GenericContentType(t *testing.T
 	assert.Equal(t, testEvent.GetData(), actualEvent.GetData(), "Event data should be equal")
 }
 
+//Clients built from the proto2 model require timestamp and value, stored events have them even when they are zero
+func TestRetrieveEventHandler_RequiredFieldsOfOldClients(t *testing.T) {
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/json")
+	w := test("POST", `{"request_id": "r", "device_type": "android", "events": [{"event_id": "123", "event_type": "UNKNOWN", "timestamp": "0",
+		"kv_pair": [{"key": "referrer", "value": ""}, {"key": "duration", "typed_value": {"int_value": "250"}}]}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	testGet := GenerateGetHandleTester(t)
+	w = testGet("/v1/events/123", "application/x-protobuf", testServer.router)
+	assert.Equal(t, http.StatusOK, w.Code, "Http status should be 200")
+
+	event := new(model.ClientEventData)
+	if err := proto.Unmarshal(w.Body.Bytes(), event); err != nil {
+		t.Fatal("Error unmarshalling protobuf response: " + err.Error())
+	}
+	assert.NotNil(t, event.Timestamp, "A zero timestamp should be kept")
+	if assert.Equal(t, 2, len(event.GetKvPair())) {
+		assert.NotNil(t, event.GetKvPair()[0].Value, "An empty value should be sent")
+		assert.Equal(t, "250", event.GetKvPair()[1].GetValue(), "Typed values should be sent in their string form too")
+		assert.Equal(t, int64(250), event.GetKvPair()[1].GetTypedValue().GetIntValue())
+	}
+}
+
 func TestRetrieveEventHandler_RecordNotFound_JSON(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	StoreEvent(testEvent)
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/12", "application/json", getSubrouter)
+	w := testGet("/v1/events/12", "application/json", testServer.router)
 
 	assert.Equal(t, http.StatusNotFound, w.Code, "Http status should be 404")
 }
 
 func TestRetrieveEventHandler_InValidRouteVariable_JSON(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
 	newEventId := "abc"
-	testEvent.EventId = &newEventId
-	StoreEvent(testEvent)
+	testEvent.EventId = "abc"
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap[newEventId]
+	_, ok := testStore.events[newEventId]
 	assert.True(t, ok, "Map should contain event")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/abc", "application/json", getSubrouter)
+	w := testGet("/v1/events/abc", "application/json", testServer.router)
 
 	assert.Equal(t, http.StatusNotFound, w.Code, "Http status should be 404")
 }
@@ -336,16 +384,16 @@ func TestRetrieveEventHandler_InValidRouteVariable_JSON(t *testing.T) {
 //-------------------------Protobuf GET-----------------
 
 func TestRetrieveEventHandler_ValidRouteVariable_Protobuf(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	StoreEvent(testEvent)
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
-	assert.Equal(t, len(eventMap), 1, "eventMap should have only 1 entry")
+	assert.Equal(t, len(testStore.events), 1, "eventMap should have only 1 entry")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/123", "application/x-protobuf", getSubrouter)
+	w := testGet("/v1/events/123", "application/x-protobuf", testServer.router)
 
 	assert.Equal(t, http.StatusOK, w.Code, "Http status should be 200")
 	assert.Equal(t, APPLICATION_PROTOBUF, w.Header().Get("Content-Type"), "Content type should be application/x-protobuf")
@@ -360,15 +408,15 @@ func TestRetrieveEventHandler_ValidRouteVariable_Protobuf(t *testing.T) {
 }
 
 func TestRetrieveEventHandler_RecordNotFound_Protobuf(t *testing.T) {
-	eventMap = make(map[string]model.ClientEventData)
+	resetTestStore()
 	testEvent := generateTestClientEvent()
-	StoreEvent(testEvent)
+	testStore.StoreEvent(testEvent)
 
-	_, ok := eventMap["123"]
+	_, ok := testStore.events["123"]
 	assert.True(t, ok, "Map should contain event")
 
 	testGet := GenerateGetHandleTester(t)
-	w := testGet("/v1/events/12", "application/x-protobuf", getSubrouter)
+	w := testGet("/v1/events/12", "application/x-protobuf", testServer.router)
 
 	assert.Equal(t, http.StatusNotFound, w.Code, "Http status should be 404")
 }
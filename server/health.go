@@ -0,0 +1,117 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	HealthStatusOK           = "OK"
+	HealthStatusFailing      = "FAILING"
+	HealthStatusNotReady     = "NOT_READY"
+	HealthStatusShuttingDown = "SHUTTING_DOWN"
+)
+
+//Checks one dependency of the server for readiness, a nil error means the dependency is healthy
+type HealthCheck func() error
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+//Optional Store interface for stores that can tell when they are broken, checked by /readyz as the datastore component
+type StoreChecker interface {
+	Check() error
+}
+
+//Optional Store interface for stores that keep their data on disk, /readyz checks the free space of the file system holding Path
+type DiskStore interface {
+	Path() string
+}
+
+//Adds a component to the /readyz checks, e.g. the backlog of a background worker
+func WithHealthCheck(name string, check HealthCheck) Option {
+	return func(s *Server) {
+		s.healthChecks = append(s.healthChecks, namedHealthCheck{name: name, check: check})
+	}
+}
+
+//The datastore, disk and ingestion queue checks come first, followed by the checks added through options
+func (s *Server) initHealthChecks() {
+	checks := []namedHealthCheck{{name: "datastore", check: s.checkDatastore}}
+	if diskStore, ok := s.store.(DiskStore); ok {
+		checks = append(checks, namedHealthCheck{name: "disk", check: diskSpaceCheck(diskStore.Path(), s.config.Readiness.MinFreeDiskInMB)})
+	}
+	if s.ingestion != nil {
+		checks = append(checks, namedHealthCheck{name: "ingestionQueue", check: s.checkIngestionBacklog})
+	}
+	if dir := s.config.Ingestion.QueueDir; s.ingestion != nil && dir != "" {
+		checks = append(checks, namedHealthCheck{name: "ingestionQueueDisk", check: diskSpaceCheck(dir, s.config.Readiness.MinFreeDiskInMB)})
+	}
+	s.healthChecks = append(checks, s.healthChecks...)
+}
+
+func (s *Server) checkDatastore() error {
+	if checker, ok := s.store.(StoreChecker); ok {
+		return checker.Check()
+	}
+	return nil
+}
+
+//Fails when the file system holding path has less than minFreeMB available
+func diskSpaceCheck(path string, minFreeMB int) HealthCheck {
+	return func() error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return err
+		}
+		freeMB := uint64(stat.Bavail) * uint64(stat.Bsize) / (1024 * 1024)
+		if freeMB < uint64(minFreeMB) {
+			return errors.New(strconv.FormatUint(freeMB, 10) + "MB free under " + path + ", need " + strconv.Itoa(minFreeMB) + "MB")
+		}
+		return nil
+	}
+}
+
+//Runs every health check, the server is ready when all of them pass and it isn't shutting down
+func (s *Server) readiness() (*model.HeartBeat, bool) {
+	ready := true
+	components := make([]*model.ComponentStatus, 0, len(s.healthChecks))
+	for _, hc := range s.healthChecks {
+		component := &model.ComponentStatus{Name: hc.name, Status: HealthStatusOK}
+		if err := hc.check(); err != nil {
+			ready = false
+			component.Status, component.ErrorMessage = HealthStatusFailing, err.Error()
+		}
+		components = append(components, component)
+	}
+
+	status := HealthStatusOK
+	if s.shuttingDown() {
+		ready = false
+		status = HealthStatusShuttingDown
+	} else if !ready {
+		status = HealthStatusNotReady
+	}
+	return &model.HeartBeat{Status: status, Timestamp: time.Now().UTC().String(), Components: components}, ready
+}
+
+//Readiness for load balancers, 503 while a dependency is failing or the server is shutting down
+func (s *Server) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		heartBeat, ready := s.readiness()
+		if !ready {
+			s.RequestLogger(req).WithFields(log.Fields{"method": "ReadyzHandler", "status": heartBeat.GetStatus()}).Warningln("Server not ready")
+			writeJSON(w, http.StatusServiceUnavailable, heartBeat)
+			return
+		}
+		writeJSON(w, http.StatusOK, heartBeat)
+	})
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//MemoryStore that reports a broken datastore and keeps its data in the temp dir
+type brokenTestStore struct {
+	*MemoryStore
+}
+
+func (bs brokenTestStore) Check() error {
+	return errors.New("datastore unavailable")
+}
+
+func (bs brokenTestStore) Path() string {
+	return os.TempDir()
+}
+
+func getReadyz(t *testing.T, s *Server) (int, *model.HeartBeat) {
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	heartBeat := new(model.HeartBeat)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), heartBeat), "Readiness should be a HeartBeat")
+	return w.Code, heartBeat
+}
+
+func TestReadyzHandler_Ready(t *testing.T) {
+	code, heartBeat := getReadyz(t, newTestServer(t))
+	assert.Equal(t, http.StatusOK, code, "Server should be ready")
+	assert.Equal(t, HealthStatusOK, heartBeat.GetStatus())
+	assert.Equal(t, "datastore", heartBeat.GetComponents()[0].GetName(), "Datastore should be checked")
+	assert.Equal(t, HealthStatusOK, heartBeat.GetComponents()[0].GetStatus())
+}
+
+func TestReadyzHandler_FailingComponents(t *testing.T) {
+	s := newTestServer(t, WithStore(brokenTestStore{NewMemoryStore()}), WithHealthCheck("queue", func() error { return nil }))
+	code, heartBeat := getReadyz(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "Failing datastore should make the server not ready")
+	assert.Equal(t, HealthStatusNotReady, heartBeat.GetStatus())
+
+	components := heartBeat.GetComponents()
+	assert.Equal(t, 3, len(components), "Datastore, disk and added checks should be reported")
+	assert.Equal(t, HealthStatusFailing, components[0].GetStatus())
+	assert.Equal(t, "datastore unavailable", components[0].GetErrorMessage())
+	assert.Equal(t, "disk", components[1].GetName(), "Disk stores should get a disk space check")
+	assert.Equal(t, "queue", components[2].GetName(), "Added checks should come last")
+	assert.Equal(t, HealthStatusOK, components[2].GetStatus())
+}
+
+func TestReadyzHandler_ShuttingDown(t *testing.T) {
+	s := newTestServer(t)
+	assert.NoError(t, s.Shutdown(), "Error shutting down")
+	code, heartBeat := getReadyz(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "Server should not be ready while shutting down")
+	assert.Equal(t, HealthStatusShuttingDown, heartBeat.GetStatus())
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Liveness should not depend on readiness")
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	assert.NoError(t, diskSpaceCheck(os.TempDir(), 0)(), "Any free space should pass")
+	assert.Error(t, diskSpaceCheck(os.TempDir(), 1<<40)(), "Too little free space should fail")
+	assert.Error(t, diskSpaceCheck("/nonexistent/meowtrics", 0)(), "Missing paths should fail")
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+//Precisions a HyperLogLog can be created with, 2^precision registers of one byte each
+const (
+	minHyperLogLogPrecision = 4
+	maxHyperLogLogPrecision = 18
+)
+
+var HyperLogLogPrecisionError = errors.New("HyperLogLog precisions differ")
+
+/*
+Estimates the number of distinct values added to it with a fixed amount of memory. The relative standard error of
+the estimate is 1.04/sqrt(2^precision), 0.81% at precision 14. Sketches with the same precision can be merged into
+the sketch of the union of their values.
+*/
+type HyperLogLog struct {
+	precision uint
+	registers []uint8
+}
+
+func NewHyperLogLog(precision int) *HyperLogLog {
+	return &HyperLogLog{precision: uint(precision), registers: make([]uint8, 1<<uint(precision))}
+}
+
+func (h *HyperLogLog) Add(value string) {
+	hash := hyperLogLogHash(value)
+	index := hash >> (64 - h.precision)
+	//The guard bit caps the rank for hashes whose remaining bits are all zero
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1)) + 1)
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other.precision != h.precision {
+		return HyperLogLogPrecisionError
+	}
+	for i, rank := range other.registers {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+	}
+	return nil
+}
+
+//Harmonic mean estimate, with linear counting for small cardinalities where it is more accurate
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	var sum float64
+	zeros := 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := hyperLogLogAlpha(len(h.registers)) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+func (h *HyperLogLog) RelativeStandardError() float64 {
+	return 1.04 / math.Sqrt(float64(len(h.registers)))
+}
+
+func hyperLogLogAlpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+//FNV-1a followed by the splitmix64 finalizer, FNV alone doesn't spread similar values like user ids over all bits
+func hyperLogLogHash(value string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(value))
+	hash := hasher.Sum64()
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return hash
+}
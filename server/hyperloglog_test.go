@@ -0,0 +1,44 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertInDelta(t *testing.T, expected float64, actual float64, delta float64, message string) {
+	if math.Abs(actual-expected) > delta {
+		t.Errorf("%s: expected %v within %v, got %v", message, expected, delta, actual)
+	}
+}
+
+func TestHyperLogLog_Estimate(t *testing.T) {
+	hll := NewHyperLogLog(14)
+	assert.Equal(t, uint64(0), hll.Estimate(), "Empty sketch should estimate 0")
+
+	for i := 0; i < 10; i++ {
+		hll.Add("user" + strconv.Itoa(i))
+		hll.Add("user" + strconv.Itoa(i))
+	}
+	assert.Equal(t, uint64(10), hll.Estimate(), "Small cardinalities should be exact")
+
+	for i := 10; i < 100000; i++ {
+		hll.Add("user" + strconv.Itoa(i))
+	}
+	assertInDelta(t, 100000, float64(hll.Estimate()), 100000*3*hll.RelativeStandardError(), "Estimate should be within three standard errors")
+	assertInDelta(t, 0.0081, hll.RelativeStandardError(), 0.0001, "Standard error of precision 14")
+}
+
+func TestHyperLogLog_Merge(t *testing.T) {
+	first, second := NewHyperLogLog(12), NewHyperLogLog(12)
+	for i := 0; i < 20000; i++ {
+		first.Add(strconv.Itoa(i))
+		second.Add(strconv.Itoa(i + 10000))
+	}
+	assert.NoError(t, first.Merge(second))
+	assertInDelta(t, 30000, float64(first.Estimate()), 30000*3*first.RelativeStandardError(), "Merged sketch should count shared values once")
+
+	assert.Equal(t, HyperLogLogPrecisionError, first.Merge(NewHyperLogLog(14)), "Precisions should match")
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"meowtrics/model"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//Attempts at storing a queued upload and the wait before the first retry, doubled for every further retry
+const (
+	ingestionWriteAttempts = 5
+	ingestionRetryBackoff  = 100 * time.Millisecond
+)
+
+//Bounded queue between the POST handlers and the store, drained by a pool of workers that write in batches.
+//A queue opened with OpenIngestionQueue also writes every upload to a segment log on disk before it is accepted.
+type IngestionQueue struct {
+	uploads   chan queuedUpload
+	size      int
+	batchSize int
+	write     func(batch []*model.ClientEventUploadRequest) []error
+	log       *segmentLog
+
+	writeAttempts int
+	retryBackoff  time.Duration
+
+	mutex  sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+	logErr error
+}
+
+//Starts workers goroutines that hand up to batchSize events at a time to write, which returns one error per upload.
+//Uploads that fail are retried with a backoff and dropped after the last attempt. Queued uploads are lost if the
+//process crashes.
+func NewIngestionQueue(size int, workers int, batchSize int, write func(batch []*model.ClientEventUploadRequest) []error) *IngestionQueue {
+	return newIngestionQueue(size, workers, batchSize, write, nil, nil)
+}
+
+//Like NewIngestionQueue, but uploads are kept in segment files of up to segmentSize bytes in dir until they are
+//stored, and the ones that weren't stored before a crash are queued again. Uploads failing every attempt are moved to
+//the dead letter file of dir. With sync every append is fsynced before the upload is accepted.
+func OpenIngestionQueue(dir string, segmentSize int64, sync bool, size int, workers int, batchSize int, write func(batch []*model.ClientEventUploadRequest) []error) (*IngestionQueue, error) {
+	segments, pending, err := openSegmentLog(dir, segmentSize, sync)
+	if err != nil {
+		return nil, err
+	}
+	return newIngestionQueue(size, workers, batchSize, write, segments, pending), nil
+}
+
+func newIngestionQueue(size int, workers int, batchSize int, write func(batch []*model.ClientEventUploadRequest) []error, segments *segmentLog, pending []queuedUpload) *IngestionQueue {
+	//Replayed uploads get room on top of the queue size so that none of them has to be dropped
+	q := &IngestionQueue{uploads: make(chan queuedUpload, size+len(pending)), size: size, batchSize: batchSize, write: write, log: segments,
+		writeAttempts: ingestionWriteAttempts, retryBackoff: ingestionRetryBackoff}
+	for _, upload := range pending {
+		q.uploads <- upload
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+//Queues the upload request without blocking, returns QueueFullError when the queue is full or closed
+func (q *IngestionQueue) Enqueue(uploadRequest *model.ClientEventUploadRequest) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed || len(q.uploads) >= q.size {
+		return QueueFullError
+	}
+	upload := queuedUpload{uploadRequest: uploadRequest}
+	if q.log != nil {
+		seq, err := q.log.append(uploadRequest)
+		if err != nil {
+			return err
+		}
+		upload.seq = seq
+	}
+	q.uploads <- upload
+	return nil
+}
+
+//Whether queued uploads are kept in a segment log, so that they survive a crash before they are stored
+func (q *IngestionQueue) Durable() bool {
+	return q.log != nil
+}
+
+//Upload requests waiting for a worker
+func (q *IngestionQueue) Depth() int {
+	return len(q.uploads)
+}
+
+func (q *IngestionQueue) Capacity() int {
+	return q.size
+}
+
+//The last error acknowledging stored uploads in the segment log
+func (q *IngestionQueue) Err() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.logErr
+}
+
+//Stops taking upload requests and waits until the workers have written the queued ones
+func (q *IngestionQueue) Close() error {
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.uploads)
+	q.mutex.Unlock()
+
+	q.wg.Wait()
+	if q.log != nil {
+		return q.log.close()
+	}
+	return nil
+}
+
+func (q *IngestionQueue) work() {
+	defer q.wg.Done()
+
+	for upload := range q.uploads {
+		batch := []queuedUpload{upload}
+		events := len(upload.uploadRequest.GetEvents())
+		//Whatever is already queued joins the batch, the worker never waits for a batch to fill up
+	fill:
+		for events < q.batchSize {
+			select {
+			case next, ok := <-q.uploads:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+				events += len(next.uploadRequest.GetEvents())
+			default:
+				break fill
+			}
+		}
+		q.writeBatch(batch)
+	}
+}
+
+/*
+Writes the batch and retries the uploads that failed until they are stored or out of attempts. Every upload is
+acknowledged in the end, the ones that never stored once they are in the dead letter file, so that a failing upload
+can't hold back the commit point of the segment log. An upload that can't be dead lettered stays unacknowledged and is
+retried when the log is opened again.
+*/
+func (q *IngestionQueue) writeBatch(batch []queuedUpload) {
+	backoff := q.retryBackoff
+	for attempt := 1; len(batch) > 0; attempt++ {
+		uploadRequests := make([]*model.ClientEventUploadRequest, len(batch))
+		for i, upload := range batch {
+			uploadRequests[i] = upload.uploadRequest
+		}
+		errs := q.write(uploadRequests)
+
+		var failed []queuedUpload
+		for i, upload := range batch {
+			if i < len(errs) && errs[i] != nil {
+				failed = append(failed, upload)
+				continue
+			}
+			q.ack(upload)
+		}
+		if len(failed) > 0 && attempt >= q.writeAttempts {
+			for _, upload := range failed {
+				if q.log == nil {
+					continue
+				}
+				if err := q.log.deadLetter(upload); err != nil {
+					q.setLogErr(err)
+					continue
+				}
+				q.ack(upload)
+			}
+			return
+		}
+		if len(failed) > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		batch = failed
+	}
+}
+
+func (q *IngestionQueue) ack(upload queuedUpload) {
+	if q.log == nil {
+		return
+	}
+	if err := q.log.ack(upload.seq); err != nil {
+		q.setLogErr(err)
+	}
+}
+
+func (q *IngestionQueue) setLogErr(err error) {
+	q.mutex.Lock()
+	q.logErr = err
+	q.mutex.Unlock()
+}
+
+//Stores a batch of validated upload requests, there's no client left to report errors to so they are logged. The
+//events stored before a failure are removed from their upload request so that a retry doesn't store them twice.
+func (s *Server) storeUploadBatch(batch []*model.ClientEventUploadRequest) []error {
+	errs := make([]error, len(batch))
+	for i, uploadRequest := range batch {
+		logger := s.logger.WithFields(log.Fields{"method": "storeUploadBatch", "requestId": uploadRequest.GetRequestId()})
+		var stored int
+		if stored, errs[i] = s.storeEvents(uploadRequest); errs[i] != nil {
+			uploadRequest.Events = uploadRequest.Events[stored:]
+			logger.WithFields(log.Fields{"error": errs[i].Error()}).Errorln("Error storing queued events")
+			continue
+		}
+		logger.Debugln("Queued request stored")
+	}
+	s.logger.WithFields(log.Fields{"method": "storeUploadBatch", "requests": len(batch)}).Debugln("Batch written")
+	return errs
+}
+
+func (s *Server) ingestionQueueDepth() int {
+	if s.ingestion == nil {
+		return 0
+	}
+	return s.ingestion.Depth()
+}
+
+//Fails once the queue is full, new uploads are turned away until the workers catch up, and when stored uploads
+//can't be acknowledged in the segment log
+func (s *Server) checkIngestionBacklog() error {
+	if s.ingestion == nil {
+		return nil
+	}
+	if s.ingestion.Depth() >= s.ingestion.Capacity() {
+		return QueueFullError
+	}
+	return s.ingestion.Err()
+}
+
+func (s *Server) ingestionRetryAfter() time.Duration {
+	return time.Duration(s.config.Ingestion.RetryAfterInSeconds) * time.Second
+}
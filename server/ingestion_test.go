@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func generateAsyncTestConfig(queueSize int) *Config {
+	config := DefaultConfig()
+	config.Ingestion.Async = true
+	config.Ingestion.QueueSize = queueSize
+	return config
+}
+
+func TestCreateEventHandler_Async(t *testing.T) {
+	store := NewMemoryStore()
+	s := newTestServer(t, WithConfig(generateAsyncTestConfig(10)), WithStore(store))
+
+	w := postTestEvent(t, s.Handler())
+	assert.Equal(t, http.StatusAccepted, w.Code, "Queued uploads should be accepted")
+
+	assert.NoError(t, s.Shutdown(), "Error shutting down")
+	assert.Equal(t, 1, store.CountEvents(), "Queued events should be stored by shutdown")
+	assert.Equal(t, float64(1), s.metrics.eventsIngestedTotal.Value("UNKNOWN", otherDeviceType), "Stored events should be counted")
+}
+
+func TestCreateEventHandler_AsyncQueueFull(t *testing.T) {
+	s := newTestServer(t, WithConfig(generateAsyncTestConfig(1)))
+	s.ingestion.Close()
+	//Without workers the first upload fills the queue for good
+	s.ingestion = NewIngestionQueue(1, 0, 1, s.storeUploadBatch)
+
+	w := postTestEvent(t, s.Handler())
+	assert.Equal(t, http.StatusAccepted, w.Code, "First upload should be queued")
+	w = postTestEvent(t, s.Handler())
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "Uploads should be turned away when the queue is full")
+	assert.Equal(t, "1", w.Header().Get("Retry-After"), "Retry-After header should be set")
+	assert.Contains(t, w.Body.String(), QueueFull)
+	assert.Equal(t, float64(1), s.metrics.ingestionRejectionsTotal.Value(), "Rejected uploads should be counted")
+	assert.Equal(t, 1, s.ingestionQueueDepth(), "Queue depth should be reported")
+
+	code, heartBeat := getReadyz(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "Full queue should make the server not ready")
+	assert.Equal(t, "ingestionQueue", heartBeat.GetComponents()[1].GetName())
+	assert.Equal(t, HealthStatusFailing, heartBeat.GetComponents()[1].GetStatus())
+}
+
+func TestIngestionQueue_Batches(t *testing.T) {
+	release := make(chan struct{})
+	batches := make(chan int, 10)
+	q := NewIngestionQueue(10, 1, 100, func(batch []*model.ClientEventUploadRequest) []error {
+		batches <- len(batch)
+		<-release
+		return nil
+	})
+
+	upload := generateTestClientEventUploadRequest_Valid()
+	assert.NoError(t, q.Enqueue(upload))
+	assert.Equal(t, 1, <-batches, "Worker should not wait for a batch to fill up")
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, q.Enqueue(upload))
+	}
+	close(release)
+
+	assert.NoError(t, q.Close(), "Error closing queue")
+	assert.Equal(t, 3, <-batches, "Queued uploads should be written together")
+	assert.Equal(t, QueueFullError, q.Enqueue(upload), "Closed queue should not take uploads")
+}
+
+func TestIngestionQueue_RetriesFailedWrites(t *testing.T) {
+	attempts := make(chan int, 10)
+	calls := 0
+	q := NewIngestionQueue(10, 1, 100, func(batch []*model.ClientEventUploadRequest) []error {
+		calls++
+		attempts <- calls
+		if calls < 3 {
+			return []error{errors.New("datastore unavailable")}
+		}
+		return nil
+	})
+	q.retryBackoff = time.Millisecond
+
+	assert.NoError(t, q.Enqueue(generateTestClientEventUploadRequest_Valid()))
+	assert.NoError(t, q.Close(), "Error closing queue")
+	assert.Equal(t, 3, len(attempts), "Failed write should be retried until it succeeds")
+}
+
+//MemoryStore that fails a single write of the given event
+type failingOnceTestStore struct {
+	*MemoryStore
+	eventId string
+	failed  *bool
+}
+
+func (fs failingOnceTestStore) StoreEvent(event *model.ClientEventData) error {
+	if event.GetEventId() == fs.eventId && !*fs.failed {
+		*fs.failed = true
+		return errors.New("datastore unavailable")
+	}
+	return fs.MemoryStore.StoreEvent(event)
+}
+
+func TestStoreUploadBatch_KeepsUnstoredEvents(t *testing.T) {
+	failed := false
+	s := newTestServer(t, WithStore(failingOnceTestStore{NewMemoryStore(), "secondEventId", &failed}))
+
+	uploadRequest := generateTestClientEventUploadRequest_Valid()
+	second := generateTestClientEvent()
+	second.EventId = "secondEventId"
+	uploadRequest.Events = append(uploadRequest.Events, second)
+
+	errs := s.storeUploadBatch([]*model.ClientEventUploadRequest{uploadRequest})
+	assert.Error(t, errs[0], "Failed store should be reported")
+	assert.Equal(t, 1, len(uploadRequest.GetEvents()), "Stored events should not be written again on retry")
+	assert.Equal(t, "secondEventId", uploadRequest.GetEvents()[0].GetEventId())
+
+	errs = s.storeUploadBatch([]*model.ClientEventUploadRequest{uploadRequest})
+	assert.NoError(t, errs[0], "Retry should store the remaining events")
+	assert.Equal(t, 2, s.store.CountEvents())
+}
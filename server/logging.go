@@ -0,0 +1,295 @@
+package server
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const rotatedLogTimeFormat = "20060102T150405.000"
+
+var (
+	UnknownLogOutputError = errors.New("Unknown log output")
+	LogFileClosedError    = errors.New("Log file is closed")
+)
+
+//Log file writer rotating by size and by age, rotated files are timestamped, optionally gzipped,
+//and only the newest maxBackups are kept
+type RotatingFile struct {
+	path           string
+	maxSize        int64
+	rotateInterval time.Duration
+	maxBackups     int
+	compress       bool
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func NewRotatingFile(path string, maxSize int64, rotateInterval time.Duration, maxBackups int, compress bool) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, maxSize: maxSize, rotateInterval: rotateInterval, maxBackups: maxBackups, compress: compress, now: time.Now}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file = file
+	rf.size = info.Size()
+	rf.openedAt = rf.now()
+	return nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file == nil {
+		return 0, LogFileClosedError
+	}
+
+	expired := rf.rotateInterval > 0 && rf.now().Sub(rf.openedAt) >= rf.rotateInterval
+	full := rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize
+	if expired || full {
+		if err := rf.rotate(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error rotating log file: "+err.Error())
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+//Renames the current file with a timestamp suffix and starts a new one, compression and pruning of old
+//backups happen in the background so that logging isn't blocked
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+
+	rotated := rf.path + "." + rf.now().UTC().Format(rotatedLogTimeFormat)
+	if err := os.Rename(rf.path, rotated); err != nil {
+		rf.open()
+		return err
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	go rf.cleanupBackups(rotated)
+	return nil
+}
+
+func (rf *RotatingFile) cleanupBackups(rotated string) {
+	if rf.compress {
+		if err := gzipFile(rotated); err != nil {
+			fmt.Fprintln(os.Stderr, "Error compressing rotated log file: "+err.Error())
+		}
+	}
+
+	if rf.maxBackups <= 0 {
+		return
+	}
+	backups, err := filepath.Glob(rf.path + ".*")
+	if err != nil {
+		return
+	}
+	//Timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for len(backups) > rf.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+func gzipFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+type LogConfig struct {
+	Level                 string
+	Format                string
+	Outputs               []string
+	FileName              string
+	MaxSizeInMB           int
+	RotateIntervalInHours int
+	MaxBackups            int
+	Compress              bool
+}
+
+//Reads the logging settings from the app properties, logOutputs is a comma separated list of file, stdout and stderr
+func LoadLogConfig(props appProperties) LogConfig {
+	var outputs []string
+	for _, output := range strings.Split(getStringOrDefault(props, "logOutputs", "file"), ",") {
+		if output = strings.TrimSpace(output); output != "" {
+			outputs = append(outputs, output)
+		}
+	}
+
+	return LogConfig{
+		Level:                 getStringOrDefault(props, "logLevel", "info"),
+		Format:                getStringOrDefault(props, "logFormat", "json"),
+		Outputs:               outputs,
+		FileName:              getStringOrDefault(props, "logFileName", logFileName),
+		MaxSizeInMB:           props.GetInt("logMaxSizeInMB"),
+		RotateIntervalInHours: props.GetInt("logRotateIntervalInHours"),
+		MaxBackups:            props.GetInt("logMaxBackups"),
+		Compress:              props.GetBool("logCompress"),
+	}
+}
+
+//Points the logger at the configured outputs, the returned closer closes the log file if there is one
+func ConfigureLogger(logger *log.Logger, config LogConfig) (io.Closer, error) {
+	level, err := log.ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var formatter log.Formatter
+	switch config.Format {
+	case "json":
+		formatter = new(log.JSONFormatter)
+	case "text":
+		formatter = &log.TextFormatter{DisableColors: true}
+	default:
+		return nil, errors.New("Unknown log format: " + config.Format)
+	}
+
+	var writers []io.Writer
+	var closer io.Closer
+	for _, output := range config.Outputs {
+		switch output {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		case "file":
+			if closer != nil {
+				continue
+			}
+			rf, err := NewRotatingFile(config.FileName, int64(config.MaxSizeInMB)*1024*1024, time.Duration(config.RotateIntervalInHours)*time.Hour, config.MaxBackups, config.Compress)
+			if err != nil {
+				return nil, err
+			}
+			writers = append(writers, rf)
+			closer = rf
+		default:
+			if closer != nil {
+				closer.Close()
+			}
+			return nil, errors.New(UnknownLogOutputError.Error() + ": " + output)
+		}
+	}
+	if len(writers) == 0 {
+		writers = append(writers, os.Stdout)
+	}
+
+	logger.Out = io.MultiWriter(writers...)
+	logger.Formatter = formatter
+	logger.Level = level
+	return closer, nil
+}
+
+//Level of a logger that can change while the logger is in use
+type logLevel struct {
+	mutex sync.RWMutex
+	level log.Level
+}
+
+func (ll *logLevel) set(level log.Level) {
+	ll.mutex.Lock()
+	ll.level = level
+	ll.mutex.Unlock()
+}
+
+func (ll *logLevel) get() log.Level {
+	ll.mutex.RLock()
+	defer ll.mutex.RUnlock()
+	return ll.level
+}
+
+//Formats the entries within the level and drops the others
+type levelFilter struct {
+	log.Formatter
+	level *logLevel
+}
+
+func (lf *levelFilter) Format(entry *log.Entry) ([]byte, error) {
+	if entry.Level > lf.level.get() {
+		return nil, nil
+	}
+	return lf.Formatter.Format(entry)
+}
+
+/*
+Makes the level of logger changeable while it is in use through the returned logLevel. logrus reads Logger.Level
+without a lock, so the logger is left at the debug level and entries above the current level are dropped when they
+are formatted. Has to be called before the logger is used, loggers shared by several servers share their level.
+*/
+func filterLogLevel(logger *log.Logger) *logLevel {
+	if filter, ok := logger.Formatter.(*levelFilter); ok {
+		return filter.level
+	}
+	level := &logLevel{level: logger.Level}
+	logger.Formatter = &levelFilter{Formatter: logger.Formatter, level: level}
+	logger.Level = log.DebugLevel
+	return level
+}
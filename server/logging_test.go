@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-log")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	rf, err := NewRotatingFile(path, 10, 0, 0, false)
+	assert.NoError(t, err, "Error opening log file")
+	now := time.Now()
+	rf.now = func() time.Time { return now }
+
+	rf.Write([]byte("meowmeow\n"))
+	now = now.Add(time.Second)
+	rf.Write([]byte("meowtrics\n"))
+	rf.Close()
+
+	backups, _ := filepath.Glob(path + ".*")
+	assert.Equal(t, 1, len(backups), "Full log file should be rotated")
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "meowtrics\n", string(content), "New log file should only have the latest line")
+}
+
+func TestRotatingFile_RotatesByAgeAndPrunesBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-log")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	rf, err := NewRotatingFile(path, 0, time.Hour, 2, false)
+	assert.NoError(t, err, "Error opening log file")
+	now := time.Now()
+	rf.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		rf.Write([]byte("meow\n"))
+		now = now.Add(time.Hour)
+	}
+	rf.Close()
+
+	//Pruning runs in the background
+	var backups []string
+	for i := 0; i < 100; i++ {
+		backups, _ = filepath.Glob(path + ".*")
+		if len(backups) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 2, len(backups), "Only maxBackups rotated files should be kept")
+}
+
+func TestConfigureLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-log")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logger := log.New()
+	_, err = ConfigureLogger(logger, LogConfig{Level: "info", Format: "json", Outputs: []string{"kafka"}})
+	assert.Error(t, err, "Unknown output should return an error")
+
+	_, err = ConfigureLogger(logger, LogConfig{Level: "meow", Format: "json"})
+	assert.Error(t, err, "Unknown level should return an error")
+
+	path := filepath.Join(dir, "test.log")
+	closer, err := ConfigureLogger(logger, LogConfig{Level: "warning", Format: "text", Outputs: []string{"file"}, FileName: path})
+	assert.NoError(t, err, "Error configuring logger")
+	assert.Equal(t, log.WarnLevel, logger.Level, "Log level should be set")
+
+	logger.Infoln("hidden")
+	logger.Warningln("shown")
+	closer.Close()
+
+	content, _ := ioutil.ReadFile(path)
+	assert.False(t, strings.Contains(string(content), "hidden"), "Info lines should be filtered")
+	assert.True(t, strings.Contains(string(content), "shown"), "Warning lines should be written")
+}
+
+func TestFilterLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.Out = &buf
+	logger.Level = log.WarnLevel
+	level := filterLogLevel(logger)
+	assert.Equal(t, log.WarnLevel, level.get(), "Level of the logger should be kept")
+	assert.True(t, level == filterLogLevel(logger), "Filtering a logger twice should share the level")
+
+	logger.Infoln("hidden")
+	logger.Warningln("shown")
+	level.set(log.DebugLevel)
+	logger.Debugln("debug")
+	assert.False(t, strings.Contains(buf.String(), "hidden"), "Entries above the level should be dropped")
+	assert.True(t, strings.Contains(buf.String(), "shown"))
+	assert.True(t, strings.Contains(buf.String(), "debug"), "Level changes should apply to the next entry")
+}
@@ -0,0 +1,381 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"meowtrics/model"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
+)
+
+//Bucket width of measurement queries that don't set one, and the most buckets a query over a time range may ask for
+const (
+	defaultMeasurementBucketSeconds = 3600
+	maxMeasurementBuckets           = 10000
+)
+
+//Percentiles returned for every bucket of a measurement query
+var measurementPercentiles = []float64{0.5, 0.95, 0.99}
+
+//Checks the measurements of every event, returns the index of the first event with an invalid one
+func validateMeasurements(events []*model.ClientEventData) (int, error) {
+	for i, event := range events {
+		for _, measurement := range event.GetMeasurements() {
+			if err := validateMeasurement(measurement); err != nil {
+				return i, err
+			}
+		}
+	}
+	return -1, nil
+}
+
+func validateMeasurement(measurement *model.Measurement) error {
+	if measurement.GetName() == "" {
+		return errors.New("measurement name is required")
+	}
+	if !isFinite(measurement.GetValue()) {
+		return errors.New("measurement " + measurement.GetName() + " has a value that is not a finite number")
+	}
+
+	histogram := measurement.GetHistogram()
+	if histogram == nil {
+		return nil
+	}
+	bounds := histogram.GetUpperBounds()
+	if len(histogram.GetBucketCounts()) != len(bounds)+1 {
+		return errors.New("histogram of measurement " + measurement.GetName() + " needs one bucket count more than upper bounds")
+	}
+	for i, bound := range bounds {
+		if !isFinite(bound) || (i > 0 && bound <= bounds[i-1]) {
+			return errors.New("upper bounds of the histogram of measurement " + measurement.GetName() + " must be finite and increasing")
+		}
+	}
+	if !isFinite(histogram.GetSum()) || !isFinite(histogram.GetMin()) || !isFinite(histogram.GetMax()) {
+		return errors.New("histogram of measurement " + measurement.GetName() + " has a sum, min or max that is not a finite number")
+	}
+	return nil
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+//Measurements are only aggregated together when their name, unit and kind match
+type measurementKey struct {
+	name string
+	unit string
+	kind model.MeasurementKind
+}
+
+func newMeasurementKey(measurement *model.Measurement) measurementKey {
+	return measurementKey{name: measurement.GetName(), unit: measurement.GetUnit(), kind: measurement.GetKind()}
+}
+
+//Observations spread evenly over [low, high], a single value has low == high
+type measurementSegment struct {
+	low   float64
+	high  float64
+	count float64
+}
+
+//Statistics of the measurements in one bucket of a query
+type measurementStats struct {
+	count    uint64
+	sum      float64
+	min      float64
+	max      float64
+	segments []measurementSegment
+}
+
+func (ms *measurementStats) add(measurement *model.Measurement) {
+	if histogram := measurement.GetHistogram(); histogram != nil {
+		ms.addHistogram(histogram)
+		return
+	}
+	value := measurement.GetValue()
+	ms.addSegment(measurementSegment{low: value, high: value, count: 1})
+	ms.count++
+	ms.sum += value
+}
+
+func (ms *measurementStats) addHistogram(histogram *model.Histogram) {
+	for _, segment := range histogramSegments(histogram) {
+		ms.addSegment(segment)
+		ms.count += uint64(segment.count)
+	}
+	ms.sum += histogram.GetSum()
+}
+
+//Buckets of a client histogram become segments between their bounds, narrowed down by min and max when the client
+//sent them. The open ends of the first and last bucket fall back to the nearest bound.
+func histogramSegments(histogram *model.Histogram) []measurementSegment {
+	var total uint64
+	for _, count := range histogram.GetBucketCounts() {
+		total += count
+	}
+	if total == 0 {
+		return nil
+	}
+	average := histogram.GetSum() / float64(total)
+
+	var segments []measurementSegment
+	bounds := histogram.GetUpperBounds()
+	for i, count := range histogram.GetBucketCounts() {
+		if count == 0 {
+			continue
+		}
+		low, high := math.Inf(-1), math.Inf(1)
+		if i > 0 {
+			low = bounds[i-1]
+		}
+		if i < len(bounds) {
+			high = bounds[i]
+		}
+		if histogram.Min != nil {
+			low = math.Max(low, histogram.GetMin())
+		}
+		if histogram.Max != nil {
+			high = math.Min(high, histogram.GetMax())
+		}
+		switch {
+		case math.IsInf(low, -1) && math.IsInf(high, 1):
+			low, high = average, average
+		case math.IsInf(low, -1):
+			low = high
+		case math.IsInf(high, 1):
+			high = low
+		}
+		if low > high {
+			low = high
+		}
+		segments = append(segments, measurementSegment{low: low, high: high, count: float64(count)})
+	}
+	return segments
+}
+
+func (ms *measurementStats) addSegment(segment measurementSegment) {
+	if len(ms.segments) == 0 || segment.low < ms.min {
+		ms.min = segment.low
+	}
+	if len(ms.segments) == 0 || segment.high > ms.max {
+		ms.max = segment.high
+	}
+	ms.segments = append(ms.segments, segment)
+}
+
+//Nearest rank percentile, interpolated within a segment. Needs the segments sorted by their middle, so overlapping
+//client histogram buckets make it an estimate.
+func (ms *measurementStats) percentile(q float64) float64 {
+	if len(ms.segments) == 0 {
+		return 0
+	}
+	rank := q * float64(ms.count)
+	var seen float64
+	for _, segment := range ms.segments {
+		if seen+segment.count >= rank {
+			return segment.low + (segment.high-segment.low)*(rank-seen)/segment.count
+		}
+		seen += segment.count
+	}
+	return ms.segments[len(ms.segments)-1].high
+}
+
+func (ms *measurementStats) bucket(start int64) *model.MeasurementBucket {
+	bucket := &model.MeasurementBucket{StartTimestamp: start, Count: ms.count, Sum: ms.sum, Min: ms.min, Max: ms.max}
+	if ms.count > 0 {
+		bucket.Avg = ms.sum / float64(ms.count)
+	}
+	sort.Slice(ms.segments, func(i, j int) bool {
+		return ms.segments[i].low+ms.segments[i].high < ms.segments[j].low+ms.segments[j].high
+	})
+	percentiles := make([]float64, len(measurementPercentiles))
+	for i, q := range measurementPercentiles {
+		percentiles[i] = ms.percentile(q)
+	}
+	bucket.P50, bucket.P95, bucket.P99 = percentiles[0], percentiles[1], percentiles[2]
+	return bucket
+}
+
+type measurementSeries struct {
+	group   []string
+	unit    string
+	kind    model.MeasurementKind
+	buckets map[int64]*measurementStats
+}
+
+/*
+Aggregates the measurements of the stored events matching the query into time buckets, one series per distinct
+value of the group_by dimensions, unit and kind, so that e.g. ms and s or counters and gauges are never mixed in one
+bucket. Bucket starts are multiples of bucket_seconds.
+*/
+func (s *Server) queryMeasurements(query *model.MeasurementQueryRequest) (*model.MeasurementQueryResponse, *model.ErrorResponse) {
+	invalid := func(description string) *model.ErrorResponse {
+		return &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid measurement query", Description: description}
+	}
+
+	if query.GetName() == "" {
+		return nil, invalid("name is required")
+	}
+	bucketSeconds := query.GetBucketSeconds()
+	switch {
+	case bucketSeconds < 0:
+		return nil, invalid("bucket_seconds must be positive")
+	case bucketSeconds == 0:
+		bucketSeconds = defaultMeasurementBucketSeconds
+	}
+	if query.FromTimestamp != nil && query.ToTimestamp != nil {
+		if query.GetFromTimestamp() >= query.GetToTimestamp() {
+			return nil, invalid("from_timestamp must be before to_timestamp")
+		}
+		if (query.GetToTimestamp()-query.GetFromTimestamp())/bucketSeconds > maxMeasurementBuckets {
+			return nil, invalid("at most " + strconv.Itoa(maxMeasurementBuckets) + " buckets can be queried, use larger buckets")
+		}
+	}
+	for _, dimension := range query.GetGroupBy() {
+		if dimension != "event_type" && dimension != "device_type" && (!strings.HasPrefix(dimension, KV_PAIR_PREFIX) || dimension == KV_PAIR_PREFIX) {
+			return nil, invalid("group_by must be event_type, device_type or kv:<key>, got " + dimension)
+		}
+	}
+
+	series := make(map[string]*measurementSeries)
+	for _, event := range s.store.AllEvents() {
+		if query.FromTimestamp != nil && event.GetTimestamp() < query.GetFromTimestamp() {
+			continue
+		}
+		if query.ToTimestamp != nil && event.GetTimestamp() >= query.GetToTimestamp() {
+			continue
+		}
+		if !eventFilterMatches(query.GetFilter(), event, event.GetDeviceType()) {
+			continue
+		}
+
+		for _, measurement := range event.GetMeasurements() {
+			if measurement.GetName() != query.GetName() {
+				continue
+			}
+
+			group := make([]string, len(query.GetGroupBy()))
+			for i, dimension := range query.GetGroupBy() {
+				group[i] = eventLabelValue(event, event.GetDeviceType(), dimension)
+			}
+			key := strings.Join(append(group, measurement.GetUnit(), measurement.GetKind().String()), labelSeparator)
+			groupSeries, ok := series[key]
+			if !ok {
+				groupSeries = &measurementSeries{group: group, unit: measurement.GetUnit(), kind: measurement.GetKind(), buckets: make(map[int64]*measurementStats)}
+				series[key] = groupSeries
+			}
+
+			start := bucketStart(event.GetTimestamp(), bucketSeconds)
+			stats, ok := groupSeries.buckets[start]
+			if !ok {
+				stats = new(measurementStats)
+				groupSeries.buckets[start] = stats
+			}
+			stats.add(measurement)
+		}
+	}
+
+	resp := &model.MeasurementQueryResponse{Name: query.GetName(), BucketSeconds: bucketSeconds}
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		groupSeries := series[key]
+		respSeries := &model.MeasurementSeries{Unit: groupSeries.unit, Kind: groupSeries.kind}
+		for i, dimension := range query.GetGroupBy() {
+			respSeries.Group = append(respSeries.Group, &model.KeyValuePair{Key: strings.TrimPrefix(dimension, KV_PAIR_PREFIX), Value: proto.String(groupSeries.group[i])})
+		}
+
+		starts := make([]int64, 0, len(groupSeries.buckets))
+		for start := range groupSeries.buckets {
+			starts = append(starts, start)
+		}
+		sortInt64s(starts)
+		for _, start := range starts {
+			respSeries.Buckets = append(respSeries.Buckets, groupSeries.buckets[start].bucket(start))
+		}
+		resp.Series = append(resp.Series, respSeries)
+	}
+	return resp, nil
+}
+
+//Start of the bucket holding timestamp, rounding down for timestamps before the epoch too
+func bucketStart(timestamp int64, bucketSeconds int64) int64 {
+	start := timestamp - timestamp%bucketSeconds
+	if timestamp%bucketSeconds < 0 {
+		start -= bucketSeconds
+	}
+	return start
+}
+
+func sortInt64s(values []int64) {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+}
+
+//Reads a measurement query from the from, to, bucket_seconds and group_by query parameters plus the event filter ones
+func parseMeasurementQuery(name string, query url.Values) (*model.MeasurementQueryRequest, error) {
+	filter, err := parseEventFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	measurementQuery := &model.MeasurementQueryRequest{Name: name, Filter: filter, GroupBy: query["group_by"]}
+
+	if measurementQuery.FromTimestamp, err = timestampParameter(query, "from"); err != nil {
+		return nil, err
+	}
+	if measurementQuery.ToTimestamp, err = timestampParameter(query, "to"); err != nil {
+		return nil, err
+	}
+	if value := query.Get("bucket_seconds"); value != "" {
+		measurementQuery.BucketSeconds, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("bucket_seconds must be a number, got " + value)
+		}
+	}
+	return measurementQuery, nil
+}
+
+func timestampParameter(query url.Values, param string) (*int64, error) {
+	value := query.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errors.New(param + " must be a unix timestamp in seconds, got " + value)
+	}
+	return &timestamp, nil
+}
+
+/*
+Percentiles, min, max, avg and sum of the measurement called name per time bucket, e.g.
+GET /v1/measurements/app_start?group_by=device_type&bucket_seconds=86400
+*/
+func (s *Server) MeasurementsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := s.RequestLogger(req).WithFields(log.Fields{"method": "MeasurementsHandler"})
+
+		query, err := parseMeasurementQuery(mux.Vars(req)["name"], req.URL.Query())
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err.Error()}).Infoln("Invalid measurement query")
+			writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid measurement query", Description: err.Error()})
+			return
+		}
+		resp, errResp := s.queryMeasurements(query)
+		if errResp != nil {
+			logger.WithFields(log.Fields{"error": errResp.GetDescription()}).Infoln("Invalid measurement query")
+			writeJSON(w, http.StatusBadRequest, errResp)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func generateTestMeasurementEvent(id string, timestamp int64, deviceType string, value float64) *model.ClientEventData {
+	return &model.ClientEventData{
+		EventId:      id,
+		EventType:    model.ClientEventType_UNKNOWN,
+		Timestamp:    proto.Int64(timestamp),
+		DeviceType:   deviceType,
+		Measurements: []*model.Measurement{{Name: "app_start", Kind: model.MeasurementKind_MEASUREMENT_KIND_TIMING, Unit: "ms", Value: value}},
+	}
+}
+
+func TestValidateMeasurements(t *testing.T) {
+	valid := &model.Measurement{Name: "app_start", Value: 840}
+	histogram := &model.Measurement{Name: "frame_time", Histogram: &model.Histogram{UpperBounds: []float64{16, 33}, BucketCounts: []uint64{50, 8, 1}, Sum: 900}}
+	_, err := validateMeasurements([]*model.ClientEventData{{Measurements: []*model.Measurement{valid, histogram}}})
+	assert.NoError(t, err, "Valid measurements should be accepted")
+
+	invalid := []*model.Measurement{
+		{Value: 1},
+		{Name: "frame_time", Histogram: &model.Histogram{UpperBounds: []float64{16, 33}, BucketCounts: []uint64{50, 8}}},
+		{Name: "frame_time", Histogram: &model.Histogram{UpperBounds: []float64{33, 16}, BucketCounts: []uint64{50, 8, 1}}},
+	}
+	for _, measurement := range invalid {
+		index, err := validateMeasurements([]*model.ClientEventData{{}, {Measurements: []*model.Measurement{measurement}}})
+		assert.Error(t, err, "Invalid measurement should be rejected: "+measurement.String())
+		assert.Equal(t, 1, index, "Index of the event should be returned")
+	}
+}
+
+func TestMeasurementStats_Values(t *testing.T) {
+	stats := new(measurementStats)
+	for i := 100; i > 0; i-- {
+		stats.add(&model.Measurement{Name: "app_start", Value: float64(i)})
+	}
+	bucket := stats.bucket(3600)
+	assert.Equal(t, int64(3600), bucket.GetStartTimestamp())
+	assert.Equal(t, uint64(100), bucket.GetCount())
+	assert.Equal(t, float64(5050), bucket.GetSum())
+	assert.Equal(t, float64(1), bucket.GetMin())
+	assert.Equal(t, float64(100), bucket.GetMax())
+	assert.Equal(t, 50.5, bucket.GetAvg())
+	assert.Equal(t, float64(50), bucket.GetP50())
+	assert.Equal(t, float64(95), bucket.GetP95())
+	assert.Equal(t, float64(99), bucket.GetP99())
+}
+
+func TestMeasurementStats_Histogram(t *testing.T) {
+	stats := new(measurementStats)
+	stats.add(&model.Measurement{Name: "frame_time", Histogram: &model.Histogram{UpperBounds: []float64{10, 20}, BucketCounts: []uint64{0, 10, 0}, Sum: 150}})
+	bucket := stats.bucket(0)
+	assert.Equal(t, uint64(10), bucket.GetCount())
+	assert.Equal(t, float64(15), bucket.GetAvg())
+	assert.Equal(t, float64(15), bucket.GetP50(), "Percentiles should be interpolated within a bucket")
+	assert.Equal(t, float64(10), bucket.GetMin(), "Bucket bounds should be used without min and max")
+	assert.Equal(t, float64(20), bucket.GetMax())
+
+	stats = new(measurementStats)
+	stats.add(&model.Measurement{Name: "frame_time", Histogram: &model.Histogram{UpperBounds: []float64{10}, BucketCounts: []uint64{1, 1}, Sum: 52, Min: proto.Float64(2), Max: proto.Float64(50)}})
+	bucket = stats.bucket(0)
+	assert.Equal(t, float64(2), bucket.GetMin(), "Client min and max should narrow the open buckets")
+	assert.Equal(t, float64(50), bucket.GetMax())
+}
+
+func TestQueryMeasurements(t *testing.T) {
+	store := NewMemoryStore()
+	store.StoreEvent(generateTestMeasurementEvent("1", 7200, "android", 800))
+	store.StoreEvent(generateTestMeasurementEvent("2", 7300, "android", 1000))
+	store.StoreEvent(generateTestMeasurementEvent("3", 10900, "android", 600))
+	store.StoreEvent(generateTestMeasurementEvent("4", 7400, "iPhone", 400))
+	other := generateTestMeasurementEvent("5", 7400, "iPhone", 5)
+	other.Measurements[0].Name = "crashes"
+	store.StoreEvent(other)
+	s := newTestServer(t, WithStore(store))
+
+	resp, errResp := s.queryMeasurements(&model.MeasurementQueryRequest{Name: "app_start", GroupBy: []string{"device_type"}})
+	assert.Nil(t, errResp, "Valid query should be accepted")
+	assert.Equal(t, int64(defaultMeasurementBucketSeconds), resp.GetBucketSeconds())
+	if assert.Equal(t, 2, len(resp.GetSeries())) {
+		android := resp.GetSeries()[0]
+		assert.Equal(t, "device_type", android.GetGroup()[0].GetKey())
+		assert.Equal(t, "android", android.GetGroup()[0].GetValue())
+		assert.Equal(t, "ms", android.GetUnit())
+		if assert.Equal(t, 2, len(android.GetBuckets()), "Events should be split into hourly buckets") {
+			assert.Equal(t, int64(7200), android.GetBuckets()[0].GetStartTimestamp())
+			assert.Equal(t, float64(900), android.GetBuckets()[0].GetAvg())
+			assert.Equal(t, float64(1000), android.GetBuckets()[0].GetMax())
+			assert.Equal(t, int64(10800), android.GetBuckets()[1].GetStartTimestamp())
+		}
+		assert.Equal(t, "iPhone", resp.GetSeries()[1].GetGroup()[0].GetValue())
+		assert.Equal(t, uint64(1), resp.GetSeries()[1].GetBuckets()[0].GetCount(), "Other measurements should not be counted")
+	}
+
+	from, to := int64(7200), int64(10800)
+	resp, errResp = s.queryMeasurements(&model.MeasurementQueryRequest{Name: "app_start", FromTimestamp: &from, ToTimestamp: &to, BucketSeconds: 86400,
+		Filter: &model.EventFilter{DeviceTypes: []string{"android"}}})
+	assert.Nil(t, errResp)
+	if assert.Equal(t, 1, len(resp.GetSeries())) && assert.Equal(t, 1, len(resp.GetSeries()[0].GetBuckets())) {
+		assert.Equal(t, uint64(2), resp.GetSeries()[0].GetBuckets()[0].GetCount(), "Time range and filter should be applied")
+		assert.Equal(t, float64(1800), resp.GetSeries()[0].GetBuckets()[0].GetSum())
+	}
+
+	epoch := int64(0)
+	invalid := []*model.MeasurementQueryRequest{
+		{},
+		{Name: "app_start", BucketSeconds: -1},
+		{Name: "app_start", GroupBy: []string{"country"}},
+		{Name: "app_start", FromTimestamp: &to, ToTimestamp: &from},
+		{Name: "app_start", FromTimestamp: &epoch, ToTimestamp: &to, BucketSeconds: 1},
+	}
+	for _, query := range invalid {
+		_, errResp = s.queryMeasurements(query)
+		assert.NotNil(t, errResp, "Invalid query should be rejected: "+query.String())
+	}
+}
+
+func TestQueryMeasurements_MixedUnits(t *testing.T) {
+	store := NewMemoryStore()
+	store.StoreEvent(generateTestMeasurementEvent("1", 7200, "android", 800))
+	seconds := generateTestMeasurementEvent("2", 7300, "android", 2)
+	seconds.Measurements[0].Unit = "s"
+	store.StoreEvent(seconds)
+	gauge := generateTestMeasurementEvent("3", 7300, "android", 900)
+	gauge.Measurements[0].Kind = model.MeasurementKind_MEASUREMENT_KIND_GAUGE
+	store.StoreEvent(gauge)
+	s := newTestServer(t, WithStore(store))
+
+	resp, errResp := s.queryMeasurements(&model.MeasurementQueryRequest{Name: "app_start", GroupBy: []string{"device_type"}})
+	assert.Nil(t, errResp)
+	if assert.Equal(t, 3, len(resp.GetSeries()), "Units and kinds should not be aggregated together") {
+		assert.Equal(t, "ms", resp.GetSeries()[0].GetUnit())
+		assert.Equal(t, model.MeasurementKind_MEASUREMENT_KIND_GAUGE, resp.GetSeries()[0].GetKind())
+		assert.Equal(t, float64(900), resp.GetSeries()[0].GetBuckets()[0].GetSum())
+		assert.Equal(t, "ms", resp.GetSeries()[1].GetUnit())
+		assert.Equal(t, model.MeasurementKind_MEASUREMENT_KIND_TIMING, resp.GetSeries()[1].GetKind())
+		assert.Equal(t, float64(800), resp.GetSeries()[1].GetBuckets()[0].GetSum())
+		assert.Equal(t, "s", resp.GetSeries()[2].GetUnit())
+		assert.Equal(t, float64(2), resp.GetSeries()[2].GetBuckets()[0].GetSum())
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	assert.Equal(t, int64(3600), bucketStart(7199, 3600))
+	assert.Equal(t, int64(-3600), bucketStart(-1, 3600), "Timestamps before the epoch should round down")
+}
+
+func TestMeasurementsHandler(t *testing.T) {
+	s := newTestServer(t, WithStore(NewMemoryStore()))
+	uploadRequest := generateTestClientEventUploadRequest_Valid()
+	uploadRequest.Events[0].Measurements = []*model.Measurement{{Name: "app_start", Unit: "ms", Value: 840}}
+	jsonReq, err := model.MarshalJSON(uploadRequest)
+	assert.NoError(t, err, "Error marshalling request")
+	req, _ := http.NewRequest("POST", "/v1/events", strings.NewReader(string(jsonReq)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Events with measurements should be stored")
+
+	query := url.Values{"group_by": {"device_type"}, "bucket_seconds": {"60"}, "from": {strconv.FormatInt(uploadRequest.Events[0].GetTimestamp()-60, 10)}}
+	req, _ = http.NewRequest("GET", "/v1/measurements/app_start?"+query.Encode(), nil)
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := new(model.MeasurementQueryResponse)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), resp), "Response should be a MeasurementQueryResponse")
+	if assert.Equal(t, 1, len(resp.GetSeries())) {
+		assert.Equal(t, "testDeviceAndroid", resp.GetSeries()[0].GetGroup()[0].GetValue())
+		assert.Equal(t, float64(840), resp.GetSeries()[0].GetBuckets()[0].GetP99())
+	}
+
+	req, _ = http.NewRequest("GET", "/v1/measurements/app_start?from=yesterday", nil)
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid parameters should be rejected")
+
+	uploadRequest.Events[0].Measurements[0].Name = ""
+	jsonReq, _ = model.MarshalJSON(uploadRequest)
+	req, _ = http.NewRequest("POST", "/v1/events", strings.NewReader(string(jsonReq)))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Measurements without a name should be rejected")
+}
@@ -0,0 +1,384 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+)
+
+const TEXT_PLAIN_PROMETHEUS = "text/plain; version=0.0.4"
+
+//Label of the device types missing from metricsDeviceTypes, device types are chosen by clients and would otherwise add
+//a series for every value they send
+const otherDeviceType = "other"
+
+//Latency buckets in seconds, the same defaults the Prometheus client libraries use
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+//Server instrumentation, every Server has its own set so that instances in one process don't share series
+type serverMetrics struct {
+	httpRequestsTotal    *CounterVec
+	httpRequestDuration  *HistogramVec
+	eventsIngestedTotal  *CounterVec
+	decodeFailuresTotal  *CounterVec
+	validationRejections *CounterVec
+	storeEvents          *GaugeFunc
+
+	ingestionQueueDepth      *GaugeFunc
+	ingestionRejectionsTotal *CounterVec
+
+	webhookDeliveriesTotal *CounterVec
+	webhookQueueDepth      *GaugeFunc
+
+	streamSubscribers      *GaugeFunc
+	streamDisconnectsTotal *CounterVec
+
+	grpcRequestsTotal   *CounterVec
+	grpcRequestDuration *HistogramVec
+
+	deviceTypes map[string]bool
+	collectors  []metricCollector
+}
+
+func newServerMetrics(deviceTypes []string, store func() Store, queueDepth func() int, webhookQueueDepth func() int, streamSubscribers func() int) *serverMetrics {
+	m := &serverMetrics{
+		deviceTypes: make(map[string]bool),
+
+		httpRequestsTotal:    NewCounterVec("meowtrics_http_requests_total", "HTTP requests by route and status code.", "route", "code"),
+		httpRequestDuration:  NewHistogramVec("meowtrics_http_request_duration_seconds", "HTTP request latency by route and status code.", defaultLatencyBuckets, "route", "code"),
+		eventsIngestedTotal:  NewCounterVec("meowtrics_events_ingested_total", "Events stored by event type and device type.", "event_type", "device_type"),
+		decodeFailuresTotal:  NewCounterVec("meowtrics_decode_failures_total", "Upload requests that could not be decoded by content type.", "content_type"),
+		validationRejections: NewCounterVec("meowtrics_validation_rejections_total", "Upload requests rejected by validation by reason.", "reason"),
+		storeEvents:          NewGaugeFunc("meowtrics_store_events", "Events currently held by the datastore.", func() float64 { return float64(store().CountEvents()) }),
+
+		ingestionQueueDepth:      NewGaugeFunc("meowtrics_ingestion_queue_depth", "Upload requests waiting in the async ingestion queue.", func() float64 { return float64(queueDepth()) }),
+		ingestionRejectionsTotal: NewCounterVec("meowtrics_ingestion_queue_rejections_total", "Upload requests turned away because the async ingestion queue was full."),
+
+		webhookDeliveriesTotal: NewCounterVec("meowtrics_webhook_deliveries_total", "Webhook delivery attempts by result: delivered, retried or dead_lettered.", "result"),
+		webhookQueueDepth:      NewGaugeFunc("meowtrics_webhook_queue_depth", "Webhook deliveries waiting for a worker.", func() float64 { return float64(webhookQueueDepth()) }),
+
+		streamSubscribers:      NewGaugeFunc("meowtrics_stream_subscribers", "Clients connected to the event stream.", func() float64 { return float64(streamSubscribers()) }),
+		streamDisconnectsTotal: NewCounterVec("meowtrics_stream_disconnects_total", "Event stream clients disconnected by reason: client, slow_consumer, shutdown or write_error.", "reason"),
+
+		grpcRequestsTotal:   NewCounterVec("meowtrics_grpc_requests_total", "gRPC calls by method and status code.", "method", "code"),
+		grpcRequestDuration: NewHistogramVec("meowtrics_grpc_request_duration_seconds", "gRPC call latency by method and status code.", defaultLatencyBuckets, "method", "code"),
+	}
+	m.collectors = []metricCollector{m.httpRequestsTotal, m.httpRequestDuration, m.eventsIngestedTotal, m.decodeFailuresTotal, m.validationRejections, m.storeEvents,
+		m.ingestionQueueDepth, m.ingestionRejectionsTotal, m.webhookDeliveriesTotal, m.webhookQueueDepth,
+		m.streamSubscribers, m.streamDisconnectsTotal, m.grpcRequestsTotal, m.grpcRequestDuration}
+	for _, deviceType := range deviceTypes {
+		m.deviceTypes[deviceType] = true
+	}
+	return m
+}
+
+//The device_type label of an ingested event
+func (m *serverMetrics) deviceTypeLabel(deviceType string) string {
+	if m.deviceTypes[deviceType] {
+		return deviceType
+	}
+	return otherDeviceType
+}
+
+type metricCollector interface {
+	writeTo(buf *bytes.Buffer)
+}
+
+//Label values are joined with a separator that can't show up in valid UTF-8 label values
+const labelSeparator = "\xff"
+
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	c.mutex.Lock()
+	c.values[strings.Join(labelValues, labelSeparator)] += delta
+	c.mutex.Unlock()
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.values[strings.Join(labelValues, labelSeparator)]
+}
+
+func (c *CounterVec) writeTo(buf *bytes.Buffer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	writeMetricHeader(buf, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(buf, "%s%s %s\n", c.name, formatLabels(c.labels, key, "", ""), formatValue(c.values[key]))
+	}
+}
+
+type GaugeVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+func NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mutex.Lock()
+	g.values[strings.Join(labelValues, labelSeparator)] = value
+	g.mutex.Unlock()
+}
+
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.values[strings.Join(labelValues, labelSeparator)]
+}
+
+func (g *GaugeVec) writeTo(buf *bytes.Buffer) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	writeMetricHeader(buf, g.name, g.help, "gauge")
+	for _, key := range sortedKeys(g.values) {
+		fmt.Fprintf(buf, "%s%s %s\n", g.name, formatLabels(g.labels, key, "", ""), formatValue(g.values[key]))
+	}
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mutex  sync.Mutex
+	values map[string]*histogram
+}
+
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogram)}
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := strings.Join(labelValues, labelSeparator)
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+func (h *HistogramVec) writeTo(buf *bytes.Buffer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writeMetricHeader(buf, h.name, h.help, "histogram")
+	for _, key := range keys {
+		hist := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", formatValue(bound)), hist.counts[i])
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", "+Inf"), hist.count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", h.name, formatLabels(h.labels, key, "", ""), formatValue(hist.sum))
+		fmt.Fprintf(buf, "%s_count%s %d\n", h.name, formatLabels(h.labels, key, "", ""), hist.count)
+	}
+}
+
+//Summary without quantiles, keeping the sum and count of the observed values per label set
+type SummaryVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mutex  sync.Mutex
+	sums   map[string]float64
+	counts map[string]float64
+}
+
+func NewSummaryVec(name string, help string, labels ...string) *SummaryVec {
+	return &SummaryVec{name: name, help: help, labels: labels, sums: make(map[string]float64), counts: make(map[string]float64)}
+}
+
+func (s *SummaryVec) Observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, labelSeparator)
+	s.mutex.Lock()
+	s.sums[key] += value
+	s.counts[key]++
+	s.mutex.Unlock()
+}
+
+func (s *SummaryVec) Sum(labelValues ...string) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sums[strings.Join(labelValues, labelSeparator)]
+}
+
+func (s *SummaryVec) Count(labelValues ...string) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.counts[strings.Join(labelValues, labelSeparator)]
+}
+
+func (s *SummaryVec) writeTo(buf *bytes.Buffer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	writeMetricHeader(buf, s.name, s.help, "summary")
+	for _, key := range sortedKeys(s.sums) {
+		fmt.Fprintf(buf, "%s_sum%s %s\n", s.name, formatLabels(s.labels, key, "", ""), formatValue(s.sums[key]))
+		fmt.Fprintf(buf, "%s_count%s %s\n", s.name, formatLabels(s.labels, key, "", ""), formatValue(s.counts[key]))
+	}
+}
+
+//Gauge evaluated on every scrape
+type GaugeFunc struct {
+	name  string
+	help  string
+	value func() float64
+}
+
+func NewGaugeFunc(name string, help string, value func() float64) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, value: value}
+}
+
+func (g *GaugeFunc) writeTo(buf *bytes.Buffer) {
+	writeMetricHeader(buf, g.name, g.help, "gauge")
+	fmt.Fprintf(buf, "%s %s\n", g.name, formatValue(g.value()))
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name string, help string, kind string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+//Formats the joined label values as {name="value",...}, extraName and extraValue are appended when set (used for "le")
+func formatLabels(names []string, joinedValues string, extraName string, extraValue string) string {
+	var pairs []string
+	if len(names) > 0 {
+		values := strings.Split(joinedValues, labelSeparator)
+		for i, name := range names {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			pairs = append(pairs, name+`="`+labelValueEscaper.Replace(value)+`"`)
+		}
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+//Renders the collectors in the Prometheus text exposition format
+func WriteMetrics(buf *bytes.Buffer, collectors []metricCollector) {
+	for _, collector := range collectors {
+		collector.writeTo(buf)
+	}
+}
+
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeMetricsResponse(w, s.metrics.collectors)
+	})
+}
+
+func writeMetricsResponse(w http.ResponseWriter, collectors []metricCollector) {
+	var buf bytes.Buffer
+	WriteMetrics(&buf, collectors)
+	w.Header().Set("Content-Type", TEXT_PLAIN_PROMETHEUS)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+//Negroni middleware recording request counts and latencies, routes are labelled by their mux route name
+type MetricsMiddleware struct {
+	router  *mux.Router
+	metrics *serverMetrics
+}
+
+func newMetricsMiddleware(router *mux.Router, metrics *serverMetrics) *MetricsMiddleware {
+	return &MetricsMiddleware{router: router, metrics: metrics}
+}
+
+func (m *MetricsMiddleware) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	route := "notFound"
+	var match mux.RouteMatch
+	if m.router.Match(req, &match) && match.Route.GetName() != "" {
+		route = match.Route.GetName()
+	}
+
+	next(w, req)
+
+	status := http.StatusOK
+	if res, ok := w.(negroni.ResponseWriter); ok && res.Status() != 0 {
+		status = res.Status()
+	}
+	code := strconv.Itoa(status)
+	m.metrics.httpRequestsTotal.Inc(route, code)
+	m.metrics.httpRequestDuration.Observe(time.Since(start).Seconds(), route, code)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterVec_Exposition(t *testing.T) {
+	counter := NewCounterVec("test_total", "Test counter.", "route", "code")
+	counter.Inc("createEvent", "200")
+	counter.Add(2, "createEvent", "200")
+	counter.Inc("quote\"route", "404")
+
+	var buf bytes.Buffer
+	WriteMetrics(&buf, []metricCollector{counter})
+	expected := "# HELP test_total Test counter.\n" +
+		"# TYPE test_total counter\n" +
+		"test_total{route=\"createEvent\",code=\"200\"} 3\n" +
+		"test_total{route=\"quote\\\"route\",code=\"404\"} 1\n"
+	assert.Equal(t, expected, buf.String(), "Counter exposition should match")
+}
+
+func TestHistogramVec_Exposition(t *testing.T) {
+	hist := NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1}, "route")
+	hist.Observe(0.05, "heartbeat")
+	hist.Observe(0.5, "heartbeat")
+	hist.Observe(5, "heartbeat")
+
+	var buf bytes.Buffer
+	WriteMetrics(&buf, []metricCollector{hist})
+	expected := "# HELP test_seconds Test histogram.\n" +
+		"# TYPE test_seconds histogram\n" +
+		"test_seconds_bucket{route=\"heartbeat\",le=\"0.1\"} 1\n" +
+		"test_seconds_bucket{route=\"heartbeat\",le=\"1\"} 2\n" +
+		"test_seconds_bucket{route=\"heartbeat\",le=\"+Inf\"} 3\n" +
+		"test_seconds_sum{route=\"heartbeat\"} 5.55\n" +
+		"test_seconds_count{route=\"heartbeat\"} 3\n"
+	assert.Equal(t, expected, buf.String(), "Histogram exposition should match")
+}
+
+func TestMetricsMiddleware_RouteLabels(t *testing.T) {
+	n := negroni.New(newMetricsMiddleware(testServer.router, testServer.metrics))
+	n.UseHandler(testServer.router)
+
+	serve := func(method string, location string) {
+		req, err := http.NewRequest(method, location, strings.NewReader(""))
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+		n.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	heartbeats := testServer.metrics.httpRequestsTotal.Value("heartbeat", "200")
+	notFound := testServer.metrics.httpRequestsTotal.Value("notFound", "404")
+
+	serve("GET", "/heartbeat")
+	serve("GET", "/meow")
+
+	assert.Equal(t, heartbeats+1, testServer.metrics.httpRequestsTotal.Value("heartbeat", "200"), "Heartbeat request should be counted")
+	assert.Equal(t, notFound+1, testServer.metrics.httpRequestsTotal.Value("notFound", "404"), "Unknown route should be counted as notFound")
+}
+
+func TestCreateEventHandler_IngestionMetrics(t *testing.T) {
+	resetTestStore()
+	test := GeneratePostHandleTester(t, testServer.CreateEventHandler(), "application/json")
+	uploadReq := generateTestClientEventUploadRequest_Valid()
+	jsonReq, err := model.MarshalJSON(uploadReq)
+	if err != nil {
+		panic("Cannot marshal data. Error: " + err.Error())
+	}
+
+	ingested := testServer.metrics.eventsIngestedTotal.Value("UNKNOWN", otherDeviceType)
+	decodeFailures := testServer.metrics.decodeFailuresTotal.Value(APPLICATION_JSON)
+
+	test("POST", string(jsonReq))
+	test("POST", "randomString")
+
+	assert.Equal(t, ingested+1, testServer.metrics.eventsIngestedTotal.Value("UNKNOWN", otherDeviceType), "Stored event should be counted")
+	assert.Equal(t, decodeFailures+1, testServer.metrics.decodeFailuresTotal.Value(APPLICATION_JSON), "Malformed JSON should be counted")
+
+	w := httptest.NewRecorder()
+	testServer.MetricsHandler().ServeHTTP(w, nil)
+	assert.Equal(t, TEXT_PLAIN_PROMETHEUS, w.Header().Get("Content-Type"), "Content type should be the Prometheus text format")
+	assert.Contains(t, w.Body.String(), "meowtrics_store_events 1\n", "Store size should be exposed")
+}
+
+func TestEventsIngestedTotal_DeviceTypes(t *testing.T) {
+	config := DefaultConfig()
+	config.MetricsDeviceTypes = []string{"testDeviceAndroid"}
+	s := newTestServer(t, WithConfig(config))
+
+	uploadRequest := generateTestClientEventUploadRequest_Valid()
+	assert.NoError(t, s.storeUploadRequest(uploadRequest))
+	uploadRequest = generateTestClientEventUploadRequest_Valid()
+	uploadRequest.DeviceType = "unlistedDevice"
+	uploadRequest.Events[0].EventId = "unlistedEventId"
+	assert.NoError(t, s.storeUploadRequest(uploadRequest))
+
+	assert.Equal(t, float64(1), s.metrics.eventsIngestedTotal.Value("UNKNOWN", "testDeviceAndroid"), "Listed device types should be labelled as they are")
+	assert.Equal(t, float64(1), s.metrics.eventsIngestedTotal.Value("UNKNOWN", otherDeviceType), "Other device types should share one series")
+	assert.Equal(t, float64(0), s.metrics.eventsIngestedTotal.Value("UNKNOWN", "unlistedDevice"))
+}
@@ -1,7 +1,7 @@
-package main
+package server
 
 import (
-	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"meowtrics/model"
@@ -9,14 +9,15 @@ import (
 	"strconv"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/context"
+	"google.golang.org/protobuf/proto"
 )
 
 //------------------GET-----------------------
 
-func processJsonGet(id string, logger *log.Logger) (int, *model.ClientEventData) {
+func (s *Server) processJsonGet(id string, logger *log.Entry) (int, *model.ClientEventData) {
 
-	event, err := RetrieveEvent(id)
+	event, err := s.store.RetrieveEvent(id)
 	switch err {
 	case nil:
 		return http.StatusOK, event
@@ -32,9 +33,9 @@ func processJsonGet(id string, logger *log.Logger) (int, *model.ClientEventData)
 	return http.StatusInternalServerError, nil
 }
 
-func processProtobufGet(id string, logger *log.Logger) (int, []byte) {
+func (s *Server) processProtobufGet(id string, logger *log.Entry) (int, []byte) {
 
-	event, err := RetrieveEvent(id)
+	event, err := s.store.RetrieveEvent(id)
 	if err != nil {
 		switch err {
 		case RecordNotFoundError:
@@ -55,79 +56,105 @@ func processProtobufGet(id string, logger *log.Logger) (int, []byte) {
 	return http.StatusOK, protoBytes
 }
 
-func processUnsupportedMediaTypeGet(req *http.Request, logger *log.Logger) (int, *model.ErrorResponse) {
+func processUnsupportedMediaTypeGet(req *http.Request, logger *log.Entry) (int, *model.ErrorResponse) {
 	logger.WithFields(log.Fields{"method": "processUnsupportedMediaTypeGet", "error": UnsupportedMedia}).Infoln("Accept: " + req.Header.Get("Accept"))
 
-	errCode := UnsupportedMedia
-	errMsg := "Accept specifies a media type that is not supported by this resource"
-	return http.StatusUnsupportedMediaType, &model.ErrorResponse{Code: &errCode, ErrorMessage: &errMsg}
+	return http.StatusUnsupportedMediaType, &model.ErrorResponse{Code: UnsupportedMedia, ErrorMessage: "Accept specifies a media type that is not supported by this resource"}
 }
 
 //-----------------POST-----------------------
 
-func processJsonPost(req *http.Request, logger *log.Logger) (int, *model.ErrorResponse) {
+func (s *Server) processJsonPost(req *http.Request, logger *log.Entry) (int, *model.ErrorResponse) {
 
-	uploadRequest, err := decodeJson(req.Body)
+	uploadRequest, err := s.decodeJson(req.Body)
 	if err != nil {
 		logger.WithFields(log.Fields{"method": "processJsonPost", "error": err.Error()}).Warningln("Error decoding json")
+		s.metrics.decodeFailuresTotal.Inc(APPLICATION_JSON)
 
-		errCode := MalformedRequest
-		errMsg := "Request body contains malformed JSON"
-		return http.StatusBadRequest, &model.ErrorResponse{Code: &errCode, ErrorMessage: &errMsg}
+		return http.StatusBadRequest, &model.ErrorResponse{Code: MalformedRequest, ErrorMessage: "Request body contains malformed JSON", Description: err.Error()}
 	}
 
-	err, errResp := processUploadRequest(*uploadRequest, logger)
+	setUploadRequestId(req, uploadRequest.GetRequestId())
+	if status, errResp := s.processEventQuota(req, len(uploadRequest.GetEvents()), logger); errResp != nil {
+		return status, errResp
+	}
+
+	err, errResp := s.processUploadRequest(uploadRequest, logger)
 	if err != nil {
 		switch err {
 		case InvalidParametersError:
 			return http.StatusBadRequest, errResp
 		case FatalError:
 			return http.StatusInternalServerError, errResp
+		case QueueFullError:
+			context.Set(req, retryAfterKey, s.ingestionRetryAfter())
+			return http.StatusServiceUnavailable, errResp
 		}
 	}
 
+	if s.ingestion != nil {
+		return http.StatusAccepted, nil
+	}
 	return http.StatusOK, nil
 }
 
-func processProtobufPost(req *http.Request, logger *log.Logger) (int, *model.ErrorResponse) {
+func (s *Server) processProtobufPost(req *http.Request, logger *log.Entry) (int, *model.ErrorResponse) {
 
 	uploadRequest, err := decodeProtobuf(req.Body)
 	if err != nil {
 		logger.WithFields(log.Fields{"method": "processProtobufPost", "error": err.Error()}).Warningln("Error decoding body")
+		s.metrics.decodeFailuresTotal.Inc(APPLICATION_PROTOBUF)
 
-		errCode := MalformedRequest
-		errMsg := "Request body contains malformed buffered data"
-		return http.StatusBadRequest, &model.ErrorResponse{Code: &errCode, ErrorMessage: &errMsg}
+		return http.StatusBadRequest, &model.ErrorResponse{Code: MalformedRequest, ErrorMessage: "Request body contains malformed buffered data"}
 	}
 
-	err, errResp := processUploadRequest(*uploadRequest, logger)
+	setUploadRequestId(req, uploadRequest.GetRequestId())
+	if status, errResp := s.processEventQuota(req, len(uploadRequest.GetEvents()), logger); errResp != nil {
+		return status, errResp
+	}
+
+	err, errResp := s.processUploadRequest(uploadRequest, logger)
 	if err != nil {
 		switch err {
 		case InvalidParametersError:
 			return http.StatusBadRequest, errResp
 		case FatalError:
 			return http.StatusInternalServerError, errResp
+		case QueueFullError:
+			context.Set(req, retryAfterKey, s.ingestionRetryAfter())
+			return http.StatusServiceUnavailable, errResp
 		}
 	}
 
+	if s.ingestion != nil {
+		return http.StatusAccepted, nil
+	}
 	return http.StatusOK, nil
 }
 
 //Can be used for logging in case there's a system in place to ban IP addresses that try to DDOS the service.
-func processUnsupportedMediaTypePost(req *http.Request, logger *log.Logger) (int, *model.ErrorResponse) {
+func processUnsupportedMediaTypePost(req *http.Request, logger *log.Entry) (int, *model.ErrorResponse) {
 	logger.WithFields(log.Fields{"method": "processUnsupportedMediaTypePost", "error": UnsupportedMedia}).Infoln("Content-Type: " + req.Header.Get("Content-Type"))
 
-	errCode := UnsupportedMedia
-	errMsg := "Content-Type specifies a media type that is not supported by this resource"
-	return http.StatusUnsupportedMediaType, &model.ErrorResponse{Code: &errCode, ErrorMessage: &errMsg}
+	return http.StatusUnsupportedMediaType, &model.ErrorResponse{Code: UnsupportedMedia, ErrorMessage: "Content-Type specifies a media type that is not supported by this resource"}
 }
 
 //-----------------------------------------------------
 
-func decodeJson(r io.ReadCloser) (uploadRequest *model.ClientEventUploadRequest, err error) {
-	uploadRequest = new(model.ClientEventUploadRequest)
-	err = json.NewDecoder(r).Decode(uploadRequest)
-	return
+func (s *Server) decodeJson(r io.ReadCloser) (*model.ClientEventUploadRequest, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadRequest := new(model.ClientEventUploadRequest)
+	err = s.unmarshalJSON(data, uploadRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return uploadRequest, nil
 }
 
 func decodeProtobuf(r io.ReadCloser) (*model.ClientEventUploadRequest, error) {
@@ -153,32 +180,134 @@ Each upload request can have multiple events, to achieve transcational behavior
 
 Partial storage is performed in case of errors from in memory database StoreEvent() method
 */
-func processUploadRequest(uploadRequest model.ClientEventUploadRequest, logger *log.Logger) (error, *model.ErrorResponse) {
+func (s *Server) processUploadRequest(uploadRequest *model.ClientEventUploadRequest, logger *log.Entry) (error, *model.ErrorResponse) {
+	return s.ingestUploadRequest(uploadRequest, logger, s.ingestion)
+}
+
+//Like processUploadRequest, valid requests go into the given queue, or are stored right away when it is nil
+func (s *Server) ingestUploadRequest(uploadRequest *model.ClientEventUploadRequest, logger *log.Entry, queue *IngestionQueue) (error, *model.ErrorResponse) {
+	if err := validateUploadRequestFields(uploadRequest); err != nil {
+		logger.WithFields(log.Fields{"method": "processUploadRequest", "error": err.Error(), "requestId": uploadRequest.GetRequestId()}).Warningln("Error validating the upload request")
+		s.metrics.validationRejections.Inc("missing_field")
+
+		return InvalidParametersError, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Upload request is missing a required field", Description: err.Error()}
+	}
+
 	flag, index := hasValidEventIds(uploadRequest.GetEvents())
 	if !flag {
 		logger.WithFields(log.Fields{"method": "processUploadRequest", "error": InvalidParametersError.Error(), "requestId": uploadRequest.GetRequestId()}).Warningln("Error validating eventIds in the upload request")
+		s.metrics.validationRejections.Inc("missing_event_id")
 
-		errCode := InvalidRequestParameters
-		errMsg := "Event bundle has an event with invalid eventId"
-		errDes := "Event index (count starts from 0): " + strconv.Itoa(index)
-		return InvalidParametersError, &model.ErrorResponse{Code: &errCode, ErrorMessage: &errMsg, Description: &errDes}
+		return InvalidParametersError, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Event bundle has an event with invalid eventId", Description: "Event index (count starts from 0): " + strconv.Itoa(index)}
 	}
 
-	for i, event := range uploadRequest.GetEvents() {
-		err := StoreEvent(*event)
-		if err != nil {
-			logger.WithFields(log.Fields{"method": "processUploadRequest", "error": FatalError.Error(), "requestId": uploadRequest.GetRequestId()}).Errorln("Error storing event with index: " + strconv.Itoa(i))
+	if index, err := validateMeasurements(uploadRequest.GetEvents()); err != nil {
+		logger.WithFields(log.Fields{"method": "processUploadRequest", "error": err.Error(), "requestId": uploadRequest.GetRequestId()}).Warningln("Error validating measurements in the upload request")
+		s.metrics.validationRejections.Inc("invalid_measurement")
 
-			errCode := Fatal
-			errMsg := "Error storing events, aborting"
-			return FatalError, &model.ErrorResponse{Code: &errCode, ErrorMessage: &errMsg}
+		return InvalidParametersError, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Event bundle has an event with an invalid measurement", Description: "Event index (count starts from 0): " + strconv.Itoa(index) + ", " + err.Error()}
+	}
+
+	if index, err := validateKvPairs(uploadRequest.GetEvents()); err != nil {
+		logger.WithFields(log.Fields{"method": "processUploadRequest", "error": err.Error(), "requestId": uploadRequest.GetRequestId()}).Warningln("Error validating kv pairs in the upload request")
+		s.metrics.validationRejections.Inc("invalid_kv_pair")
+
+		return InvalidParametersError, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Event bundle has an event with an invalid kv pair", Description: "Event index (count starts from 0): " + strconv.Itoa(index) + ", " + err.Error()}
+	}
+
+	if queue != nil {
+		if err := queue.Enqueue(uploadRequest); err == QueueFullError {
+			logger.WithFields(log.Fields{"method": "processUploadRequest", "error": err.Error(), "requestId": uploadRequest.GetRequestId()}).Warningln("Ingestion queue is full")
+			s.metrics.ingestionRejectionsTotal.Inc()
+
+			return QueueFullError, &model.ErrorResponse{Code: QueueFull, ErrorMessage: "Ingestion queue is full, retry later"}
+		} else if err != nil {
+			logger.WithFields(log.Fields{"method": "processUploadRequest", "error": err.Error(), "requestId": uploadRequest.GetRequestId()}).Errorln("Error queueing upload request")
+
+			return FatalError, &model.ErrorResponse{Code: Fatal, ErrorMessage: "Error queueing events, aborting"}
 		}
+		logger.WithFields(log.Fields{"method": "processUploadRequest", "requestId": uploadRequest.GetRequestId()}).Infoln("Request queued")
+		return nil, nil
 	}
 
-	logger.WithFields(log.Fields{"method": "processUploadRequest", "requestId": uploadRequest.RequestId}).Infoln("Request successfully processed")
+	if err := s.storeUploadRequest(uploadRequest); err != nil {
+		logger.WithFields(log.Fields{"method": "processUploadRequest", "error": FatalError.Error(), "requestId": uploadRequest.GetRequestId()}).Errorln(err.Error())
+
+		return FatalError, &model.ErrorResponse{Code: Fatal, ErrorMessage: "Error storing events, aborting"}
+	}
+
+	logger.WithFields(log.Fields{"method": "processUploadRequest", "requestId": uploadRequest.GetRequestId()}).Infoln("Request successfully processed")
 	return nil, nil
 }
 
+//Writes the events of a validated upload request, stops at the first event the store rejects
+func (s *Server) storeUploadRequest(uploadRequest *model.ClientEventUploadRequest) error {
+	_, err := s.storeEvents(uploadRequest)
+	return err
+}
+
+//Like storeUploadRequest, also returns the number of events stored. Only events with a new event id are observed by
+//the metrics, sketches, rollups, webhooks and the stream.
+func (s *Server) storeEvents(uploadRequest *model.ClientEventUploadRequest) (int, error) {
+	for i, event := range uploadRequest.GetEvents() {
+		event.DeviceType = uploadRequest.GetDeviceType()
+		setRequiredFields(event)
+		_, err := s.store.RetrieveEvent(event.GetEventId())
+		replaced := err == nil
+		if err := s.store.StoreEvent(event); err != nil {
+			return i, errors.New("Error storing event with index: " + strconv.Itoa(i))
+		}
+		//Client retries, queue replays and stream resends store the event again, it was counted and sent out already
+		if replaced {
+			continue
+		}
+		deviceTypeLabel := s.metrics.deviceTypeLabel(uploadRequest.GetDeviceType())
+		s.metrics.eventsIngestedTotal.Inc(event.GetEventType().String(), deviceTypeLabel)
+		s.eventMetrics.Observe(event, deviceTypeLabel)
+		s.sketches.Observe(event)
+		s.rollups.Observe(event)
+		s.webhooks.Notify(event, uploadRequest.GetDeviceType())
+		s.stream.Publish(event, uploadRequest.GetDeviceType())
+	}
+	s.expireRawEvents()
+	return len(uploadRequest.GetEvents()), nil
+}
+
+//Clients built from the proto2 model fail to decode kv pairs without a value, stored typed values get their string form
+func setRequiredFields(event *model.ClientEventData) {
+	for _, pair := range event.GetKvPair() {
+		if pair.Value == nil {
+			pair.Value = proto.String(attributeString(kvPairAttribute(pair)))
+		}
+	}
+}
+
+//The fields the proto2 model required, proto3 can't tell them missing. Event ids are checked by hasValidEventIds.
+func validateUploadRequestFields(uploadRequest *model.ClientEventUploadRequest) error {
+	switch {
+	case uploadRequest.GetRequestId() == "":
+		return errors.New("request_id is required")
+	case uploadRequest.GetDeviceType() == "":
+		return errors.New("device_type is required")
+	}
+	for i, event := range uploadRequest.GetEvents() {
+		if err := validateEventFields(event); err != nil {
+			return errors.New("Event index (count starts from 0): " + strconv.Itoa(i) + ", " + err.Error())
+		}
+	}
+	return nil
+}
+
+func validateEventFields(event *model.ClientEventData) error {
+	switch {
+	case event.GetEventType() == model.ClientEventType_UNSPECIFIED:
+		return errors.New("event_type is required")
+	case event.Timestamp == nil:
+		return errors.New("timestamp is required")
+	}
+	return nil
+}
+
 func hasValidEventIds(events []*model.ClientEventData) (bool, int) {
 	for i, event := range events {
 		if event.GetEventId() == "" {
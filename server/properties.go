@@ -0,0 +1,60 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+//Read access to app properties. The components that can be reloaded read their settings through this,
+//so that a changed config can be validated before it replaces the running one.
+type appProperties interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetFloat64(key string) float64
+	GetBool(key string) bool
+	IsSet(key string) bool
+	MarshalKey(key string, rawVal interface{}) error
+}
+
+//Properties loaded by viper at startup
+type viperProperties struct{}
+
+func (viperProperties) GetString(key string) string   { return viper.GetString(key) }
+func (viperProperties) GetInt(key string) int         { return viper.GetInt(key) }
+func (viperProperties) GetFloat64(key string) float64 { return viper.GetFloat64(key) }
+func (viperProperties) GetBool(key string) bool       { return viper.GetBool(key) }
+func (viperProperties) IsSet(key string) bool         { return viper.IsSet(key) }
+func (viperProperties) MarshalKey(key string, rawVal interface{}) error {
+	return viper.MarshalKey(key, rawVal)
+}
+
+//Properties decoded from a config file without going through viper, keys are case insensitive like in viper
+type mapProperties map[string]interface{}
+
+func newMapProperties(settings map[string]interface{}) mapProperties {
+	props := make(mapProperties)
+	for key, value := range settings {
+		props[strings.ToLower(key)] = value
+	}
+	return props
+}
+
+func (m mapProperties) get(key string) interface{}    { return m[strings.ToLower(key)] }
+func (m mapProperties) GetString(key string) string   { return cast.ToString(m.get(key)) }
+func (m mapProperties) GetInt(key string) int         { return cast.ToInt(m.get(key)) }
+func (m mapProperties) GetFloat64(key string) float64 { return cast.ToFloat64(m.get(key)) }
+func (m mapProperties) GetBool(key string) bool       { return cast.ToBool(m.get(key)) }
+func (m mapProperties) IsSet(key string) bool         { return m.get(key) != nil }
+func (m mapProperties) MarshalKey(key string, rawVal interface{}) error {
+	return mapstructure.Decode(m.get(key), rawVal)
+}
+
+func getStringOrDefault(props appProperties, key string, defaultValue string) string {
+	if value := props.GetString(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"math"
+	"meowtrics/model"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/context"
+)
+
+const (
+	API_KEY_HEADER   = "X-API-Key"
+	TENANT_ID_HEADER = "X-Tenant-ID"
+)
+
+//Buckets are only pruned once the map grows past this size, full buckets carry no state worth keeping
+const maxRateLimitBuckets = 10000
+
+//Limits for a single dimension (client IP, API key or tenant), a zero value disables that limit
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             float64
+	EventsPerMinute   float64
+}
+
+type tokenBucket struct {
+	kind       string
+	dimension  string
+	capacity   float64
+	refillRate float64 //tokens per second
+	tokens     float64
+	last       time.Time
+}
+
+func newTokenBucket(capacity float64, refillRate float64, now time.Time) *tokenBucket {
+	return &tokenBucket{capacity: capacity, refillRate: refillRate, tokens: capacity, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.refillRate)
+		b.last = now
+	}
+}
+
+//Returns how long the caller has to wait until n tokens are available, zero if they are available right now.
+//Requests larger than the bucket are let through on a full bucket and leave it in debt.
+func (b *tokenBucket) wait(n float64) time.Duration {
+	if n > b.capacity {
+		n = b.capacity
+	}
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.refillRate * float64(time.Second))
+}
+
+//Token bucket rate limiter keyed per dimension, e.g. "ip:10.0.0.1" or "tenant:acme"
+type RateLimiter struct {
+	mutex   sync.Mutex
+	limits  map[string]RateLimit
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+	logger  *log.Logger
+}
+
+func NewRateLimiter(limits map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{limits: limits, buckets: make(map[string]*tokenBucket), now: time.Now, logger: log.New()}
+}
+
+//Takes n tokens from every bucket selected by the keys, either from all of them or from none.
+//Returns false and the longest wait if any bucket is short on tokens.
+func (rl *RateLimiter) take(keys map[string]string, kind string, n float64) (bool, time.Duration) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := rl.now()
+	var selected []*tokenBucket
+	var retryAfter time.Duration
+
+	for dimension, value := range keys {
+		capacity, refillRate := bucketLimits(rl.limits[dimension], kind)
+		if refillRate <= 0 || value == "" {
+			continue
+		}
+
+		bucketKey := kind + "|" + dimension + ":" + value
+		bucket, ok := rl.buckets[bucketKey]
+		if !ok {
+			bucket = newTokenBucket(capacity, refillRate, now)
+			bucket.kind, bucket.dimension = kind, dimension
+			rl.buckets[bucketKey] = bucket
+		}
+		bucket.refill(now)
+		if wait := bucket.wait(n); wait > retryAfter {
+			retryAfter = wait
+		}
+		selected = append(selected, bucket)
+	}
+
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+
+	for _, bucket := range selected {
+		bucket.tokens -= n
+	}
+
+	if len(rl.buckets) > maxRateLimitBuckets {
+		rl.prune(now)
+	}
+	return true, 0
+}
+
+//Capacity and tokens per second of the buckets of one kind ("requests" or "events") under the limit
+func bucketLimits(limit RateLimit, kind string) (float64, float64) {
+	switch kind {
+	case "requests":
+		return limit.Burst, limit.RequestsPerSecond
+	case "events":
+		return limit.EventsPerMinute, limit.EventsPerMinute / 60
+	}
+	return 0, 0
+}
+
+//Replaces the limits of a running limiter. Buckets keep the tokens they have, so a reload doesn't hand out fresh
+//quota; buckets of changed limits are refilled up to now at the old rate and then continue at the new one.
+func (rl *RateLimiter) SetLimits(limits map[string]RateLimit) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := rl.now()
+	for key, bucket := range rl.buckets {
+		capacity, refillRate := bucketLimits(limits[bucket.dimension], bucket.kind)
+		if refillRate <= 0 {
+			delete(rl.buckets, key)
+			continue
+		}
+		if capacity == bucket.capacity && refillRate == bucket.refillRate {
+			continue
+		}
+		bucket.refill(now)
+		bucket.capacity, bucket.refillRate = capacity, refillRate
+		bucket.tokens = math.Min(bucket.tokens, capacity)
+	}
+	rl.limits = limits
+}
+
+func (rl *RateLimiter) prune(now time.Time) {
+	for key, bucket := range rl.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= bucket.capacity {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+func (rl *RateLimiter) AllowRequest(keys map[string]string) (bool, time.Duration) {
+	return rl.take(keys, "requests", 1)
+}
+
+func (rl *RateLimiter) AllowEvents(keys map[string]string, count int) (bool, time.Duration) {
+	if count == 0 {
+		return true, 0
+	}
+	return rl.take(keys, "events", float64(count))
+}
+
+//Negroni middleware enforcing the requests per second limits, the events per minute limits are
+//enforced by the POST processors once the upload request is decoded
+func (rl *RateLimiter) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	//The router clears the context as well, but limited requests never reach it
+	defer context.Clear(req)
+	keys := rateLimitKeys(req)
+	context.Set(req, rateLimitKeysKey, keys)
+
+	ok, retryAfter := rl.AllowRequest(keys)
+	if !ok {
+		requestLogger(req, rl.logger).WithFields(log.Fields{"method": "RateLimiter", "error": RateLimitedError.Error(), "ip": keys["ip"], "tenant": keys["tenant"]}).Infoln("Request rate limit exceeded")
+
+		setRetryAfterHeader(w, retryAfter)
+		writeJSON(w, http.StatusTooManyRequests, &model.ErrorResponse{Code: RateLimited, ErrorMessage: "Request rate limit exceeded"})
+		return
+	}
+
+	next(w, req)
+}
+
+func rateLimitKeys(req *http.Request) map[string]string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+	return map[string]string{
+		"ip":     ip,
+		"apiKey": CallerIdentity(req),
+		"tenant": req.Header.Get(TENANT_ID_HEADER),
+	}
+}
+
+//Checks the events per minute quota for requests that went through the rate limiting middleware
+func (s *Server) processEventQuota(req *http.Request, count int, logger *log.Entry) (int, *model.ErrorResponse) {
+	keys, ok := context.Get(req, rateLimitKeysKey).(map[string]string)
+	if !ok {
+		return http.StatusOK, nil
+	}
+
+	allowed, retryAfter := s.rateLimiter.AllowEvents(keys, count)
+	if allowed {
+		return http.StatusOK, nil
+	}
+
+	logger.WithFields(log.Fields{"method": "processEventQuota", "error": RateLimitedError.Error(), "ip": keys["ip"], "tenant": keys["tenant"], "events": count}).Infoln("Event quota exceeded")
+	context.Set(req, retryAfterKey, retryAfter)
+
+	return http.StatusTooManyRequests, &model.ErrorResponse{Code: RateLimited, ErrorMessage: "Event quota exceeded", Description: "Events in request: " + strconv.Itoa(count)}
+}
+
+func setRetryAfterHeader(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+}
+
+//Whole seconds to wait, at least one
+func retryAfterSeconds(retryAfter time.Duration) int {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codegangsta/negroni"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestRateLimiter(limit RateLimit) (*RateLimiter, *time.Time) {
+	now := time.Unix(1422409858, 0)
+	rl := NewRateLimiter(map[string]RateLimit{"ip": limit})
+	rl.now = func() time.Time { return now }
+	return rl, &now
+}
+
+func TestRateLimiter_AllowRequest(t *testing.T) {
+	rl, now := generateTestRateLimiter(RateLimit{RequestsPerSecond: 1, Burst: 2})
+	keys := map[string]string{"ip": "127.0.0.1"}
+
+	ok, _ := rl.AllowRequest(keys)
+	assert.True(t, ok, "First request should be allowed")
+	ok, _ = rl.AllowRequest(keys)
+	assert.True(t, ok, "Second request should be allowed by the burst")
+
+	ok, retryAfter := rl.AllowRequest(keys)
+	assert.False(t, ok, "Third request should be limited")
+	assert.Equal(t, time.Second, retryAfter, "Retry after should be the refill time of one token")
+
+	ok, _ = rl.AllowRequest(map[string]string{"ip": "127.0.0.2"})
+	assert.True(t, ok, "Other client IPs should have their own bucket")
+
+	*now = now.Add(time.Second)
+	ok, _ = rl.AllowRequest(keys)
+	assert.True(t, ok, "Request should be allowed after the bucket refills")
+}
+
+func TestRateLimiter_AllowEvents(t *testing.T) {
+	rl, now := generateTestRateLimiter(RateLimit{EventsPerMinute: 60})
+	keys := map[string]string{"ip": "127.0.0.1"}
+
+	ok, _ := rl.AllowEvents(keys, 50)
+	assert.True(t, ok, "Events under the quota should be allowed")
+
+	ok, retryAfter := rl.AllowEvents(keys, 20)
+	assert.False(t, ok, "Events over the quota should be limited")
+	assert.Equal(t, 10*time.Second, retryAfter, "Retry after should be the refill time of the missing events")
+
+	*now = now.Add(10 * time.Second)
+	ok, _ = rl.AllowEvents(keys, 20)
+	assert.True(t, ok, "Events should be allowed after the bucket refills")
+}
+
+func TestRateLimiter_SetLimits(t *testing.T) {
+	rl, now := generateTestRateLimiter(RateLimit{RequestsPerSecond: 1, Burst: 2})
+	keys := map[string]string{"ip": "127.0.0.1"}
+	rl.AllowRequest(keys)
+	rl.AllowRequest(keys)
+
+	rl.SetLimits(map[string]RateLimit{"ip": {RequestsPerSecond: 1, Burst: 2}})
+	ok, _ := rl.AllowRequest(keys)
+	assert.False(t, ok, "Reloading the same limits should not refill the buckets")
+
+	rl.SetLimits(map[string]RateLimit{"ip": {RequestsPerSecond: 2, Burst: 4}})
+	ok, retryAfter := rl.AllowRequest(keys)
+	assert.False(t, ok, "Raised limits should not refill the buckets either")
+	assert.Equal(t, 500*time.Millisecond, retryAfter, "Buckets should refill at the new rate")
+
+	*now = now.Add(time.Second)
+	rl.SetLimits(map[string]RateLimit{"ip": {RequestsPerSecond: 1, Burst: 1}})
+	ok, _ = rl.AllowRequest(keys)
+	assert.True(t, ok, "Tokens earned at the old rate should be kept")
+	ok, _ = rl.AllowRequest(keys)
+	assert.False(t, ok, "Tokens should be capped at the new burst")
+}
+
+func TestRateLimiter_DisabledLimits(t *testing.T) {
+	rl, _ := generateTestRateLimiter(RateLimit{})
+	keys := map[string]string{"ip": "127.0.0.1", "tenant": "meow"}
+
+	for i := 0; i < 100; i++ {
+		ok, _ := rl.AllowRequest(keys)
+		assert.True(t, ok, "Zero limits should never limit requests")
+	}
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	rl, _ := generateTestRateLimiter(RateLimit{RequestsPerSecond: 1, Burst: 1})
+	n := negroni.New(rl)
+	n.UseHandler(testServer.HeartBeatHandler())
+
+	serve := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/heartbeat", strings.NewReader(""))
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+		req.RemoteAddr = "127.0.0.1:5000"
+		w := httptest.NewRecorder()
+		n.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code, "First request should pass through")
+
+	w = serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "Second request should be limited")
+	assert.Equal(t, "1", w.Header().Get("Retry-After"), "Retry-After header should be set")
+
+	errResp := new(model.ErrorResponse)
+	err := model.UnmarshalJSON(w.Body.Bytes(), errResp)
+	if err != nil {
+		panic("Error unmarshalling json response: " + err.Error())
+	}
+	assert.Equal(t, RateLimited, errResp.GetCode(), "Error code should be RATE_LIMITED")
+}
+
+func TestCreateEventHandler_EventQuotaExceeded(t *testing.T) {
+	s := newTestServer(t)
+	s.rateLimiter, _ = generateTestRateLimiter(RateLimit{EventsPerMinute: 1})
+
+	n := negroni.New(s.rateLimiter)
+	n.UseHandler(s.CreateEventHandler())
+
+	uploadReq := generateTestClientEventUploadRequest_Valid()
+	jsonReq, err := model.MarshalJSON(uploadReq)
+	if err != nil {
+		panic("Cannot marshal data. Error: " + err.Error())
+	}
+
+	post := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/v1/events", strings.NewReader(string(jsonReq)))
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+		req.Header.Set("Content-Type", APPLICATION_JSON)
+		req.RemoteAddr = "127.0.0.1:5000"
+		w := httptest.NewRecorder()
+		n.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post()
+	assert.Equal(t, http.StatusOK, w.Code, "Upload within the event quota should be posted")
+
+	s.store = NewMemoryStore()
+	w = post()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "Upload over the event quota should be limited")
+	assert.Equal(t, "60", w.Header().Get("Retry-After"), "Retry-After header should be set")
+	assert.Equal(t, 0, s.store.CountEvents(), "Store should be empty")
+}
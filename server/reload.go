@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const defaultConfigReloadPollInterval = 5 * time.Second
+
+//Settings that are only read at startup, changing them in the config file is logged but needs a restart
+var restartOnlyProperties = []string{
+	"appPort", "appGracefulShutdownTimeinSeconds", "jsonLenient",
+	"grpcPort", "grpcUploadCheckpointEvents", "grpcUploadCheckpointIntervalInMs", "grpcUploadOffsetFile",
+	"tlsEnabled", "tlsCertFile", "tlsKeyFile", "tlsClientCAFile", "tlsCertReloadIntervalInSeconds",
+	"logFormat", "logOutputs", "logFileName", "logMaxSizeInMB", "logRotateIntervalInHours", "logMaxBackups", "logCompress",
+	"configReloadPollIntervalInSeconds", "readinessDrainDelayInSeconds", "readinessMinFreeDiskInMB",
+	"shutdownFlushTimeoutInSeconds", "shutdownStoreTimeoutInSeconds", "shutdownWorkersTimeoutInSeconds",
+	"ingestionAsync", "ingestionQueueSize", "ingestionWorkers", "ingestionBatchSize", "ingestionRetryAfterInSeconds",
+	"ingestionQueueDir", "ingestionQueueSegmentSizeInMB", "ingestionQueueSync",
+	"webhookFile", "webhookWorkers", "webhookQueueSize", "webhookTimeoutInSeconds", "webhookMaxAttempts",
+	"webhookInitialBackoffInMs", "webhookMaxBackoffInSeconds", "webhookDeadLetterLimit",
+	"streamBufferSize", "streamReplaySize", "streamMaxSubscribers", "streamKeepAliveInSeconds", "streamWebSocketEnabled",
+	"sketchBucketSeconds", "sketchRetentionInHours", "sketchDistinctKeys", "sketchQuantileValues", "sketchHllPrecision", "sketchTdigestCompression",
+	"metricsDeviceTypes", "rollupDimensionKeys",
+}
+
+//Settings applied to the running server on a reload
+type reloadableConfig struct {
+	logLevel     log.Level
+	rateLimits   map[string]RateLimit
+	eventMetrics *EventMetrics
+	adminApiKey  string
+	grpcApiKey   string
+
+	adminCertSubjects []string
+	grpcCertSubjects  []string
+	rollupRetention   RollupSettings
+}
+
+//Builds every reloadable component from props so that an invalid config is rejected before anything is applied
+func loadReloadableConfig(props appProperties) (*reloadableConfig, error) {
+	config, err := LoadConfig(props)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := log.ParseLevel(config.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+	em, err := NewEventMetrics(config.EventMetricRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reloadableConfig{
+		logLevel:     level,
+		rateLimits:   config.RateLimits,
+		eventMetrics: em,
+		adminApiKey:  config.AdminApiKey,
+		grpcApiKey:   config.GRPCApiKey,
+
+		adminCertSubjects: config.AdminCertSubjects,
+		grpcCertSubjects:  config.GRPCCertSubjects,
+		rollupRetention:   config.Rollups,
+	}, nil
+}
+
+func (s *Server) applyReloadableConfig(config *reloadableConfig) {
+	s.logLevel.set(config.logLevel)
+	s.rateLimiter.SetLimits(config.rateLimits)
+	s.eventMetrics.Replace(config.eventMetrics)
+	s.adminApiKey.set(config.adminApiKey)
+	s.grpcApiKey.set(config.grpcApiKey)
+	s.adminCertSubjects.set(config.adminCertSubjects)
+	s.grpcCertSubjects.set(config.grpcCertSubjects)
+	s.rollups.SetRetention(config.rollupRetention)
+}
+
+//Reloads the config file when it changes on disk or on SIGHUP. A config that fails validation is logged and
+//the running config is kept.
+type ConfigReloader struct {
+	path         string
+	pollInterval time.Duration
+	logger       *log.Logger
+	overrides    []appProperties
+	apply        func(*reloadableConfig)
+
+	mutex   sync.Mutex
+	current appProperties
+	modTime time.Time
+
+	signals chan os.Signal
+	stop    chan struct{}
+}
+
+//Watches the file config was loaded from, the environment and flag overrides of config stay in place on reloads
+func newConfigReloader(config *Config, logger *log.Logger, apply func(*reloadableConfig)) *ConfigReloader {
+	pollInterval := time.Duration(config.ConfigReloadPollIntervalInSeconds) * time.Second
+	if pollInterval <= 0 {
+		pollInterval = defaultConfigReloadPollInterval
+	}
+	path := config.fileName
+	cr := &ConfigReloader{path: path, pollInterval: pollInterval, logger: logger, overrides: config.overrides, apply: apply, current: config.props}
+	if info, err := os.Stat(path); err == nil {
+		cr.modTime = info.ModTime()
+	}
+	return cr
+}
+
+func (cr *ConfigReloader) Start() {
+	cr.signals = make(chan os.Signal, 1)
+	cr.stop = make(chan struct{})
+	signal.Notify(cr.signals, syscall.SIGHUP)
+
+	go func() {
+		ticker := time.NewTicker(cr.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cr.signals:
+				cr.logger.WithFields(log.Fields{"method": "ConfigReloader", "file": cr.path}).Infoln("Received SIGHUP, reloading config")
+				cr.Reload()
+			case <-ticker.C:
+				if cr.changed() {
+					cr.logger.WithFields(log.Fields{"method": "ConfigReloader", "file": cr.path}).Infoln("Config file changed, reloading config")
+					cr.Reload()
+				}
+			case <-cr.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (cr *ConfigReloader) Stop() {
+	if cr.stop == nil {
+		return
+	}
+	signal.Stop(cr.signals)
+	close(cr.stop)
+	cr.stop = nil
+}
+
+func (cr *ConfigReloader) changed() bool {
+	info, err := os.Stat(cr.path)
+	if err != nil {
+		return false
+	}
+
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	return !info.ModTime().Equal(cr.modTime)
+}
+
+//Reads, validates and applies the config file
+func (cr *ConfigReloader) Reload() error {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	if info, err := os.Stat(cr.path); err == nil {
+		cr.modTime = info.ModTime()
+	}
+
+	file, err := readPropertiesFile(cr.path)
+	if err == nil {
+		var config *reloadableConfig
+		props := effectiveProperties(cr.overrides, file)
+		config, err = loadReloadableConfig(props)
+		if err == nil {
+			cr.warnRestartOnly(props)
+			cr.apply(config)
+			cr.current = props
+			cr.logger.WithFields(log.Fields{"method": "ConfigReloader", "file": cr.path}).Infoln("Config reloaded")
+			return nil
+		}
+	}
+
+	cr.logger.WithFields(log.Fields{"method": "ConfigReloader", "file": cr.path, "error": err.Error()}).Errorln("Invalid config, keeping the running config")
+	return err
+}
+
+func (cr *ConfigReloader) warnRestartOnly(props appProperties) {
+	for _, key := range restartOnlyProperties {
+		if cr.current.GetString(key) != props.GetString(key) {
+			cr.logger.WithFields(log.Fields{"method": "ConfigReloader", "property": key}).Warningln("Changed property needs a restart to take effect")
+		}
+	}
+}
+
+func readPropertiesFile(path string) (mapProperties, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]interface{})
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return newMapProperties(settings), nil
+}
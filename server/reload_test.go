@@ -0,0 +1,63 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestConfig(t *testing.T, path string, config string) {
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal("Error writing config: " + err.Error())
+	}
+}
+
+func TestConfigReloader_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(t)
+
+	path := filepath.Join(dir, "meowtricsConfig.json")
+	writeTestConfig(t, path, `{"appPort":"3003","logLevel":"info","rateLimitIpRequestsPerSecond":"1","adminApiKey":"first"}`)
+	props, err := readPropertiesFile(path)
+	assert.NoError(t, err, "Error reading config")
+	cr := newConfigReloader(&Config{props: props, fileName: path}, s.logger, s.applyReloadableConfig)
+
+	writeTestConfig(t, path, `{"appPort":"4004","logLevel":"debug","rateLimitIpRequestsPerSecond":"2","adminApiKey":"second",
+		"rawEventRetentionInHours":"48","rollupDayRetentionInDays":"90"}`)
+	assert.NoError(t, cr.Reload(), "Valid config should be reloaded")
+	assert.Equal(t, log.DebugLevel, s.logLevel.get(), "Log level should be applied")
+	assert.Equal(t, float64(2), s.rateLimiter.limits["ip"].RequestsPerSecond, "Rate limits should be applied")
+	assert.Equal(t, "second", s.adminApiKey.get(), "Admin API key should be applied")
+	_, keepsRaw := s.rollups.rawRetentionStart()
+	assert.True(t, keepsRaw, "Raw event retention should be applied")
+	assert.Equal(t, int64(90*86400), s.rollups.tiers[2].retention, "Rollup retention should be applied")
+
+	writeTestConfig(t, path, `{"logLevel":"verbose","adminApiKey":"third"}`)
+	assert.Error(t, cr.Reload(), "Unknown log level should be rejected")
+	writeTestConfig(t, path, `{"logLevel":"info","rateLimitIpBurst":"lots","adminApiKey":"third"}`)
+	assert.Error(t, cr.Reload(), "Invalid rate limit should be rejected")
+	writeTestConfig(t, path, `{"logLevel":"info",`)
+	assert.Error(t, cr.Reload(), "Malformed config should be rejected")
+
+	assert.Equal(t, log.DebugLevel, s.logLevel.get(), "Running config should be kept")
+	assert.Equal(t, "second", s.adminApiKey.get(), "Running config should be kept")
+}
+
+func TestMapProperties(t *testing.T) {
+	props := newMapProperties(map[string]interface{}{"appPort": "3003", "logCompress": "true", "rateLimitIpBurst": "2.5"})
+
+	assert.Equal(t, "3003", props.GetString("appport"), "Keys should be case insensitive")
+	assert.Equal(t, 3003, props.GetInt("appPort"), "String values should be converted")
+	assert.Equal(t, true, props.GetBool("logCompress"), "String values should be converted")
+	assert.Equal(t, 2.5, props.GetFloat64("rateLimitIpBurst"), "String values should be converted")
+	assert.False(t, props.IsSet("adminApiKey"), "Missing keys should not be set")
+}
@@ -0,0 +1,514 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"meowtrics/model"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+//Bucket width of rollup queries that don't set one, and the most buckets a query over a time range may ask for
+const (
+	defaultRollupBucketSeconds = 3600
+	maxRollupBuckets           = 10000
+)
+
+//Tier name of queries answered from the stored events
+const rawRollupTier = "raw"
+
+//Stored events are expired at most this often
+const rawEventExpiryInterval = 60
+
+//Retention of the raw events and of the minute, hour and day rollups, plus the kv pair keys the rollups are kept per
+type RollupSettings struct {
+	DimensionKeys          []string
+	RawRetentionInHours    int
+	MinuteRetentionInHours int
+	HourRetentionInDays    int
+	DayRetentionInDays     int
+}
+
+//Identifies the events of one event type, device type and combination of dimension values in one bucket of a tier
+type rollupKey struct {
+	start int64
+	group string
+}
+
+//Count, sum, min and max of the values of one measurement
+type rollupMeasurement struct {
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func (rm *rollupMeasurement) add(measurement *model.Measurement) {
+	histogram := measurement.GetHistogram()
+	if histogram == nil {
+		value := measurement.GetValue()
+		rm.merge(&rollupMeasurement{count: 1, sum: value, min: value, max: value})
+		return
+	}
+	other := &rollupMeasurement{sum: histogram.GetSum(), min: math.Inf(1), max: math.Inf(-1)}
+	for _, segment := range histogramSegments(histogram) {
+		other.count += uint64(segment.count)
+		other.min = math.Min(other.min, segment.low)
+		other.max = math.Max(other.max, segment.high)
+	}
+	if other.count > 0 {
+		rm.merge(other)
+	}
+}
+
+func (rm *rollupMeasurement) merge(other *rollupMeasurement) {
+	if rm.count == 0 || other.min < rm.min {
+		rm.min = other.min
+	}
+	if rm.count == 0 || other.max > rm.max {
+		rm.max = other.max
+	}
+	rm.count += other.count
+	rm.sum += other.sum
+}
+
+//Events and measurements aggregated in one rollup bucket. event holds the event type, device type and dimension kv
+//pairs the bucket is kept for, so that queries filter and group rollups like stored events.
+type rollupAggregate struct {
+	event        *model.ClientEventData
+	count        uint64
+	measurements map[measurementKey]*rollupMeasurement
+}
+
+func newRollupAggregate(event *model.ClientEventData) *rollupAggregate {
+	return &rollupAggregate{event: event, measurements: make(map[measurementKey]*rollupMeasurement)}
+}
+
+func (ra *rollupAggregate) add(event *model.ClientEventData) {
+	ra.count++
+	for _, measurement := range event.GetMeasurements() {
+		ra.measurement(newMeasurementKey(measurement)).add(measurement)
+	}
+}
+
+func (ra *rollupAggregate) merge(other *rollupAggregate) {
+	ra.count += other.count
+	for key, measurement := range other.measurements {
+		ra.measurement(key).merge(measurement)
+	}
+}
+
+func (ra *rollupAggregate) measurement(key measurementKey) *rollupMeasurement {
+	measurement, ok := ra.measurements[key]
+	if !ok {
+		measurement = new(rollupMeasurement)
+		ra.measurements[key] = measurement
+	}
+	return measurement
+}
+
+//One downsampling tier, buckets older than the retention are dropped and events older than it are not added
+type rollupTier struct {
+	name      string
+	seconds   int64
+	retention int64
+	buckets   map[rollupKey]*rollupAggregate
+	oldest    int64
+}
+
+func (rt *rollupTier) retentionStart(now int64) int64 {
+	return bucketStart(now-rt.retention, rt.seconds)
+}
+
+func (rt *rollupTier) prune(retentionStart int64) {
+	for key := range rt.buckets {
+		if key.start < retentionStart {
+			delete(rt.buckets, key)
+		}
+	}
+	rt.oldest = retentionStart
+}
+
+/*
+Minute, hour and day aggregates of the stored events per event type, device type and value of the configured dimension
+kv pair keys, kept up to date on ingest. Queries are answered from the coarsest tier that can, so that dashboards over
+months don't scan the raw events.
+*/
+type Rollups struct {
+	settings   RollupSettings
+	dimensions map[string]bool
+	now        func() time.Time
+
+	mutex        sync.Mutex
+	tiers        []*rollupTier
+	rawRetention int64
+	lastExpiry   int64
+}
+
+func NewRollups(settings RollupSettings) *Rollups {
+	r := &Rollups{settings: settings, dimensions: make(map[string]bool), now: time.Now}
+	for _, key := range settings.DimensionKeys {
+		r.dimensions[key] = true
+	}
+	//Finest first
+	r.tiers = []*rollupTier{{name: "minute", seconds: 60}, {name: "hour", seconds: 3600}, {name: "day", seconds: 86400}}
+	for _, tier := range r.tiers {
+		tier.buckets = make(map[rollupKey]*rollupAggregate)
+	}
+	r.SetRetention(settings)
+	return r
+}
+
+//Applies the retention of the raw events and the tiers from settings, the dimension keys are kept. Buckets past a
+//shorter retention are dropped with the next event, a longer one keeps the buckets from now on.
+func (r *Rollups) SetRetention(settings RollupSettings) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.tiers[0].retention = int64(settings.MinuteRetentionInHours) * 3600
+	r.tiers[1].retention = int64(settings.HourRetentionInDays) * 86400
+	r.tiers[2].retention = int64(settings.DayRetentionInDays) * 86400
+	r.rawRetention = int64(settings.RawRetentionInHours) * 3600
+}
+
+//The event type, device type and dimension kv pairs of event, and the key of its group
+func (r *Rollups) rollupEvent(event *model.ClientEventData) (*model.ClientEventData, string) {
+	rollupEvent := &model.ClientEventData{EventType: event.GetEventType(), DeviceType: event.GetDeviceType()}
+	group := []string{event.GetEventType().String(), event.GetDeviceType()}
+	for _, key := range r.settings.DimensionKeys {
+		value, ok := eventAttribute(event, key)
+		if !ok {
+			group = append(group, "")
+			continue
+		}
+		//The string form is kept, it equals typed values given as strings and is ordered against typed numbers
+		rollupEvent.KvPair = append(rollupEvent.KvPair, &model.KeyValuePair{Key: key, Value: proto.String(attributeString(value))})
+		group = append(group, "="+attributeString(value))
+	}
+	return rollupEvent, strings.Join(group, labelSeparator)
+}
+
+func (r *Rollups) Observe(event *model.ClientEventData) {
+	rollupEvent, group := r.rollupEvent(event)
+	now := r.now().Unix()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, tier := range r.tiers {
+		retentionStart := tier.retentionStart(now)
+		if retentionStart > tier.oldest {
+			tier.prune(retentionStart)
+		}
+		key := rollupKey{start: bucketStart(event.GetTimestamp(), tier.seconds), group: group}
+		if key.start < retentionStart {
+			continue
+		}
+		aggregate, ok := tier.buckets[key]
+		if !ok {
+			aggregate = newRollupAggregate(rollupEvent)
+			tier.buckets[key] = aggregate
+		}
+		aggregate.add(event)
+	}
+}
+
+//Start of the raw event retention when the stored events are due to be expired, at most once a minute
+func (r *Rollups) rawExpiryDue() (int64, bool) {
+	now := r.now().Unix()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.rawRetention == 0 || now-r.lastExpiry < rawEventExpiryInterval {
+		return 0, false
+	}
+	r.lastExpiry = now
+	return now - r.rawRetention, true
+}
+
+//Oldest timestamp the stored events are kept for, false when they are kept forever
+func (r *Rollups) rawRetentionStart() (int64, bool) {
+	now := r.now().Unix()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return now - r.rawRetention, r.rawRetention > 0
+}
+
+//Coarsest tier whose buckets line up with the query and that keeps every kv pair key it reads, nil when only the
+//stored events can answer it. Tiers are only picked when they still hold the start of the time range.
+func (r *Rollups) tierFor(query *model.RollupQueryRequest, bucketSeconds int64) *rollupTier {
+	for _, key := range rollupQueryKeys(query) {
+		if !r.dimensions[key] {
+			return nil
+		}
+	}
+	now := r.now().Unix()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := len(r.tiers) - 1; i >= 0; i-- {
+		tier := r.tiers[i]
+		if bucketSeconds%tier.seconds != 0 {
+			continue
+		}
+		if query.FromTimestamp != nil && (query.GetFromTimestamp()%tier.seconds != 0 || query.GetFromTimestamp() < tier.retentionStart(now)) {
+			continue
+		}
+		if query.ToTimestamp != nil && query.GetToTimestamp()%tier.seconds != 0 {
+			continue
+		}
+		return tier
+	}
+	return nil
+}
+
+//The kv pair keys a query filters or groups by
+func rollupQueryKeys(query *model.RollupQueryRequest) []string {
+	var keys []string
+	for _, pair := range query.GetFilter().GetKvPairs() {
+		keys = append(keys, pair.GetKey())
+	}
+	for _, condition := range query.GetFilter().GetKvConditions() {
+		keys = append(keys, condition.GetKey())
+	}
+	for _, dimension := range query.GetGroupBy() {
+		if strings.HasPrefix(dimension, KV_PAIR_PREFIX) {
+			keys = append(keys, strings.TrimPrefix(dimension, KV_PAIR_PREFIX))
+		}
+	}
+	return keys
+}
+
+//Series of measurement queries are split by the unit and kind of the measurement, like measurement queries
+type rollupSeries struct {
+	group       []string
+	measurement measurementKey
+	buckets     map[int64]*rollupAggregate
+}
+
+//Aggregates of the query results per group and bucket
+type rollupResult struct {
+	query         *model.RollupQueryRequest
+	bucketSeconds int64
+	series        map[string]*rollupSeries
+}
+
+//Adds the aggregate of event, a stored event or the one of a rollup bucket, to the bucket holding timestamp
+func (rr *rollupResult) add(event *model.ClientEventData, timestamp int64, aggregate *rollupAggregate) {
+	group := make([]string, len(rr.query.GetGroupBy()))
+	for i, dimension := range rr.query.GetGroupBy() {
+		group[i] = eventLabelValue(event, event.GetDeviceType(), dimension)
+	}
+
+	if rr.query.GetMeasurement() == "" {
+		rr.seriesBucket(group, measurementKey{}, timestamp).merge(aggregate)
+		return
+	}
+	for key, measurement := range aggregate.measurements {
+		if key.name == rr.query.GetMeasurement() {
+			rr.seriesBucket(group, key, timestamp).measurement(key).merge(measurement)
+		}
+	}
+}
+
+func (rr *rollupResult) seriesBucket(group []string, measurement measurementKey, timestamp int64) *rollupAggregate {
+	key := strings.Join(append(append([]string(nil), group...), measurement.unit, measurement.kind.String()), labelSeparator)
+	series, ok := rr.series[key]
+	if !ok {
+		series = &rollupSeries{group: group, measurement: measurement, buckets: make(map[int64]*rollupAggregate)}
+		rr.series[key] = series
+	}
+
+	start := bucketStart(timestamp, rr.bucketSeconds)
+	bucket, ok := series.buckets[start]
+	if !ok {
+		bucket = newRollupAggregate(nil)
+		series.buckets[start] = bucket
+	}
+	return bucket
+}
+
+func (rr *rollupResult) bucket(start int64, series *rollupSeries, aggregate *rollupAggregate) *model.RollupBucket {
+	if rr.query.GetMeasurement() == "" {
+		return &model.RollupBucket{StartTimestamp: start, Count: aggregate.count}
+	}
+	measurement := aggregate.measurements[series.measurement]
+	return &model.RollupBucket{StartTimestamp: start, Count: measurement.count, Sum: measurement.sum, Min: measurement.min,
+		Max: measurement.max, Avg: measurement.sum / float64(measurement.count)}
+}
+
+func (rr *rollupResult) response(tier string) *model.RollupQueryResponse {
+	resp := &model.RollupQueryResponse{Tier: tier, BucketSeconds: rr.bucketSeconds}
+	keys := make([]string, 0, len(rr.series))
+	for key := range rr.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := rr.series[key]
+		respSeries := &model.RollupSeries{Unit: series.measurement.unit, Kind: series.measurement.kind}
+		for i, dimension := range rr.query.GetGroupBy() {
+			respSeries.Group = append(respSeries.Group, &model.KeyValuePair{Key: strings.TrimPrefix(dimension, KV_PAIR_PREFIX), Value: proto.String(series.group[i])})
+		}
+
+		starts := make([]int64, 0, len(series.buckets))
+		for start := range series.buckets {
+			starts = append(starts, start)
+		}
+		sortInt64s(starts)
+		for _, start := range starts {
+			respSeries.Buckets = append(respSeries.Buckets, rr.bucket(start, series, series.buckets[start]))
+		}
+		resp.Series = append(resp.Series, respSeries)
+	}
+	return resp
+}
+
+func (rr *rollupResult) inRange(timestamp int64) bool {
+	if rr.query.FromTimestamp != nil && timestamp < rr.query.GetFromTimestamp() {
+		return false
+	}
+	return rr.query.ToTimestamp == nil || timestamp < rr.query.GetToTimestamp()
+}
+
+//Reads the buckets of tier matching the query
+func (r *Rollups) query(tier *rollupTier, result *rollupResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for key, aggregate := range tier.buckets {
+		if result.inRange(key.start) && eventFilterMatches(result.query.GetFilter(), aggregate.event, aggregate.event.GetDeviceType()) {
+			result.add(aggregate.event, key.start, aggregate)
+		}
+	}
+}
+
+/*
+Event counts or measurement aggregates per time bucket and group, from the coarsest rollup tier that can answer the
+query or else from the stored events. Bucket starts are multiples of bucket_seconds.
+*/
+func (s *Server) queryRollups(query *model.RollupQueryRequest) (*model.RollupQueryResponse, *model.ErrorResponse) {
+	invalid := func(description string) *model.ErrorResponse {
+		return &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid rollup query", Description: description}
+	}
+
+	bucketSeconds := query.GetBucketSeconds()
+	switch {
+	case bucketSeconds < 0:
+		return nil, invalid("bucket_seconds must be positive")
+	case bucketSeconds == 0:
+		bucketSeconds = defaultRollupBucketSeconds
+	}
+	if query.FromTimestamp != nil && query.ToTimestamp != nil {
+		if query.GetFromTimestamp() >= query.GetToTimestamp() {
+			return nil, invalid("from_timestamp must be before to_timestamp")
+		}
+		if (query.GetToTimestamp()-query.GetFromTimestamp())/bucketSeconds > maxRollupBuckets {
+			return nil, invalid("at most " + strconv.Itoa(maxRollupBuckets) + " buckets can be queried, use larger buckets")
+		}
+	}
+	for _, dimension := range query.GetGroupBy() {
+		if dimension != "event_type" && dimension != "device_type" && (!strings.HasPrefix(dimension, KV_PAIR_PREFIX) || dimension == KV_PAIR_PREFIX) {
+			return nil, invalid("group_by must be event_type, device_type or kv:<key>, got " + dimension)
+		}
+	}
+
+	result := &rollupResult{query: query, bucketSeconds: bucketSeconds, series: make(map[string]*rollupSeries)}
+	if tier := s.rollups.tierFor(query, bucketSeconds); tier != nil {
+		s.rollups.query(tier, result)
+		return result.response(tier.name), nil
+	}
+
+	if retentionStart, ok := s.rollups.rawRetentionStart(); ok && query.FromTimestamp != nil && query.GetFromTimestamp() < retentionStart {
+		return nil, invalid("from_timestamp is older than the retention of the raw events and no rollup tier can answer the query, " +
+			"align the time range and bucket_seconds to minutes, hours or days and only use the kv pair keys of rollupDimensionKeys")
+	}
+	for _, event := range s.store.AllEvents() {
+		if result.inRange(event.GetTimestamp()) && eventFilterMatches(query.GetFilter(), event, event.GetDeviceType()) {
+			aggregate := newRollupAggregate(event)
+			aggregate.add(event)
+			result.add(event, event.GetTimestamp(), aggregate)
+		}
+	}
+	return result.response(rawRollupTier), nil
+}
+
+//Adds events stored without going through ingest to the rollups and sketches, the events of a persistent store on
+//start and imported ones
+func (s *Server) observeStoredEvents(events []*model.ClientEventData) {
+	for _, event := range events {
+		s.sketches.Observe(event)
+		s.rollups.Observe(event)
+	}
+}
+
+//Removes the stored events older than the raw event retention when they are due to be expired
+func (s *Server) expireRawEvents() {
+	before, due := s.rollups.rawExpiryDue()
+	if !due {
+		return
+	}
+	expirer, ok := s.store.(StoreExpirer)
+	if !ok {
+		return
+	}
+	if removed := expirer.RemoveEventsBefore(before); removed > 0 {
+		s.logger.WithFields(log.Fields{"method": "expireRawEvents", "removed": removed, "before": before}).Infoln("Expired raw events")
+	}
+}
+
+//Reads a rollup query from the from, to, bucket_seconds, group_by and measurement query parameters plus the event
+//filter ones
+func parseRollupQuery(query url.Values) (*model.RollupQueryRequest, error) {
+	filter, err := parseEventFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	rollupQuery := &model.RollupQueryRequest{Filter: filter, GroupBy: query["group_by"], Measurement: query.Get("measurement")}
+
+	if rollupQuery.FromTimestamp, err = timestampParameter(query, "from"); err != nil {
+		return nil, err
+	}
+	if rollupQuery.ToTimestamp, err = timestampParameter(query, "to"); err != nil {
+		return nil, err
+	}
+	if value := query.Get("bucket_seconds"); value != "" {
+		rollupQuery.BucketSeconds, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("bucket_seconds must be a number, got " + value)
+		}
+	}
+	return rollupQuery, nil
+}
+
+/*
+Event counts, or aggregates of a measurement, per time bucket from the rollup tiers, e.g.
+GET /v1/rollups?event_type=USER_REGISTERED&group_by=device_type&bucket_seconds=86400
+*/
+func (s *Server) RollupsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := s.RequestLogger(req).WithFields(log.Fields{"method": "RollupsHandler"})
+
+		query, err := parseRollupQuery(req.URL.Query())
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err.Error()}).Infoln("Invalid rollup query")
+			writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid rollup query", Description: err.Error()})
+			return
+		}
+		resp, errResp := s.queryRollups(query)
+		if errResp != nil {
+			logger.WithFields(log.Fields{"error": errResp.GetDescription()}).Infoln("Invalid rollup query")
+			writeJSON(w, http.StatusBadRequest, errResp)
+			return
+		}
+		logger.WithFields(log.Fields{"tier": resp.GetTier()}).Debugln("Rollup query answered")
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
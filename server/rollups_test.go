@@ -0,0 +1,298 @@
+package server
+
+import (
+	"bytes"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+//Day 10 since the epoch, rollup test events are uploaded in the hours before it
+const rollupTestNow = 10 * 86400
+
+func generateTestRollupSettings() RollupSettings {
+	return RollupSettings{DimensionKeys: []string{"country"}, MinuteRetentionInHours: 2, HourRetentionInDays: 2, DayRetentionInDays: 30}
+}
+
+func generateTestRollupEvent(id string, eventType model.ClientEventType, timestamp int64, country string, appStart float64) *model.ClientEventData {
+	return &model.ClientEventData{
+		EventId:      id,
+		EventType:    eventType,
+		Timestamp:    proto.Int64(timestamp),
+		KvPair:       []*model.KeyValuePair{{Key: "country", Value: proto.String(country)}, {Key: "user_id", Value: proto.String("user" + id)}},
+		Measurements: []*model.Measurement{{Name: "app_start", Value: appStart}},
+	}
+}
+
+//Server with rollups kept per country, holding events uploaded from android in the three hours before rollupTestNow
+func newTestRollupServer(t *testing.T, settings RollupSettings) *Server {
+	s := newTestServer(t, WithStore(NewMemoryStore()))
+	s.config.Rollups = settings
+	s.rollups = NewRollups(settings)
+	s.rollups.now = func() time.Time { return time.Unix(rollupTestNow, 0) }
+
+	uploadRequest := &model.ClientEventUploadRequest{RequestId: "rollups", DeviceType: "android"}
+	for i := 0; i < 30; i++ {
+		country := "de"
+		if i%3 == 0 {
+			country = "fr"
+		}
+		timestamp := int64(rollupTestNow - 3*3600 + i*360)
+		uploadRequest.Events = append(uploadRequest.Events, generateTestRollupEvent(strconv.Itoa(i), model.ClientEventType_UNKNOWN, timestamp, country, float64(i)))
+	}
+	if err := s.storeUploadRequest(uploadRequest); err != nil {
+		t.Fatal("Error storing events: " + err.Error())
+	}
+	return s
+}
+
+func TestRollups_Observe(t *testing.T) {
+	r := NewRollups(generateTestRollupSettings())
+	now := time.Unix(rollupTestNow, 0)
+	r.now = func() time.Time { return now }
+
+	r.Observe(generateTestRollupEvent("1", model.ClientEventType_UNKNOWN, rollupTestNow-30, "de", 1))
+	r.Observe(generateTestRollupEvent("2", model.ClientEventType_UNKNOWN, rollupTestNow-20, "de", 2))
+	r.Observe(generateTestRollupEvent("3", model.ClientEventType_UNKNOWN, rollupTestNow-10, "fr", 3))
+	for _, tier := range r.tiers {
+		assert.Equal(t, 2, len(tier.buckets), "Events should be rolled up per country in the "+tier.name+" tier")
+	}
+
+	r.Observe(generateTestRollupEvent("4", model.ClientEventType_UNKNOWN, rollupTestNow-5*3600, "de", 4))
+	assert.Equal(t, 2, len(r.tiers[0].buckets), "Events older than the retention of a tier should not be added to it")
+	assert.Equal(t, 3, len(r.tiers[1].buckets))
+
+	now = now.Add(3 * time.Hour)
+	r.Observe(generateTestRollupEvent("5", model.ClientEventType_UNKNOWN, now.Unix(), "de", 5))
+	assert.Equal(t, 1, len(r.tiers[0].buckets), "Buckets older than the retention of a tier should be dropped")
+	assert.Equal(t, 4, len(r.tiers[1].buckets))
+}
+
+func TestRollupMeasurement_Add(t *testing.T) {
+	rm := new(rollupMeasurement)
+	rm.add(&model.Measurement{Name: "frame_time", Value: 12})
+	rm.add(&model.Measurement{Name: "frame_time", Histogram: &model.Histogram{UpperBounds: []float64{10, 20}, BucketCounts: []uint64{2, 0, 1}, Sum: 40, Min: proto.Float64(5), Max: proto.Float64(25)}})
+	assert.Equal(t, uint64(4), rm.count)
+	assert.Equal(t, float64(52), rm.sum)
+	assert.Equal(t, float64(5), rm.min, "Histograms should add their min")
+	assert.Equal(t, float64(25), rm.max)
+}
+
+func TestQueryRollups_Tiers(t *testing.T) {
+	s := newTestRollupServer(t, generateTestRollupSettings())
+
+	tiers := []struct {
+		query *model.RollupQueryRequest
+		tier  string
+	}{
+		{&model.RollupQueryRequest{BucketSeconds: 86400}, "day"},
+		{&model.RollupQueryRequest{}, "hour"},
+		{&model.RollupQueryRequest{BucketSeconds: 600, FromTimestamp: proto.Int64(rollupTestNow - 3600)}, "minute"},
+		{&model.RollupQueryRequest{BucketSeconds: 600, FromTimestamp: proto.Int64(rollupTestNow - 3*3600)}, rawRollupTier},
+		{&model.RollupQueryRequest{BucketSeconds: 3600, FromTimestamp: proto.Int64(rollupTestNow - 3600 + 60)}, "minute"},
+		{&model.RollupQueryRequest{BucketSeconds: 90}, rawRollupTier},
+		{&model.RollupQueryRequest{GroupBy: []string{"kv:user_id"}}, rawRollupTier},
+		{&model.RollupQueryRequest{Filter: &model.EventFilter{KvPairs: []*model.KeyValuePair{{Key: "user_id", Value: proto.String("user1")}}}}, rawRollupTier},
+	}
+	for _, test := range tiers {
+		resp, errResp := s.queryRollups(test.query)
+		assert.Nil(t, errResp, "Valid query should be accepted: "+test.query.String())
+		assert.Equal(t, test.tier, resp.GetTier(), "Coarsest tier that can answer should be picked: "+test.query.String())
+	}
+}
+
+func TestQueryRollups_MatchesRawEvents(t *testing.T) {
+	s := newTestRollupServer(t, generateTestRollupSettings())
+
+	queries := []*model.RollupQueryRequest{
+		{BucketSeconds: 3600, GroupBy: []string{"kv:country", "event_type"}},
+		{BucketSeconds: 86400, Measurement: "app_start", GroupBy: []string{"device_type"}},
+		{BucketSeconds: 1800, FromTimestamp: proto.Int64(rollupTestNow - 3600), Filter: &model.EventFilter{KvPairs: []*model.KeyValuePair{{Key: "country", Value: proto.String("fr")}}}},
+		{BucketSeconds: 3600, Measurement: "app_start", Filter: &model.EventFilter{KvConditions: []*model.KeyValueCondition{{Key: "country", Comparison: model.Comparison_COMPARISON_NOT_EQUAL, Value: stringAttribute("fr")}}}},
+	}
+	for _, query := range queries {
+		resp, errResp := s.queryRollups(query)
+		assert.Nil(t, errResp, "Valid query should be accepted: "+query.String())
+		assert.NotEqual(t, rawRollupTier, resp.GetTier(), "Query should be answered from a rollup tier: "+query.String())
+
+		//Bucket widths that are no multiple of a minute are always answered from the raw events
+		raw := proto.Clone(query).(*model.RollupQueryRequest)
+		raw.BucketSeconds = query.GetBucketSeconds() + 1
+		rawResp, errResp := s.queryRollups(raw)
+		assert.Nil(t, errResp)
+		assert.Equal(t, rawRollupTier, rawResp.GetTier())
+		if assert.Equal(t, len(rawResp.GetSeries()), len(resp.GetSeries()), "Rollups and raw events should have the same groups: "+query.String()) {
+			for i, series := range resp.GetSeries() {
+				if len(series.GetGroup()) > 0 {
+					assert.Equal(t, rawResp.GetSeries()[i].GetGroup()[0].GetValue(), series.GetGroup()[0].GetValue())
+				}
+				var count, rawCount uint64
+				var sum, rawSum float64
+				for _, bucket := range series.GetBuckets() {
+					count += bucket.GetCount()
+					sum += bucket.GetSum()
+				}
+				for _, bucket := range rawResp.GetSeries()[i].GetBuckets() {
+					rawCount += bucket.GetCount()
+					rawSum += bucket.GetSum()
+				}
+				assert.Equal(t, rawCount, count, "Rollups and raw events should count the same events: "+query.String())
+				assert.Equal(t, rawSum, sum)
+			}
+		}
+	}
+
+	resp, _ := s.queryRollups(&model.RollupQueryRequest{BucketSeconds: 86400, Measurement: "app_start", GroupBy: []string{"kv:country"}})
+	if assert.Equal(t, 2, len(resp.GetSeries())) {
+		de := resp.GetSeries()[0].GetBuckets()[0]
+		assert.Equal(t, "de", resp.GetSeries()[0].GetGroup()[0].GetValue())
+		assert.Equal(t, "country", resp.GetSeries()[0].GetGroup()[0].GetKey())
+		assert.Equal(t, int64(9*86400), de.GetStartTimestamp())
+		assert.Equal(t, uint64(20), de.GetCount())
+		assert.Equal(t, float64(1), de.GetMin())
+		assert.Equal(t, float64(29), de.GetMax())
+		assert.Equal(t, float64(15), de.GetAvg())
+	}
+}
+
+func TestRollups_DuplicateEventIds(t *testing.T) {
+	s := newTestRollupServer(t, generateTestRollupSettings())
+	for i := 0; i < 2; i++ {
+		uploadRequest := &model.ClientEventUploadRequest{RequestId: "retry", DeviceType: "android",
+			Events: []*model.ClientEventData{generateTestRollupEvent("0", model.ClientEventType_UNKNOWN, rollupTestNow-3*3600, "fr", 0)}}
+		assert.NoError(t, s.storeUploadRequest(uploadRequest), "Error storing events")
+	}
+	assert.Equal(t, 30, s.store.CountEvents())
+
+	for _, bucketSeconds := range []int64{86400, 86401} {
+		resp, errResp := s.queryRollups(&model.RollupQueryRequest{BucketSeconds: bucketSeconds})
+		assert.Nil(t, errResp)
+		var count uint64
+		for _, series := range resp.GetSeries() {
+			for _, bucket := range series.GetBuckets() {
+				count += bucket.GetCount()
+			}
+		}
+		assert.Equal(t, uint64(30), count, "Events stored again under the same event id should only count once in the "+resp.GetTier()+" tier")
+	}
+	assert.Equal(t, float64(30), s.metrics.eventsIngestedTotal.Value("UNKNOWN", "android"))
+}
+
+func TestQueryRollups_MixedUnits(t *testing.T) {
+	s := newTestRollupServer(t, generateTestRollupSettings())
+	seconds := generateTestRollupEvent("seconds", model.ClientEventType_UNKNOWN, rollupTestNow-3600, "de", 2)
+	seconds.Measurements[0].Unit = "s"
+	seconds.Measurements[0].Kind = model.MeasurementKind_MEASUREMENT_KIND_TIMING
+	uploadRequest := &model.ClientEventUploadRequest{RequestId: "units", DeviceType: "android", Events: []*model.ClientEventData{seconds}}
+	assert.NoError(t, s.storeUploadRequest(uploadRequest), "Error storing events")
+
+	for _, bucketSeconds := range []int64{86400, 86401} {
+		resp, errResp := s.queryRollups(&model.RollupQueryRequest{BucketSeconds: bucketSeconds, Measurement: "app_start"})
+		assert.Nil(t, errResp)
+		if assert.Equal(t, 2, len(resp.GetSeries()), "Units and kinds should not be aggregated together in the "+resp.GetTier()+" tier") {
+			assert.Equal(t, "s", resp.GetSeries()[0].GetUnit())
+			assert.Equal(t, model.MeasurementKind_MEASUREMENT_KIND_TIMING, resp.GetSeries()[0].GetKind())
+			assert.Equal(t, float64(2), resp.GetSeries()[0].GetBuckets()[0].GetSum())
+			assert.Equal(t, "", resp.GetSeries()[1].GetUnit())
+			assert.Equal(t, uint64(30), resp.GetSeries()[1].GetBuckets()[0].GetCount())
+		}
+	}
+}
+
+func TestQueryRollups_Invalid(t *testing.T) {
+	s := newTestRollupServer(t, generateTestRollupSettings())
+	from, to := int64(7200), int64(3600)
+	invalid := []*model.RollupQueryRequest{
+		{BucketSeconds: -1},
+		{GroupBy: []string{"country"}},
+		{FromTimestamp: &from, ToTimestamp: &to},
+		{FromTimestamp: proto.Int64(0), ToTimestamp: proto.Int64((maxRollupBuckets + 1) * 3600)},
+	}
+	for _, query := range invalid {
+		_, errResp := s.queryRollups(query)
+		assert.NotNil(t, errResp, "Invalid query should be rejected: "+query.String())
+	}
+}
+
+func TestRawEventRetention(t *testing.T) {
+	settings := generateTestRollupSettings()
+	settings.RawRetentionInHours = 2
+	s := newTestRollupServer(t, settings)
+	assert.Equal(t, 20, s.store.CountEvents(), "Events older than the raw retention should be removed on ingest")
+
+	_, errResp := s.queryRollups(&model.RollupQueryRequest{BucketSeconds: 90, FromTimestamp: proto.Int64(rollupTestNow - 3*3600)})
+	assert.NotNil(t, errResp, "Queries only the expired raw events can answer should be rejected")
+	resp, errResp := s.queryRollups(&model.RollupQueryRequest{BucketSeconds: 3600, FromTimestamp: proto.Int64(rollupTestNow - 3*3600)})
+	assert.Nil(t, errResp, "Rollups should outlive the raw events")
+	assert.Equal(t, uint64(10), resp.GetSeries()[0].GetBuckets()[0].GetCount())
+}
+
+func TestRollupsHandler(t *testing.T) {
+	s := newTestRollupServer(t, generateTestRollupSettings())
+
+	query := url.Values{"group_by": {"kv:country"}, "bucket_seconds": {"86400"}, "kv_pair": {"country:fr"}, "measurement": {"app_start"}}
+	req, _ := http.NewRequest("GET", "/v1/rollups?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := new(model.RollupQueryResponse)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), resp), "Response should be a RollupQueryResponse")
+	assert.Equal(t, "day", resp.GetTier())
+	if assert.Equal(t, 1, len(resp.GetSeries())) {
+		assert.Equal(t, "fr", resp.GetSeries()[0].GetGroup()[0].GetValue())
+		assert.Equal(t, uint64(10), resp.GetSeries()[0].GetBuckets()[0].GetCount())
+		assert.Equal(t, float64(135), resp.GetSeries()[0].GetBuckets()[0].GetSum())
+	}
+
+	req, _ = http.NewRequest("GET", "/v1/rollups?bucket_seconds=daily", nil)
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid parameters should be rejected")
+}
+
+//Events stored before the server started and imported ones don't go through ingest but should still be rolled up
+func TestRollups_StoredEvents(t *testing.T) {
+	now := time.Now().Unix()
+	store := NewMemoryStore()
+	store.StoreEvent(generateTestRollupEvent("1", model.ClientEventType_UNKNOWN, now-120, "de", 1))
+	store.StoreEvent(generateTestRollupEvent("2", model.ClientEventType_UNKNOWN, now-60, "fr", 2))
+	s := newTestServer(t, WithStore(store))
+
+	countEvents := func() uint64 {
+		resp, errResp := s.queryRollups(&model.RollupQueryRequest{BucketSeconds: 86400})
+		assert.Nil(t, errResp)
+		assert.Equal(t, "day", resp.GetTier())
+		var count uint64
+		for _, series := range resp.GetSeries() {
+			for _, bucket := range series.GetBuckets() {
+				count += bucket.GetCount()
+			}
+		}
+		return count
+	}
+	assert.Equal(t, uint64(2), countEvents(), "Stored events should be rolled up on start")
+	assert.Equal(t, uint64(2), s.sketches.DistinctCount(sketchQuery{name: "user_id"}).GetTotalEstimate(), "Stored events should be sketched on start")
+
+	var body []byte
+	for _, event := range []*model.ClientEventData{
+		generateTestRollupEvent("2", model.ClientEventType_UNKNOWN, now-60, "fr", 2),
+		generateTestRollupEvent("3", model.ClientEventType_UNKNOWN, now-30, "fr", 3),
+	} {
+		data, _ := model.MarshalJSON(event)
+		body = append(append(body, data...), '\n')
+	}
+	req, _ := http.NewRequest("POST", "/admin/events", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	s.ImportEventsHandler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint64(3), countEvents(), "Imported events should be rolled up, replaced ones only once")
+	assert.Equal(t, uint64(3), s.sketches.DistinctCount(sketchQuery{name: "user_id"}).GetTotalEstimate())
+}
@@ -0,0 +1,347 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"meowtrics/model"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	segmentFileSuffix  = ".seg"
+	commitFileName     = "commit"
+	deadLetterFileName = "dead-letters"
+	//Payload length, CRC-32 of the payload and sequence number
+	segmentRecordHeaderSize = 16
+	//Longest payload of a record, a header claiming more is corrupt rather than a reason to allocate that much
+	maxSegmentRecordSize = 64 * 1024 * 1024
+)
+
+var (
+	CorruptSegmentError        = errors.New("Corrupt ingestion queue segment")
+	SegmentRecordTooLargeError = errors.New("Upload request is too large for the ingestion queue")
+)
+
+//An upload request read back from or written to the segment log
+type queuedUpload struct {
+	seq           uint64
+	uploadRequest *model.ClientEventUploadRequest
+}
+
+/*
+Append only log of queued upload requests. Records are appended to segment files named after the first sequence number
+they hold, and the commit file keeps the sequence number up to which every upload is stored. Segments that only hold
+stored uploads are removed, the rest is replayed when the log is opened again.
+*/
+type segmentLog struct {
+	dir         string
+	segmentSize int64
+	sync        bool
+
+	mutex      sync.Mutex
+	segments   []uint64
+	active     *os.File
+	activeSize int64
+	nextSeq    uint64
+	committed  uint64
+	acked      map[uint64]bool
+}
+
+func segmentFileName(firstSeq uint64) string {
+	return fmt.Sprintf("%020d%s", firstSeq, segmentFileSuffix)
+}
+
+//Opens or creates the log in dir and returns the uploads that were never acknowledged, oldest first
+func openSegmentLog(dir string, segmentSize int64, sync bool) (*segmentLog, []queuedUpload, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	l := &segmentLog{dir: dir, segmentSize: segmentSize, sync: sync, acked: make(map[uint64]bool)}
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, commitFileName)); err == nil {
+		if l.committed, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err != nil {
+			return nil, nil, errors.New("Invalid ingestion queue commit file: " + err.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	l.nextSeq = l.committed + 1
+
+	names, err := filepath.Glob(filepath.Join(dir, "*"+segmentFileSuffix))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range names {
+		firstSeq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), segmentFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		l.segments = append(l.segments, firstSeq)
+	}
+	sort.Sort(uint64Slice(l.segments))
+
+	var pending []queuedUpload
+	for i, firstSeq := range l.segments {
+		last := i == len(l.segments)-1
+		uploads, err := l.readSegment(firstSeq, last)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, upload := range uploads {
+			if upload.seq > l.committed {
+				pending = append(pending, upload)
+			}
+			if upload.seq >= l.nextSeq {
+				l.nextSeq = upload.seq + 1
+			}
+		}
+	}
+
+	if len(l.segments) == 0 {
+		err = l.createSegment(l.nextSeq)
+	} else {
+		err = l.openActiveSegment()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := l.removeStoredSegments(); err != nil {
+		return nil, nil, err
+	}
+	return l, pending, nil
+}
+
+//Reads every record of a segment. A torn record at the end of the last segment is what a crash during an append
+//leaves behind, it is cut off. Anywhere else it means the segment is corrupt.
+func (l *segmentLog) readSegment(firstSeq uint64, last bool) ([]queuedUpload, error) {
+	path := filepath.Join(l.dir, segmentFileName(firstSeq))
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var uploads []queuedUpload
+	var offset int64
+	reader := bufio.NewReader(file)
+	for {
+		upload, size, err := readSegmentRecord(reader)
+		if err == io.EOF {
+			return uploads, nil
+		}
+		if err != nil {
+			if !last {
+				return nil, CorruptSegmentError
+			}
+			return uploads, os.Truncate(path, offset)
+		}
+		uploads = append(uploads, upload)
+		offset += size
+	}
+}
+
+func readSegmentRecord(r io.Reader) (queuedUpload, int64, error) {
+	header := make([]byte, segmentRecordHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return queuedUpload{}, 0, err
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > maxSegmentRecordSize {
+		return queuedUpload{}, 0, CorruptSegmentError
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return queuedUpload{}, 0, CorruptSegmentError
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return queuedUpload{}, 0, CorruptSegmentError
+	}
+
+	upload := queuedUpload{seq: binary.BigEndian.Uint64(header[8:16]), uploadRequest: new(model.ClientEventUploadRequest)}
+	if err := proto.Unmarshal(payload, upload.uploadRequest); err != nil {
+		return queuedUpload{}, 0, CorruptSegmentError
+	}
+	return upload, int64(len(header) + len(payload)), nil
+}
+
+func (l *segmentLog) createSegment(firstSeq uint64) error {
+	file, err := os.OpenFile(filepath.Join(l.dir, segmentFileName(firstSeq)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if l.active != nil {
+		l.active.Close()
+	}
+	l.active, l.activeSize = file, 0
+	l.segments = append(l.segments, firstSeq)
+	return nil
+}
+
+func (l *segmentLog) openActiveSegment() error {
+	file, err := os.OpenFile(filepath.Join(l.dir, segmentFileName(l.segments[len(l.segments)-1])), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.active, l.activeSize = file, info.Size()
+	return nil
+}
+
+//The record of an upload request as written to segments and the dead letter file
+func encodeSegmentRecord(seq uint64, uploadRequest *model.ClientEventUploadRequest) ([]byte, error) {
+	payload, err := proto.Marshal(uploadRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxSegmentRecordSize {
+		return nil, SegmentRecordTooLargeError
+	}
+
+	record := make([]byte, segmentRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	binary.BigEndian.PutUint64(record[8:16], seq)
+	copy(record[segmentRecordHeaderSize:], payload)
+	return record, nil
+}
+
+//Writes the upload request to the active segment, starting a new one once it is full, and returns its sequence number
+func (l *segmentLog) append(uploadRequest *model.ClientEventUploadRequest) (uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	seq := l.nextSeq
+	record, err := encodeSegmentRecord(seq, uploadRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	if l.activeSize > 0 && l.activeSize+int64(len(record)) > l.segmentSize {
+		if err := l.createSegment(seq); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := l.active.Write(record); err != nil {
+		return 0, err
+	}
+	if l.sync {
+		if err := l.active.Sync(); err != nil {
+			return 0, err
+		}
+	}
+	l.activeSize += int64(len(record))
+	l.nextSeq++
+	return seq, nil
+}
+
+//Appends an upload that failed to store to the dead letter file, in the record format of the segments. The file is
+//only written by the queue, it is kept for an operator to inspect or import.
+func (l *segmentLog) deadLetter(upload queuedUpload) error {
+	record, err := encodeSegmentRecord(upload.seq, upload.uploadRequest)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(l.dir, deadLetterFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(record)
+	if err == nil && l.sync {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+//Marks the upload as stored. Uploads are stored out of order by the workers, the commit file only moves past an
+//upload once everything before it is stored too.
+func (l *segmentLog) ack(seq uint64) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.acked[seq] = true
+	committed := l.committed
+	for l.acked[committed+1] {
+		delete(l.acked, committed+1)
+		committed++
+	}
+	if committed == l.committed {
+		return nil
+	}
+	l.committed = committed
+
+	if err := l.writeCommit(); err != nil {
+		return err
+	}
+	return l.removeStoredSegments()
+}
+
+func (l *segmentLog) writeCommit() error {
+	tmp := filepath.Join(l.dir, commitFileName+".tmp")
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(strconv.FormatUint(l.committed, 10))
+	if err == nil && l.sync {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(l.dir, commitFileName)); err != nil {
+		return err
+	}
+	if l.sync {
+		return syncDir(l.dir)
+	}
+	return nil
+}
+
+//Removes the segments before the active one whose uploads are all stored
+func (l *segmentLog) removeStoredSegments() error {
+	for len(l.segments) > 1 && l.segments[1] <= l.committed+1 {
+		if err := os.Remove(filepath.Join(l.dir, segmentFileName(l.segments[0]))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		l.segments = l.segments[1:]
+	}
+	return nil
+}
+
+func (l *segmentLog) close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.active.Close()
+}
+
+type uint64Slice []uint64
+
+func (s uint64Slice) Len() int           { return len(s) }
+func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
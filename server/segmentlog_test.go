@@ -0,0 +1,188 @@
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"meowtrics/model"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestQueueDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "meowtrics-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func openTestSegmentLog(t *testing.T, dir string, segmentSize int64) (*segmentLog, []queuedUpload) {
+	l, pending, err := openSegmentLog(dir, segmentSize, false)
+	if err != nil {
+		t.Fatal("Error opening segment log: " + err.Error())
+	}
+	return l, pending
+}
+
+func TestSegmentLog_Replay(t *testing.T) {
+	dir := generateTestQueueDir(t)
+	defer os.RemoveAll(dir)
+
+	l, pending := openTestSegmentLog(t, dir, 1024*1024)
+	assert.Equal(t, 0, len(pending), "New log should have nothing to replay")
+	for i := 0; i < 3; i++ {
+		_, err := l.append(generateTestClientEventUploadRequest_Valid())
+		assert.NoError(t, err, "Error appending")
+	}
+	assert.NoError(t, l.ack(2))
+	assert.Equal(t, uint64(0), l.committed, "Commit should wait for earlier uploads")
+	assert.NoError(t, l.ack(1))
+	assert.Equal(t, uint64(2), l.committed, "Commit should move past acknowledged uploads")
+	assert.NoError(t, l.close())
+
+	l, pending = openTestSegmentLog(t, dir, 1024*1024)
+	assert.Equal(t, 1, len(pending), "Unacknowledged uploads should be replayed")
+	assert.Equal(t, uint64(3), pending[0].seq)
+	assert.Equal(t, "testRequestId", pending[0].uploadRequest.GetRequestId(), "Replayed uploads should be decoded")
+
+	seq, err := l.append(generateTestClientEventUploadRequest_Valid())
+	assert.NoError(t, err, "Error appending")
+	assert.Equal(t, uint64(4), seq, "Sequence numbers should continue after a restart")
+	l.close()
+}
+
+func TestSegmentLog_RemovesStoredSegments(t *testing.T) {
+	dir := generateTestQueueDir(t)
+	defer os.RemoveAll(dir)
+
+	//Every record gets its own segment
+	l, _ := openTestSegmentLog(t, dir, 1)
+	for i := 0; i < 4; i++ {
+		l.append(generateTestClientEventUploadRequest_Valid())
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentFileSuffix))
+	assert.Equal(t, 4, len(segments), "Full segments should be rotated")
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		assert.NoError(t, l.ack(seq))
+	}
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+segmentFileSuffix))
+	assert.Equal(t, []string{filepath.Join(dir, segmentFileName(4))}, segments, "Stored segments should be removed")
+	l.close()
+}
+
+func TestSegmentLog_TornRecord(t *testing.T) {
+	dir := generateTestQueueDir(t)
+	defer os.RemoveAll(dir)
+
+	l, _ := openTestSegmentLog(t, dir, 1024*1024)
+	l.append(generateTestClientEventUploadRequest_Valid())
+	l.append(generateTestClientEventUploadRequest_Valid())
+	l.close()
+
+	path := filepath.Join(dir, segmentFileName(1))
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	file.Write([]byte{0, 0, 1, 0, 42})
+	file.Close()
+
+	l, pending := openTestSegmentLog(t, dir, 1024*1024)
+	assert.Equal(t, 2, len(pending), "Complete records should survive a torn append")
+	l.append(generateTestClientEventUploadRequest_Valid())
+	l.close()
+
+	_, pending = openTestSegmentLog(t, dir, 1024*1024)
+	assert.Equal(t, 3, len(pending), "Torn record should be cut off before new appends")
+}
+
+func TestOpenIngestionQueue_AtLeastOnce(t *testing.T) {
+	dir := generateTestQueueDir(t)
+	defer os.RemoveAll(dir)
+
+	var mutex sync.Mutex
+	var written []string
+	write := func(batch []*model.ClientEventUploadRequest) []error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, uploadRequest := range batch {
+			written = append(written, uploadRequest.GetRequestId())
+		}
+		return nil
+	}
+
+	//Without workers nothing is stored before the "crash"
+	q, err := OpenIngestionQueue(dir, 1024*1024, true, 10, 0, 100, write)
+	assert.NoError(t, err, "Error opening queue")
+	assert.NoError(t, q.Enqueue(generateTestClientEventUploadRequest_Valid()))
+	assert.NoError(t, q.Enqueue(generateTestClientEventUploadRequest_Valid()))
+	q.Close()
+
+	q, err = OpenIngestionQueue(dir, 1024*1024, true, 10, 1, 100, write)
+	assert.NoError(t, err, "Error reopening queue")
+	q.Close()
+	assert.Equal(t, 2, len(written), "Uploads should be replayed after a restart")
+	assert.NoError(t, q.Err())
+
+	q, err = OpenIngestionQueue(dir, 1024*1024, true, 10, 1, 100, write)
+	assert.NoError(t, err, "Error reopening queue")
+	q.Close()
+	assert.Equal(t, 2, len(written), "Stored uploads should not be replayed again")
+}
+
+func TestSegmentLog_OversizedRecord(t *testing.T) {
+	dir := generateTestQueueDir(t)
+	defer os.RemoveAll(dir)
+
+	l, _ := openTestSegmentLog(t, dir, 1024*1024)
+	l.append(generateTestClientEventUploadRequest_Valid())
+	l.close()
+
+	//A corrupt header claiming a huge payload must not be allocated
+	path := filepath.Join(dir, segmentFileName(1))
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	file.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2})
+	file.Close()
+
+	l, pending := openTestSegmentLog(t, dir, 1024*1024)
+	assert.Equal(t, 1, len(pending), "Oversized record should be cut off like a torn one")
+	l.append(generateTestClientEventUploadRequest_Valid())
+	l.close()
+
+	_, pending = openTestSegmentLog(t, dir, 1024*1024)
+	assert.Equal(t, 2, len(pending))
+}
+
+func TestOpenIngestionQueue_DeadLetter(t *testing.T) {
+	dir := generateTestQueueDir(t)
+	defer os.RemoveAll(dir)
+
+	var mutex sync.Mutex
+	attempts := 0
+	write := func(batch []*model.ClientEventUploadRequest) []error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		attempts++
+		return []error{errors.New("datastore unavailable")}
+	}
+
+	q, err := OpenIngestionQueue(dir, 1024*1024, true, 10, 1, 100, write)
+	assert.NoError(t, err, "Error opening queue")
+	q.retryBackoff = time.Millisecond
+	assert.NoError(t, q.Enqueue(generateTestClientEventUploadRequest_Valid()))
+	q.Close()
+	assert.NoError(t, q.Err())
+	assert.Equal(t, ingestionWriteAttempts, attempts, "Failed upload should be retried")
+
+	deadLetters, err := ioutil.ReadFile(filepath.Join(dir, deadLetterFileName))
+	assert.NoError(t, err, "Failed upload should be written to the dead letter file")
+	assert.True(t, len(deadLetters) > segmentRecordHeaderSize)
+
+	q, err = OpenIngestionQueue(dir, 1024*1024, true, 10, 1, 100, write)
+	assert.NoError(t, err, "Error reopening queue")
+	q.Close()
+	assert.Equal(t, ingestionWriteAttempts, attempts, "Dead lettered upload should not be replayed")
+}
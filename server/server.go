@@ -1,83 +1,379 @@
-package main
+/*
+Package server is the Meowtrics event collection server. It can run on its own through the meowtrics command
+(see RunCommand) or be mounted inside another Go service:
+
+	s, err := server.NewServer(server.WithConfig(config), server.WithLogger(logger))
+	mux.Handle("/meowtrics/", http.StripPrefix("/meowtrics", s.Handler()))
+
+Every Server has its own datastore, logger, limiters and metrics, so several of them can run in one process.
+*/
+package server
 
 import (
-	"fmt"
-	"meowtrics/model"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
-	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 	"gopkg.in/tylerb/graceful.v1"
 )
 
 var (
-	file            *os.File
-	meowtricsLogger = log.New()
-	logFileName     = "log-meowtrics.log"
-	configFileName  = "meowtricsConfig"
-	router          *mux.Router
-	postSubrouter   *mux.Router
-	getSubrouter    *mux.Router
-	eventMap        map[string]model.ClientEventData
+	logFileName    = "log-meowtrics.log"
+	configFileName = "meowtricsConfig"
+)
+
+var (
+	ServerStartedError  = errors.New("Server already started")
+	ServerShutdownError = errors.New("Server was shut down")
 )
 
-func init() {
-	initApp()
-	initRouter()
+type Server struct {
+	config       *Config
+	logger       *log.Logger
+	logLevel     *logLevel
+	logFile      io.Closer
+	store        Store
+	auth         AuthFunc
+	grpcAuth     GRPCAuthFunc
+	middleware   []negroni.Handler
+	healthChecks []namedHealthCheck
+
+	router          *mux.Router
+	handler         http.Handler
+	rateLimiter     *RateLimiter
+	eventMetrics    *EventMetrics
+	metrics         *serverMetrics
+	ingestion       *IngestionQueue
+	webhooks        *Webhooks
+	stream          *EventStream
+	sketches        *Sketches
+	rollups         *Rollups
+	producerOffsets *ProducerOffsets
+	adminApiKey     adminKey
+	grpcApiKey      adminKey
+	startedAt       time.Time
+
+	adminCertSubjects certSubjects
+	grpcCertSubjects  certSubjects
+
+	mutex          sync.Mutex
+	httpServer     *graceful.Server
+	listener       net.Listener
+	grpcServer     *grpc.Server
+	configReloader *ConfigReloader
+	shutdownHooks  map[string][]func() error
+	done           chan struct{}
+	serveErr       error
+	shutdown       bool
 }
 
-func initApp() {
+//Configures a Server in NewServer
+type Option func(*Server)
 
-	err := InitializeLogger(file, logFileName, meowtricsLogger, new(log.JSONFormatter))
-	if err != nil {
-		fmt.Println("Error initializing logger, defaulting to stdout. Error: " + err.Error())
+//Uses config instead of DefaultConfig()
+func WithConfig(config *Config) Option {
+	return func(s *Server) {
+		s.config = config
 	}
+}
+
+//Uses store instead of a new MemoryStore. Shutdown flushes the store if it is a StoreFlusher and closes it if it is an io.Closer.
+func WithStore(store Store) Option {
+	return func(s *Server) {
+		s.store = store
+	}
+}
 
-	err = LoadAppProperties(configFileName, DeploymentConfigDefaultPath, meowtricsLogger)
+//Logs to logger as it is, the log settings of the config are only applied to the logger the server creates itself
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+//Adds negroni middleware, it runs after the access log, metrics and rate limiting middleware and before the routes
+func WithMiddleware(middleware ...negroni.Handler) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+//Replaces the adminApiKey check guarding the /admin endpoints
+func WithAuth(auth AuthFunc) Option {
+	return func(s *Server) {
+		s.auth = auth
+	}
+}
+
+//Builds the datastore, limiters, routes and middleware of a new server
+func NewServer(options ...Option) (*Server, error) {
+	s := new(Server)
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.config == nil {
+		s.config = DefaultConfig()
+	}
+	if s.logger == nil {
+		s.logger = log.New()
+		closer, err := ConfigureLogger(s.logger, s.config.Log)
+		if err != nil {
+			return nil, errors.New("Error configuring logger: " + err.Error())
+		}
+		s.logFile = closer
+	}
+	s.logLevel = filterLogLevel(s.logger)
+	if s.store == nil {
+		s.store = NewMemoryStore()
+	}
+	if s.auth == nil {
+		s.auth = s.checkAdminApiKey
+	}
+	if s.grpcAuth == nil {
+		s.grpcAuth = s.checkGRPCApiKey
+	}
+
+	em, err := NewEventMetrics(s.config.EventMetricRules)
 	if err != nil {
-		meowtricsLogger.Panicln("Error reading app properties:" + err.Error())
+		s.closeLogFile()
+		return nil, errors.New("Error reading event metric rules: " + err.Error())
+	}
+	s.eventMetrics = em
+	s.rateLimiter = NewRateLimiter(s.config.RateLimits)
+	s.rateLimiter.logger = s.logger
+	s.metrics = newServerMetrics(s.config.MetricsDeviceTypes, func() Store { return s.store }, s.ingestionQueueDepth, s.webhookQueueDepth, s.streamSubscribers)
+	s.adminApiKey.set(s.config.AdminApiKey)
+	s.grpcApiKey.set(s.config.GRPCApiKey)
+	s.adminCertSubjects.set(s.config.AdminCertSubjects)
+	s.grpcCertSubjects.set(s.config.GRPCCertSubjects)
+	s.startedAt = time.Now()
+	s.shutdownHooks = make(map[string][]func() error)
+	//Created before the ingestion queue, whose workers may store replayed uploads right away, and closed with the
+	//workers once the queue stopped notifying it of stored events
+	webhooks, err := NewWebhooks(s.config.Webhooks, s.logger, s.metrics.webhookDeliveriesTotal)
+	if err != nil {
+		s.closeLogFile()
+		return nil, errors.New("Error loading webhooks: " + err.Error())
+	}
+	s.webhooks = webhooks
+	s.onShutdown(shutdownStageWorkers, s.webhooks.Close)
+	s.stream = NewEventStream(s.config.Stream, s.metrics.streamDisconnectsTotal)
+	s.sketches = NewSketches(s.config.Sketches)
+	s.rollups = NewRollups(s.config.Rollups)
+	s.observeStoredEvents(s.store.AllEvents())
+	offsets, err := NewProducerOffsets(s.config.UploadStream.OffsetFile)
+	if err != nil {
+		s.webhooks.Close()
+		s.closeLogFile()
+		return nil, errors.New("Error loading upload stream offsets: " + err.Error())
+	}
+	s.producerOffsets = offsets
+	if settings := s.config.Ingestion; settings.Async && settings.QueueDir != "" {
+		queue, err := OpenIngestionQueue(settings.QueueDir, int64(settings.SegmentSizeInMB)*1024*1024, settings.Sync,
+			settings.QueueSize, settings.Workers, settings.BatchSize, s.storeUploadBatch)
+		if err != nil {
+			s.webhooks.Close()
+			s.closeLogFile()
+			return nil, errors.New("Error opening ingestion queue: " + err.Error())
+		}
+		s.ingestion = queue
+	} else if settings.Async {
+		s.ingestion = NewIngestionQueue(settings.QueueSize, settings.Workers, settings.BatchSize, s.storeUploadBatch)
 	}
+	if s.ingestion != nil {
+		s.onShutdown(shutdownStageIngestion, s.ingestion.Close)
+	}
+
+	s.initHealthChecks()
+	s.initRouter()
 
-	eventMap = make(map[string]model.ClientEventData)
+	n := negroni.New(negroni.NewRecovery(), NewAccessLogger(s.logger), negroni.NewStatic(http.Dir("public")))
+	n.Use(newMetricsMiddleware(s.router, s.metrics))
+	n.Use(s.rateLimiter)
+	for _, middleware := range s.middleware {
+		n.Use(middleware)
+	}
+	n.UseHandler(s.router)
+	s.handler = n
+
+	return s, nil
 }
 
-func initRouter() {
+func (s *Server) initRouter() {
+
+	s.router = mux.NewRouter()
+	s.router.StrictSlash(true)
 
-	router = mux.NewRouter()
-	router.StrictSlash(true)
+	postSubrouter := s.router.PathPrefix("/v1/").Methods("POST").Subrouter()
+	postSubrouter.Handle("/events", s.CreateEventHandler()).Name("createEvent")
 
-	postSubrouter = router.PathPrefix("/v1/").Methods("POST").Subrouter()
-	postSubrouter.Handle("/events", CreateEventHandler())
+	getSubrouter := s.router.PathPrefix("/v1/").Methods("GET").Subrouter()
+	getSubrouter.Handle("/events/{id:[0-9]+}", s.RetrieveEventHandler()).Name("retrieveEvent")
+	getSubrouter.Handle("/events/stream", s.StreamEventsHandler()).Name("streamEvents")
+	getSubrouter.Handle("/measurements/{name}", s.MeasurementsHandler()).Name("queryMeasurements")
+	getSubrouter.Handle("/rollups", s.RollupsHandler()).Name("queryRollups")
+	getSubrouter.Handle("/funnels", s.FunnelsHandler()).Name("queryFunnel")
+
+	s.router.Handle("/heartbeat", s.HeartBeatHandler()).Name("heartbeat")
+	s.router.Handle("/healthz", s.HeartBeatHandler()).Methods("GET").Name("healthz")
+	s.router.Handle("/readyz", s.ReadyzHandler()).Methods("GET").Name("readyz")
+	s.router.Handle("/metrics", s.MetricsHandler()).Methods("GET").Name("metrics")
+	s.router.Handle("/metrics/events", s.EventMetricsHandler()).Methods("GET").Name("eventMetrics")
+	s.router.Handle("/metrics/sketches/distinct", s.DistinctCountHandler()).Methods("GET").Name("sketchDistinct")
+	s.router.Handle("/metrics/sketches/quantiles", s.QuantilesHandler()).Methods("GET").Name("sketchQuantiles")
+	s.router.Handle("/admin/loglevel", s.AdminAuthHandler(s.LogLevelHandler())).Methods("GET", "PUT").Name("adminLogLevel")
+	s.router.Handle("/admin/events", s.AdminAuthHandler(s.ExportEventsHandler())).Methods("GET").Name("adminExportEvents")
+	s.router.Handle("/admin/events", s.AdminAuthHandler(s.ImportEventsHandler())).Methods("POST").Name("adminImportEvents")
+	s.router.Handle("/admin/compact", s.AdminAuthHandler(s.CompactEventsHandler())).Methods("POST").Name("adminCompact")
+	s.router.Handle("/admin/stats", s.AdminAuthHandler(s.StatsHandler())).Methods("GET").Name("adminStats")
+	s.router.Handle("/admin/webhooks", s.AdminAuthHandler(s.ListWebhooksHandler())).Methods("GET").Name("adminListWebhooks")
+	s.router.Handle("/admin/webhooks", s.AdminAuthHandler(s.CreateWebhookHandler())).Methods("POST").Name("adminCreateWebhook")
+	s.router.Handle("/admin/webhooks/deadletters", s.AdminAuthHandler(s.DeadLettersHandler())).Methods("GET").Name("adminDeadLetters")
+	s.router.Handle("/admin/webhooks/deadletters/redeliver", s.AdminAuthHandler(s.RedeliverHandler())).Methods("POST").Name("adminRedeliver")
+	s.router.Handle("/admin/webhooks/{id:[0-9a-f]+}", s.AdminAuthHandler(s.DeleteWebhookHandler())).Methods("DELETE").Name("adminDeleteWebhook")
+	s.router.NotFoundHandler = s.NotFoundHandler()
+}
+
+//The full middleware chain and routes, for mounting the server inside another one
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
 
-	getSubrouter = router.PathPrefix("/v1/").Methods("GET").Subrouter()
-	getSubrouter.Handle("/events/{id:[0-9]+}", RetrieveEventHandler())
+func (s *Server) Logger() *log.Logger {
+	return s.logger
+}
 
-	router.Handle("/heartbeat", HeartBeatHandler())
-	router.NotFoundHandler = NotFoundHandler()
+//Returns the request scoped logger, falling back to the server's logger for requests that bypassed the access log middleware
+func (s *Server) RequestLogger(req *http.Request) *log.Entry {
+	return requestLogger(req, s.logger)
 }
 
-func main() {
+//Listens on appPort, and on grpcPort when it is set, and serves in the background until Shutdown. Errors binding the
+//ports are returned right away.
+//When the config was loaded from a file, the file is watched for changes while the server runs.
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	n := negroni.Classic()
-	n.UseHandler(router)
+	if s.shutdown {
+		return ServerShutdownError
+	}
+	if s.httpServer != nil {
+		return ServerStartedError
+	}
 
-	appGracefulShutdownTimeinSeconds, err := strconv.Atoi(viper.GetString("appGracefulShutdownTimeinSeconds"))
+	tlsConfig, err := LoadTLSConfig(s.config.TLS, s.logger)
 	if err != nil {
-		meowtricsLogger.Errorln("Error reading config: " + err.Error())
+		return errors.New("Error loading TLS config: " + err.Error())
+	}
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(s.config.AppPort))
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		//The TLS listener is built here so that certificates come from tlsConfig.GetCertificate
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	if s.config.GRPCPort != 0 {
+		if err := s.startGRPC(tlsConfig); err != nil {
+			listener.Close()
+			return err
+		}
+	}
+
+	s.httpServer = &graceful.Server{
+		Timeout:          time.Duration(s.config.AppGracefulShutdownTimeinSeconds) * time.Second,
+		NoSignalHandling: true,
+		Server:           &http.Server{Handler: s.handler, TLSConfig: tlsConfig},
+	}
+	s.listener = listener
+
+	if s.config.fileName != "" {
+		s.configReloader = newConfigReloader(s.config, s.logger, s.applyReloadableConfig)
+		s.configReloader.Start()
+		s.onShutdown(shutdownStageWorkers, func() error {
+			s.configReloader.Stop()
+			return nil
+		})
 	}
 
-	graceful.Run(":"+viper.GetString("appPort"), time.Duration(appGracefulShutdownTimeinSeconds)*time.Second, n)
+	s.done = make(chan struct{})
+	go func(srv *graceful.Server, done chan struct{}) {
+		err := srv.Serve(listener)
+		s.mutex.Lock()
+		if !s.shutdown {
+			s.serveErr = err
+		}
+		s.mutex.Unlock()
+		close(done)
+	}(s.httpServer, s.done)
+
+	s.logger.WithFields(log.Fields{"method": "Start", "addr": listener.Addr().String(), "tls": tlsConfig != nil}).Infoln("Server started")
+	return nil
+}
+
+//The address the server listens on, nil before Start
+func (s *Server) Addr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
 
-	defer cleanup(file, meowtricsLogger)
+func (s *Server) shuttingDown() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.shutdown
 }
 
-func cleanup(file *os.File, logger *log.Logger) {
-	logger.Println("Starting clean up")
+//Starts the server and shuts it down on SIGINT or SIGTERM, returns the error that stopped it if it failed while serving
+func (s *Server) ListenAndServe() error {
+	if err := s.Start(); err != nil {
+		s.closeLogFile()
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		s.logger.WithFields(log.Fields{"method": "ListenAndServe", "signal": sig.String()}).Infoln("Shutting down")
+	case <-s.done:
+	}
+
+	shutdownErr := s.Shutdown()
 
-	logger.Println("Closing file stream")
-	file.Close()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.serveErr != nil {
+		return s.serveErr
+	}
+	return shutdownErr
+}
+
+func (s *Server) closeLogFile() {
+	if s.logFile != nil {
+		s.logger.Println("Closing file stream")
+		s.logFile.Close()
+		s.logFile = nil
+	}
 }
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/stretchr/testify/assert"
+)
+
+func postTestEvent(t *testing.T, handler http.Handler) *httptest.ResponseRecorder {
+	jsonReq, err := model.MarshalJSON(generateTestClientEventUploadRequest_Valid())
+	assert.NoError(t, err, "Error marshalling request")
+	req, err := http.NewRequest("POST", "/v1/events", strings.NewReader(string(jsonReq)))
+	assert.NoError(t, err, "Error creating request")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestNewServer_IndependentInstances(t *testing.T) {
+	first, second := NewMemoryStore(), NewMemoryStore()
+	s1 := newTestServer(t, WithStore(first))
+	s2 := newTestServer(t, WithStore(second))
+
+	w := postTestEvent(t, s1.Handler())
+	assert.Equal(t, http.StatusOK, w.Code, "Event should be created")
+	assert.Equal(t, 1, first.CountEvents(), "Event should be stored by the server that received it")
+	assert.Equal(t, 0, second.CountEvents(), "Other servers should not see the event")
+
+	s2.adminApiKey.set("secondKey")
+	assert.Equal(t, "", s1.adminApiKey.get(), "Admin API keys should not be shared")
+}
+
+func TestNewServer_Options(t *testing.T) {
+	called := false
+	middleware := negroni.HandlerFunc(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		called = true
+		next(w, req)
+	})
+	s := newTestServer(t, WithMiddleware(middleware), WithAuth(func(req *http.Request) bool {
+		return req.Header.Get("X-Test-Auth") == "meow"
+	}))
+
+	req, _ := http.NewRequest("GET", "/admin/stats", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.True(t, called, "Custom middleware should run")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Custom auth should reject the request")
+
+	req.Header.Set("X-Test-Auth", "meow")
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Custom auth should accept the request")
+}
+
+func TestServer_StartShutdown(t *testing.T) {
+	config := DefaultConfig()
+	config.AppPort = 0
+	s := newTestServer(t, WithConfig(config))
+	assert.Nil(t, s.Addr(), "Address should be nil before Start")
+
+	assert.NoError(t, s.Start(), "Error starting server")
+	assert.Equal(t, ServerStartedError, s.Start(), "Server should only start once")
+
+	res, err := http.Get("http://" + s.Addr().String() + "/heartbeat")
+	assert.NoError(t, err, "Error calling started server")
+	if err == nil {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode, "Heartbeat should succeed")
+	}
+
+	assert.NoError(t, s.Shutdown(), "Error shutting down")
+	assert.Equal(t, ServerShutdownError, s.Start(), "Server should not start after Shutdown")
+}
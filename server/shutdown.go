@@ -0,0 +1,166 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//Shutdown stages in the order they run, hooks can be added to the ingestion and workers stages with onShutdown
+const (
+	shutdownStageReadiness = "readiness"
+	shutdownStageRequests  = "requests"
+	shutdownStageIngestion = "ingestion"
+	shutdownStageDatastore = "datastore"
+	shutdownStageWorkers   = "workers"
+)
+
+//One step of the shutdown sequence, a zero timeout waits for run to return
+type shutdownStage struct {
+	name    string
+	timeout time.Duration
+	run     func() error
+}
+
+//Adds a hook to a shutdown stage, must be called while the server is built or with s.mutex held
+func (s *Server) onShutdown(stage string, hook func() error) {
+	s.shutdownHooks[stage] = append(s.shutdownHooks[stage], hook)
+}
+
+//Shuts the server down in order, each stage is logged and has its own timeout:
+//
+//	readiness  /readyz reports not ready for readinessDrainDelayInSeconds so load balancers stop sending traffic
+//	requests   closes the event streams, stops accepting connections and gives in flight uploads and gRPC calls
+//	           appGracefulShutdownTimeinSeconds
+//	ingestion  flushes the async ingestion queues within shutdownFlushTimeoutInSeconds
+//	datastore  flushes and closes the store within shutdownStoreTimeoutInSeconds
+//	workers    stops the background workers within shutdownWorkersTimeoutInSeconds
+//
+//The log file the server opened is closed last. A stage that fails or times out is logged and the next one still
+//runs, the first error is returned. A server that was shut down can't be started again.
+func (s *Server) Shutdown() error {
+	s.mutex.Lock()
+	if s.shutdown {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.shutdown = true
+	srv, done, grpcSrv := s.httpServer, s.done, s.grpcServer
+	hooks := make(map[string][]func() error)
+	for stage, stageHooks := range s.shutdownHooks {
+		hooks[stage] = append([]func() error{}, stageHooks...)
+	}
+	s.mutex.Unlock()
+
+	started := time.Now()
+	stages := []shutdownStage{
+		{name: shutdownStageReadiness, run: func() error {
+			if srv != nil {
+				time.Sleep(time.Duration(s.config.Readiness.DrainDelayInSeconds) * time.Second)
+			}
+			return nil
+		}},
+		{name: shutdownStageRequests, timeout: requestsStageTimeout(s.config.AppGracefulShutdownTimeinSeconds), run: func() error {
+			//Event streams never finish on their own
+			s.stream.Close()
+			grpcStopped := make(chan struct{})
+			go func() {
+				if grpcSrv != nil {
+					stopGRPC(grpcSrv, seconds(s.config.AppGracefulShutdownTimeinSeconds))
+				}
+				close(grpcStopped)
+			}()
+			if srv != nil {
+				srv.Stop(srv.Timeout)
+				<-done
+			}
+			<-grpcStopped
+			return nil
+		}},
+		{name: shutdownStageIngestion, timeout: seconds(s.config.Shutdown.FlushTimeoutInSeconds), run: runHooks(hooks[shutdownStageIngestion])},
+		{name: shutdownStageDatastore, timeout: seconds(s.config.Shutdown.StoreTimeoutInSeconds), run: s.closeStore},
+		{name: shutdownStageWorkers, timeout: seconds(s.config.Shutdown.WorkersTimeoutInSeconds), run: runHooks(hooks[shutdownStageWorkers])},
+	}
+
+	var firstErr error
+	for _, stage := range stages {
+		if err := s.runShutdownStage(stage); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.logger.WithFields(log.Fields{"method": "Shutdown", "elapsed": time.Since(started).String()}).Infoln("Shutdown finished")
+	s.closeLogFile()
+	return firstErr
+}
+
+func (s *Server) runShutdownStage(stage shutdownStage) error {
+	logger := s.logger.WithFields(log.Fields{"method": "Shutdown", "stage": stage.name})
+	logger.Infoln("Shutdown stage started")
+	started := time.Now()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- stage.run()
+	}()
+
+	var timeout <-chan time.Time
+	if stage.timeout > 0 {
+		timeout = time.After(stage.timeout)
+	}
+
+	var err error
+	select {
+	case err = <-result:
+	case <-timeout:
+		err = errors.New("Shutdown stage " + stage.name + " timed out after " + stage.timeout.String())
+	}
+
+	logger = logger.WithFields(log.Fields{"elapsed": time.Since(started).String()})
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err.Error()}).Errorln("Shutdown stage failed")
+		return err
+	}
+	logger.Infoln("Shutdown stage finished")
+	return nil
+}
+
+//Flushes the store if it buffers writes and closes it if it can be closed
+func (s *Server) closeStore() error {
+	if flusher, ok := s.store.(StoreFlusher); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+	if closer, ok := s.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+//Runs every hook even when one fails, returns the first error
+func runHooks(hooks []func() error) func() error {
+	return func() error {
+		var firstErr error
+		for _, hook := range hooks {
+			if err := hook(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+}
+
+//The in flight requests are cut off after the graceful timeout, the extra second lets their connections close
+func requestsStageTimeout(gracefulTimeoutInSeconds int) time.Duration {
+	if gracefulTimeoutInSeconds == 0 {
+		return 0
+	}
+	return seconds(gracefulTimeoutInSeconds) + time.Second
+}
+
+func seconds(value int) time.Duration {
+	return time.Duration(value) * time.Second
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//MemoryStore that records the order it is flushed and closed in
+type recordingTestStore struct {
+	*MemoryStore
+	calls *[]string
+}
+
+func (rs recordingTestStore) Flush() error {
+	*rs.calls = append(*rs.calls, "flush")
+	return nil
+}
+
+func (rs recordingTestStore) Close() error {
+	*rs.calls = append(*rs.calls, "close")
+	return nil
+}
+
+func TestShutdown_StageOrder(t *testing.T) {
+	var calls []string
+	s := newTestServer(t, WithStore(recordingTestStore{NewMemoryStore(), &calls}))
+	s.onShutdown(shutdownStageWorkers, func() error {
+		calls = append(calls, "workers")
+		return nil
+	})
+	s.onShutdown(shutdownStageIngestion, func() error {
+		calls = append(calls, "ingestion")
+		return nil
+	})
+
+	assert.NoError(t, s.Shutdown(), "Error shutting down")
+	assert.Equal(t, []string{"ingestion", "flush", "close", "workers"}, calls, "Stages should run in order")
+	assert.NoError(t, s.Shutdown(), "Shutting down twice should do nothing")
+	assert.Equal(t, 4, len(calls), "Stages should only run once")
+}
+
+func TestShutdown_StageFailures(t *testing.T) {
+	config := DefaultConfig()
+	config.Shutdown.FlushTimeoutInSeconds = 1
+	s := newTestServer(t, WithConfig(config))
+
+	release := make(chan struct{})
+	defer close(release)
+	workersStopped := false
+	s.onShutdown(shutdownStageIngestion, func() error {
+		<-release
+		return nil
+	})
+	s.onShutdown(shutdownStageWorkers, func() error {
+		return errors.New("worker failed")
+	})
+	s.onShutdown(shutdownStageWorkers, func() error {
+		workersStopped = true
+		return nil
+	})
+
+	err := s.Shutdown()
+	assert.Error(t, err, "Timed out stage should be reported")
+	assert.Contains(t, err.Error(), "ingestion timed out", "First failure should be returned")
+	assert.True(t, workersStopped, "Later stages and hooks should still run")
+}
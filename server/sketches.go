@@ -0,0 +1,388 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"meowtrics/model"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//Quantiles returned by the quantile sketch endpoint when the request names none
+var defaultSketchQuantiles = []float64{0.5, 0.95, 0.99}
+
+//Rollups of approximate aggregates kept up to date on ingest
+type SketchSettings struct {
+	BucketSeconds      int
+	RetentionInHours   int
+	DistinctKeys       []string
+	QuantileValues     []string
+	HLLPrecision       int
+	TDigestCompression int
+}
+
+//Identifies one sketch: name is the kv pair key of a distinct count, or the measurement name or kv:<key> of a quantile
+//sketch. Only measurements set unit and kind, their quantiles are kept apart like in measurement queries.
+type sketchKey struct {
+	eventType model.ClientEventType
+	start     int64
+	name      string
+	unit      string
+	kind      model.MeasurementKind
+}
+
+/*
+Per event type and time bucket, HyperLogLogs of the values of the configured kv pair keys and t-digests of the
+configured measurements and numeric kv pair values. Buckets older than the retention are dropped, events older than it are not
+added.
+*/
+type Sketches struct {
+	settings       SketchSettings
+	distinctKeys   map[string]bool
+	quantileValues map[string]bool
+	now            func() time.Time
+
+	mutex     sync.Mutex
+	distinct  map[sketchKey]*HyperLogLog
+	quantiles map[sketchKey]*TDigest
+	oldest    int64
+}
+
+func NewSketches(settings SketchSettings) *Sketches {
+	sk := &Sketches{
+		settings:       settings,
+		distinctKeys:   make(map[string]bool),
+		quantileValues: make(map[string]bool),
+		now:            time.Now,
+		distinct:       make(map[sketchKey]*HyperLogLog),
+		quantiles:      make(map[sketchKey]*TDigest),
+	}
+	for _, key := range settings.DistinctKeys {
+		sk.distinctKeys[key] = true
+	}
+	for _, value := range settings.QuantileValues {
+		sk.quantileValues[value] = true
+	}
+	return sk
+}
+
+func (sk *Sketches) bucketSeconds() int64 {
+	return int64(sk.settings.BucketSeconds)
+}
+
+//Start of the oldest bucket still kept
+func (sk *Sketches) retentionStart() int64 {
+	return bucketStart(sk.now().Unix()-int64(sk.settings.RetentionInHours)*3600, sk.bucketSeconds())
+}
+
+func (sk *Sketches) Observe(event *model.ClientEventData) {
+	start := bucketStart(event.GetTimestamp(), sk.bucketSeconds())
+	retentionStart := sk.retentionStart()
+	if start < retentionStart {
+		return
+	}
+
+	sk.mutex.Lock()
+	defer sk.mutex.Unlock()
+	if retentionStart > sk.oldest {
+		sk.prune(retentionStart)
+	}
+
+	for _, pair := range event.GetKvPair() {
+		value := kvPairAttribute(pair)
+		if sk.distinctKeys[pair.GetKey()] {
+			key := sketchKey{eventType: event.GetEventType(), start: start, name: pair.GetKey()}
+			hll, ok := sk.distinct[key]
+			if !ok {
+				hll = NewHyperLogLog(sk.settings.HLLPrecision)
+				sk.distinct[key] = hll
+			}
+			hll.Add(attributeString(value))
+		}
+
+		if !isTypedNumber(value) || !sk.quantileValues[KV_PAIR_PREFIX+pair.GetKey()] {
+			continue
+		}
+		number, _ := attributeNumber(value)
+		sk.digest(sketchKey{eventType: event.GetEventType(), start: start, name: KV_PAIR_PREFIX + pair.GetKey()}).Add(number, 1)
+	}
+
+	//Only configured names get a t-digest, the names are chosen by clients and every digest takes memory
+	for _, measurement := range event.GetMeasurements() {
+		if !sk.quantileValues[measurement.GetName()] {
+			continue
+		}
+		digest := sk.digest(sketchKey{eventType: event.GetEventType(), start: start, name: measurement.GetName(), unit: measurement.GetUnit(), kind: measurement.GetKind()})
+		if histogram := measurement.GetHistogram(); histogram != nil {
+			for _, segment := range histogramSegments(histogram) {
+				digest.Add((segment.low+segment.high)/2, segment.count)
+			}
+		} else {
+			digest.Add(measurement.GetValue(), 1)
+		}
+	}
+}
+
+func (sk *Sketches) digest(key sketchKey) *TDigest {
+	digest, ok := sk.quantiles[key]
+	if !ok {
+		digest = NewTDigest(float64(sk.settings.TDigestCompression))
+		sk.quantiles[key] = digest
+	}
+	return digest
+}
+
+func (sk *Sketches) prune(retentionStart int64) {
+	for key := range sk.distinct {
+		if key.start < retentionStart {
+			delete(sk.distinct, key)
+		}
+	}
+	for key := range sk.quantiles {
+		if key.start < retentionStart {
+			delete(sk.quantiles, key)
+		}
+	}
+	sk.oldest = retentionStart
+}
+
+//Which sketches a query reads: every event type when eventTypes is empty, buckets starting in [from, to)
+type sketchQuery struct {
+	eventTypes []model.ClientEventType
+	name       string
+	unit       *string
+	kind       *model.MeasurementKind
+	from       *int64
+	to         *int64
+}
+
+func (query sketchQuery) matches(key sketchKey, bucketSeconds int64) bool {
+	if key.name != query.name {
+		return false
+	}
+	if (query.unit != nil && key.unit != *query.unit) || (query.kind != nil && key.kind != *query.kind) {
+		return false
+	}
+	if query.from != nil && key.start < bucketStart(*query.from, bucketSeconds) {
+		return false
+	}
+	if query.to != nil && key.start >= *query.to {
+		return false
+	}
+	if len(query.eventTypes) == 0 {
+		return true
+	}
+	for _, eventType := range query.eventTypes {
+		if eventType == key.eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (sk *Sketches) DistinctCount(query sketchQuery) *model.DistinctCountResponse {
+	sk.mutex.Lock()
+	defer sk.mutex.Unlock()
+
+	buckets := make(map[int64]*HyperLogLog)
+	total := NewHyperLogLog(sk.settings.HLLPrecision)
+	for key, hll := range sk.distinct {
+		if !query.matches(key, sk.bucketSeconds()) {
+			continue
+		}
+		bucket, ok := buckets[key.start]
+		if !ok {
+			bucket = NewHyperLogLog(sk.settings.HLLPrecision)
+			buckets[key.start] = bucket
+		}
+		bucket.Merge(hll)
+		total.Merge(hll)
+	}
+
+	resp := &model.DistinctCountResponse{Key: query.name, BucketSeconds: sk.bucketSeconds(), TotalEstimate: total.Estimate(),
+		RelativeStandardError: total.RelativeStandardError()}
+	starts := make([]int64, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sortInt64s(starts)
+	for _, start := range starts {
+		resp.Buckets = append(resp.Buckets, &model.DistinctCountBucket{StartTimestamp: start, Estimate: buckets[start].Estimate()})
+	}
+	return resp
+}
+
+//Quantiles of the digests matching the query, an error if they are of more than one unit or kind
+func (sk *Sketches) Quantiles(query sketchQuery, quantiles []float64) (*model.QuantileResponse, error) {
+	sk.mutex.Lock()
+	defer sk.mutex.Unlock()
+
+	compression := float64(sk.settings.TDigestCompression)
+	buckets := make(map[int64]*TDigest)
+	total := NewTDigest(compression)
+	var measurement *measurementKey
+	for key, digest := range sk.quantiles {
+		if !query.matches(key, sk.bucketSeconds()) {
+			continue
+		}
+		if measurement == nil {
+			measurement = &measurementKey{name: key.name, unit: key.unit, kind: key.kind}
+		} else if measurement.unit != key.unit || measurement.kind != key.kind {
+			return nil, errors.New("value " + query.name + " was sent with more than one unit or kind, pick one with unit and kind")
+		}
+		bucket, ok := buckets[key.start]
+		if !ok {
+			bucket = NewTDigest(compression)
+			buckets[key.start] = bucket
+		}
+		bucket.Merge(digest)
+		total.Merge(digest)
+	}
+
+	resp := &model.QuantileResponse{Value: query.name, BucketSeconds: sk.bucketSeconds(), TotalCount: uint64(total.Count()),
+		Total: quantileEstimates(total, quantiles)}
+	if measurement != nil {
+		resp.Unit, resp.Kind = measurement.unit, measurement.kind
+	}
+	starts := make([]int64, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sortInt64s(starts)
+	for _, start := range starts {
+		resp.Buckets = append(resp.Buckets, &model.QuantileBucket{StartTimestamp: start, Count: uint64(buckets[start].Count()),
+			Quantiles: quantileEstimates(buckets[start], quantiles)})
+	}
+	return resp, nil
+}
+
+func quantileEstimates(digest *TDigest, quantiles []float64) []*model.QuantileEstimate {
+	if digest.Count() == 0 {
+		return nil
+	}
+	estimates := make([]*model.QuantileEstimate, len(quantiles))
+	for i, q := range quantiles {
+		estimates[i] = &model.QuantileEstimate{Quantile: q, Value: digest.Quantile(q), RankError: digest.RankError(q)}
+	}
+	return estimates
+}
+
+//Reads the event_type, from and to query parameters, rollups are only kept per event type so other filters are rejected
+func parseSketchQuery(name string, query url.Values) (sketchQuery, error) {
+	filter, err := parseEventFilter(query)
+	if err != nil {
+		return sketchQuery{}, err
+	}
+	if len(filter.GetDeviceTypes()) > 0 || len(filter.GetKvPairs()) > 0 || len(filter.GetKvConditions()) > 0 {
+		return sketchQuery{}, errors.New("sketches are kept per event type, device_type and kv_pair can't be queried")
+	}
+
+	sq := sketchQuery{eventTypes: filter.GetEventTypes(), name: name}
+	if sq.from, err = timestampParameter(query, "from"); err != nil {
+		return sketchQuery{}, err
+	}
+	if sq.to, err = timestampParameter(query, "to"); err != nil {
+		return sketchQuery{}, err
+	}
+	if sq.from != nil && sq.to != nil && *sq.from >= *sq.to {
+		return sketchQuery{}, errors.New("from must be before to")
+	}
+	return sq, nil
+}
+
+//Reads the optional unit and kind query parameters, the kind by name like MEASUREMENT_KIND_TIMING or by number
+func parseMeasurementUnitAndKind(query url.Values) (*string, *model.MeasurementKind, error) {
+	var unit *string
+	var kind *model.MeasurementKind
+	if values, ok := query["unit"]; ok {
+		unit = &values[0]
+	}
+	if value := query.Get("kind"); value != "" {
+		number, ok := model.MeasurementKind_value[strings.ToUpper(value)]
+		if !ok {
+			parsed, err := strconv.Atoi(value)
+			if _, known := model.MeasurementKind_name[int32(parsed)]; err != nil || !known {
+				return nil, nil, errors.New("Unknown measurement kind " + value)
+			}
+			number = int32(parsed)
+		}
+		kind = model.MeasurementKind(number).Enum()
+	}
+	return unit, kind, nil
+}
+
+func invalidSketchQuery(w http.ResponseWriter, logger *log.Entry, err error) {
+	logger.WithFields(log.Fields{"error": err.Error()}).Infoln("Invalid sketch query")
+	writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid sketch query", Description: err.Error()})
+}
+
+/*
+Estimated distinct values of the kv pair key per rollup bucket, e.g.
+GET /metrics/sketches/distinct?key=user_id&event_type=USER_REGISTERED
+*/
+func (s *Server) DistinctCountHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := s.RequestLogger(req).WithFields(log.Fields{"method": "DistinctCountHandler"})
+
+		key := req.URL.Query().Get("key")
+		if !s.sketches.distinctKeys[key] {
+			invalidSketchQuery(w, logger, errors.New("key "+key+" has no distinct count sketches, the keys are configured in sketchDistinctKeys"))
+			return
+		}
+		query, err := parseSketchQuery(key, req.URL.Query())
+		if err != nil {
+			invalidSketchQuery(w, logger, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.sketches.DistinctCount(query))
+	})
+}
+
+/*
+Estimated quantiles of a measurement, or of a numeric kv pair given as kv:<key>, per rollup bucket, e.g.
+GET /metrics/sketches/quantiles?value=app_start&q=0.5&q=0.99
+*/
+func (s *Server) QuantilesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := s.RequestLogger(req).WithFields(log.Fields{"method": "QuantilesHandler"})
+
+		value := req.URL.Query().Get("value")
+		if !s.sketches.quantileValues[value] {
+			invalidSketchQuery(w, logger, errors.New("value "+value+" has no quantile sketches, the measurement names and kv:<key> values are configured in sketchQuantileValues"))
+			return
+		}
+		query, err := parseSketchQuery(value, req.URL.Query())
+		if err != nil {
+			invalidSketchQuery(w, logger, err)
+			return
+		}
+		if query.unit, query.kind, err = parseMeasurementUnitAndKind(req.URL.Query()); err != nil {
+			invalidSketchQuery(w, logger, err)
+			return
+		}
+
+		quantiles := defaultSketchQuantiles
+		if params := req.URL.Query()["q"]; len(params) > 0 {
+			quantiles = make([]float64, len(params))
+			for i, param := range params {
+				quantiles[i], err = strconv.ParseFloat(strings.TrimSpace(param), 64)
+				if err != nil || math.IsNaN(quantiles[i]) || quantiles[i] < 0 || quantiles[i] > 1 {
+					invalidSketchQuery(w, logger, errors.New("q must be between 0 and 1, got "+param))
+					return
+				}
+			}
+		}
+		resp, err := s.sketches.Quantiles(query, quantiles)
+		if err != nil {
+			invalidSketchQuery(w, logger, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func generateTestSketchSettings() SketchSettings {
+	return SketchSettings{BucketSeconds: 3600, RetentionInHours: 24, DistinctKeys: []string{"user_id"}, QuantileValues: []string{"app_start", "kv:retries", "kv:user_id"}, HLLPrecision: 14, TDigestCompression: 100}
+}
+
+func generateTestSketchEvent(eventType model.ClientEventType, timestamp int64, userId string, appStart float64) *model.ClientEventData {
+	return &model.ClientEventData{
+		EventId:      userId,
+		EventType:    eventType,
+		Timestamp:    proto.Int64(timestamp),
+		KvPair:       []*model.KeyValuePair{{Key: "user_id", Value: proto.String(userId)}, {Key: "retries", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 2}}}},
+		Measurements: []*model.Measurement{{Name: "app_start", Value: appStart}},
+	}
+}
+
+func TestSketches_DistinctCount(t *testing.T) {
+	sk := NewSketches(generateTestSketchSettings())
+	now := time.Unix(100000, 0)
+	sk.now = func() time.Time { return now }
+
+	for i := 0; i < 1000; i++ {
+		sk.Observe(generateTestSketchEvent(model.ClientEventType_USER_REGISTERED, 91000, "user"+strconv.Itoa(i), 1))
+		sk.Observe(generateTestSketchEvent(model.ClientEventType_UNKNOWN, 97200, "user"+strconv.Itoa(i%100), 1))
+	}
+
+	resp := sk.DistinctCount(sketchQuery{name: "user_id"})
+	assert.Equal(t, int64(3600), resp.GetBucketSeconds())
+	if assert.Equal(t, 2, len(resp.GetBuckets())) {
+		assert.Equal(t, int64(90000), resp.GetBuckets()[0].GetStartTimestamp())
+		assertInDelta(t, 1000, float64(resp.GetBuckets()[0].GetEstimate()), 1000*3*resp.GetRelativeStandardError(), "Distinct users of the first bucket")
+		assert.Equal(t, uint64(100), resp.GetBuckets()[1].GetEstimate(), "Repeated values should count once")
+	}
+	assertInDelta(t, 1000, float64(resp.GetTotalEstimate()), 1000*3*resp.GetRelativeStandardError(), "Users of both buckets should count once")
+
+	resp = sk.DistinctCount(sketchQuery{name: "user_id", eventTypes: []model.ClientEventType{model.ClientEventType_UNKNOWN}})
+	assert.Equal(t, uint64(100), resp.GetTotalEstimate(), "Other event types should not be counted")
+	from, to := int64(91000), int64(97200)
+	resp = sk.DistinctCount(sketchQuery{name: "user_id", from: &from, to: &to})
+	assert.Equal(t, 1, len(resp.GetBuckets()), "The bucket holding from should be included and buckets from to on excluded")
+
+	sk.Observe(generateTestSketchEvent(model.ClientEventType_UNKNOWN, 3600, "late", 1))
+	assert.Equal(t, 2, len(sk.DistinctCount(sketchQuery{name: "user_id"}).GetBuckets()), "Events older than the retention should not be added")
+	now = now.Add(24 * time.Hour)
+	sk.Observe(generateTestSketchEvent(model.ClientEventType_UNKNOWN, now.Unix(), "user1", 1))
+	resp = sk.DistinctCount(sketchQuery{name: "user_id"})
+	if assert.Equal(t, 2, len(resp.GetBuckets()), "Buckets older than the retention should be dropped") {
+		assert.Equal(t, int64(97200), resp.GetBuckets()[0].GetStartTimestamp())
+	}
+}
+
+func TestSketches_Quantiles(t *testing.T) {
+	sk := NewSketches(generateTestSketchSettings())
+	sk.now = func() time.Time { return time.Unix(100000, 0) }
+	for i := 1; i <= 100; i++ {
+		sk.Observe(generateTestSketchEvent(model.ClientEventType_UNKNOWN, 91000, "user"+strconv.Itoa(i), float64(i)))
+	}
+	histogram := &model.Measurement{Name: "app_start", Histogram: &model.Histogram{UpperBounds: []float64{1000}, BucketCounts: []uint64{0, 100}, Sum: 150000, Min: proto.Float64(1000), Max: proto.Float64(2000)}}
+	sk.Observe(&model.ClientEventData{EventId: "histogram", Timestamp: proto.Int64(97200), Measurements: []*model.Measurement{histogram}})
+
+	resp, _ := sk.Quantiles(sketchQuery{name: "app_start"}, []float64{0.5, 0.99})
+	assert.Equal(t, uint64(200), resp.GetTotalCount())
+	if assert.Equal(t, 2, len(resp.GetBuckets())) {
+		first := resp.GetBuckets()[0]
+		assert.Equal(t, uint64(100), first.GetCount())
+		assertInDelta(t, 50, first.GetQuantiles()[0].GetValue(), 100*first.GetQuantiles()[0].GetRankError()+1, "Median of the first bucket")
+		assert.Equal(t, 0.99, first.GetQuantiles()[1].GetQuantile())
+		assertInDelta(t, 1500, resp.GetBuckets()[1].GetQuantiles()[0].GetValue(), 1, "Histogram buckets should be added at their middle")
+	}
+	assert.Equal(t, 2, len(resp.GetTotal()))
+
+	resp, _ = sk.Quantiles(sketchQuery{name: "kv:retries"}, defaultSketchQuantiles)
+	assert.Equal(t, uint64(100), resp.GetTotalCount(), "Numeric kv pairs should be sketched")
+	assert.Equal(t, float64(2), resp.GetTotal()[0].GetValue())
+	resp, _ = sk.Quantiles(sketchQuery{name: "kv:user_id"}, defaultSketchQuantiles)
+	assert.Equal(t, uint64(0), resp.GetTotalCount(), "String kv pairs should not be sketched")
+
+	event := generateTestSketchEvent(model.ClientEventType_UNKNOWN, 91000, "user1", 1)
+	event.Measurements = append(event.Measurements, &model.Measurement{Name: "frame_time", Value: 16})
+	event.KvPair = append(event.KvPair, &model.KeyValuePair{Key: "amount", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: 2.5}}})
+	sk.Observe(event)
+	resp, _ = sk.Quantiles(sketchQuery{name: "frame_time"}, defaultSketchQuantiles)
+	assert.Equal(t, uint64(0), resp.GetTotalCount(), "Only configured measurements should be sketched")
+	resp, _ = sk.Quantiles(sketchQuery{name: "kv:amount"}, defaultSketchQuantiles)
+	assert.Equal(t, uint64(0), resp.GetTotalCount(), "Only configured kv pairs should be sketched")
+	assert.Equal(t, 3, len(sk.quantiles), "No digests should be kept for values that aren't configured")
+}
+
+func TestSketches_QuantilesMixedUnits(t *testing.T) {
+	sk := NewSketches(generateTestSketchSettings())
+	sk.now = func() time.Time { return time.Unix(100000, 0) }
+	for i, unit := range []string{"ms", "ms", "s"} {
+		event := generateTestSketchEvent(model.ClientEventType_UNKNOWN, 91000, "user"+strconv.Itoa(i), 2)
+		event.Measurements[0].Unit = unit
+		event.Measurements[0].Kind = model.MeasurementKind_MEASUREMENT_KIND_TIMING
+		sk.Observe(event)
+	}
+
+	_, err := sk.Quantiles(sketchQuery{name: "app_start"}, defaultSketchQuantiles)
+	assert.NotNil(t, err, "Quantiles of several units should not be merged")
+
+	unit := "ms"
+	resp, err := sk.Quantiles(sketchQuery{name: "app_start", unit: &unit}, defaultSketchQuantiles)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), resp.GetTotalCount(), "Only the measurements of the unit should be counted")
+	assert.Equal(t, "ms", resp.GetUnit())
+	assert.Equal(t, model.MeasurementKind_MEASUREMENT_KIND_TIMING, resp.GetKind())
+}
+
+func TestSketchHandlers(t *testing.T) {
+	config := DefaultConfig()
+	config.Sketches.QuantileValues = []string{"app_start"}
+	s := newTestServer(t, WithConfig(config))
+	uploadRequest := generateTestClientEventUploadRequest_Valid()
+	uploadRequest.Events[0].KvPair = []*model.KeyValuePair{{Key: "user_id", Value: proto.String("42")}}
+	uploadRequest.Events[0].Measurements = []*model.Measurement{{Name: "app_start", Value: 840}}
+	assert.NoError(t, s.storeUploadRequest(uploadRequest), "Error storing events")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/metrics/sketches/distinct?key=user_id&event_type=UNKNOWN")
+	assert.Equal(t, http.StatusOK, w.Code)
+	distinct := new(model.DistinctCountResponse)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), distinct), "Response should be a DistinctCountResponse")
+	assert.Equal(t, uint64(1), distinct.GetTotalEstimate())
+
+	w = get("/metrics/sketches/quantiles?value=app_start&q=0.5")
+	assert.Equal(t, http.StatusOK, w.Code)
+	quantiles := new(model.QuantileResponse)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), quantiles), "Response should be a QuantileResponse")
+	if assert.Equal(t, 1, len(quantiles.GetTotal())) {
+		assert.Equal(t, float64(840), quantiles.GetTotal()[0].GetValue())
+	}
+	assert.Equal(t, http.StatusOK, get("/metrics/sketches/quantiles?value=app_start&unit=&kind=MEASUREMENT_KIND_UNSPECIFIED").Code)
+
+	for _, path := range []string{
+		"/metrics/sketches/distinct?key=country",
+		"/metrics/sketches/distinct?key=user_id&device_type=ios",
+		"/metrics/sketches/quantiles",
+		"/metrics/sketches/quantiles?value=frame_time",
+		"/metrics/sketches/quantiles?value=app_start&q=1.5",
+		"/metrics/sketches/quantiles?value=app_start&from=20&to=10",
+		"/metrics/sketches/quantiles?value=app_start&kind=meow",
+	} {
+		assert.Equal(t, http.StatusBadRequest, get(path).Code, "Invalid query should be rejected: "+path)
+	}
+}
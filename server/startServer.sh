@@ -1,3 +1,3 @@
 #!/bin/bash
 
-go run server.go handlers.go utilities.go processor.go datasource.go
\ No newline at end of file
+go build -o meowtrics ./cmd/meowtrics && ./meowtrics serve "$@"
\ No newline at end of file
@@ -0,0 +1,379 @@
+package server
+
+import (
+	"errors"
+	"meowtrics/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const TEXT_EVENT_STREAM = "text/event-stream"
+
+//Reasons subscribers are disconnected for, the label of meowtrics_stream_disconnects_total
+const (
+	streamDisconnectSlow      = "slow_consumer"
+	streamDisconnectClient    = "client"
+	streamDisconnectShutdown  = "shutdown"
+	streamDisconnectTransport = "write_error"
+)
+
+var (
+	StreamClosedError       = errors.New("Event stream is closed")
+	StreamFullError         = errors.New("Too many event stream subscribers")
+	StreamSlowConsumerError = errors.New("Event stream subscriber fell behind")
+	InvalidLastEventIdError = errors.New("Last-Event-ID must be a number")
+)
+
+//Event stream subscribers and the replay buffer
+type StreamSettings struct {
+	BufferSize         int
+	ReplaySize         int
+	MaxSubscribers     int
+	KeepAliveInSeconds int
+	WebSocketEnabled   bool
+}
+
+//One client of the event stream. Its events channel is closed when it is unsubscribed, err tells why.
+type streamSubscriber struct {
+	filter *model.EventFilter
+	events chan *model.StreamedEvent
+	err    error
+}
+
+/*
+Fans stored events out to the subscribers of GET /v1/events/stream. Every subscriber has a buffer of BufferSize
+events, a subscriber whose buffer is full is disconnected rather than slowing down ingestion. The last ReplaySize
+events are kept so that a reconnecting client can resume after the id it saw last.
+*/
+type EventStream struct {
+	settings    StreamSettings
+	disconnects *CounterVec
+
+	mutex       sync.Mutex
+	lastId      uint64
+	recent      []*model.StreamedEvent
+	subscribers map[*streamSubscriber]struct{}
+	closed      bool
+}
+
+//Disconnected subscribers are counted by reason in disconnects
+func NewEventStream(settings StreamSettings, disconnects *CounterVec) *EventStream {
+	return &EventStream{settings: settings, disconnects: disconnects, subscribers: make(map[*streamSubscriber]struct{})}
+}
+
+//Numbers a stored event and hands it to every subscriber whose filter it matches, without blocking
+func (es *EventStream) Publish(event *model.ClientEventData, deviceType string) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	es.lastId++
+	streamed := &model.StreamedEvent{Id: es.lastId, DeviceType: deviceType, Event: event}
+	if es.settings.ReplaySize > 0 {
+		es.recent = append(es.recent, streamed)
+		if over := len(es.recent) - es.settings.ReplaySize; over > 0 {
+			es.recent = es.recent[over:]
+		}
+	}
+
+	for subscriber := range es.subscribers {
+		if !eventFilterMatches(subscriber.filter, event, deviceType) {
+			continue
+		}
+		select {
+		case subscriber.events <- streamed:
+		default:
+			es.remove(subscriber, StreamSlowConsumerError, streamDisconnectSlow)
+		}
+	}
+}
+
+/*
+Adds a subscriber for the events matching filter. With resume set, the kept events after lastEventId that match the
+filter are returned to be sent before the ones arriving on the subscriber's channel, with nothing missed or repeated
+in between. Events older than the replay buffer can't be resumed, nor can ids above the last one after a restart:
+the replay then starts with a missed_events message whose id is the one before the oldest kept event.
+*/
+func (es *EventStream) Subscribe(filter *model.EventFilter, lastEventId uint64, resume bool) ([]*model.StreamedEvent, *streamSubscriber, error) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	if es.closed {
+		return nil, nil, StreamClosedError
+	}
+	if es.settings.MaxSubscribers > 0 && len(es.subscribers) >= es.settings.MaxSubscribers {
+		return nil, nil, StreamFullError
+	}
+
+	var replay []*model.StreamedEvent
+	if resume {
+		oldest := es.lastId + 1
+		if len(es.recent) > 0 {
+			oldest = es.recent[0].GetId()
+		}
+		if lastEventId > es.lastId || lastEventId+1 < oldest {
+			lastEventId = oldest - 1
+			replay = append(replay, &model.StreamedEvent{Id: lastEventId, MissedEvents: true})
+		}
+		for _, streamed := range es.recent {
+			if streamed.GetId() > lastEventId && eventFilterMatches(filter, streamed.GetEvent(), streamed.GetDeviceType()) {
+				replay = append(replay, streamed)
+			}
+		}
+	}
+	subscriber := &streamSubscriber{filter: filter, events: make(chan *model.StreamedEvent, es.settings.BufferSize)}
+	es.subscribers[subscriber] = struct{}{}
+	return replay, subscriber, nil
+}
+
+//Removes a subscriber that went away, reason labels the disconnect
+func (es *EventStream) Unsubscribe(subscriber *streamSubscriber, reason string) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.remove(subscriber, nil, reason)
+}
+
+//Callers hold es.mutex
+func (es *EventStream) remove(subscriber *streamSubscriber, err error, reason string) {
+	if _, ok := es.subscribers[subscriber]; !ok {
+		return
+	}
+	delete(es.subscribers, subscriber)
+	subscriber.err = err
+	close(subscriber.events)
+	es.disconnects.Inc(reason)
+}
+
+func (es *EventStream) Subscribers() int {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	return len(es.subscribers)
+}
+
+//Disconnects every subscriber and turns new ones away, so that open streams don't hold up the shutdown
+func (es *EventStream) Close() error {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	es.closed = true
+	for subscriber := range es.subscribers {
+		es.remove(subscriber, StreamClosedError, streamDisconnectShutdown)
+	}
+	return nil
+}
+
+func (s *Server) streamSubscribers() int {
+	if s.stream == nil {
+		return 0
+	}
+	return s.stream.Subscribers()
+}
+
+//Last-Event-ID is read from the header EventSource sends on reconnects, or from the lastEventId query parameter
+func lastEventId(req *http.Request) (uint64, bool, error) {
+	value := req.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = req.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, InvalidLastEventIdError
+	}
+	return id, true, nil
+}
+
+/*
+Pushes newly stored events matching the event_type, device_type and kv_pair query parameters. Served as
+Server-Sent Events, or as a WebSocket when the request asks for an upgrade and streamWebSocketEnabled is set.
+Every message is a JSON StreamedEvent.
+*/
+func (s *Server) StreamEventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := s.RequestLogger(req).WithFields(log.Fields{"method": "StreamEventsHandler"})
+
+		filter, err := parseEventFilter(req.URL.Query())
+		if err != nil {
+			invalidStreamParameters(w, err)
+			return
+		}
+		lastId, resume, err := lastEventId(req)
+		if err != nil {
+			invalidStreamParameters(w, err)
+			return
+		}
+		s.serveEventStream(w, req, filter, lastId, resume, logger)
+	})
+}
+
+func invalidStreamParameters(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid stream parameters", Description: err.Error()})
+}
+
+func (s *Server) serveEventStream(w http.ResponseWriter, req *http.Request, filter *model.EventFilter, lastId uint64, resume bool, logger *log.Entry) {
+	webSocket := strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+	if webSocket && !s.config.Stream.WebSocketEnabled {
+		writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "WebSocket streaming is disabled, use Server-Sent Events"})
+		return
+	}
+	if webSocket {
+		if err := checkWebSocketHandshake(req); err != nil {
+			writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: HeaderNotRecognized, ErrorMessage: "Invalid WebSocket handshake", Description: err.Error()})
+			return
+		}
+	}
+
+	replay, subscriber, err := s.stream.Subscribe(filter, lastId, resume)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err.Error()}).Warningln("Rejected event stream subscriber")
+
+		writeJSON(w, http.StatusServiceUnavailable, &model.ErrorResponse{Code: Unavailable, ErrorMessage: err.Error()})
+		return
+	}
+
+	var sink streamSink
+	if webSocket {
+		sink, err = upgradeWebSocket(w, req)
+	} else {
+		sink, err = newServerSentEvents(w)
+	}
+	if err != nil {
+		s.stream.Unsubscribe(subscriber, streamDisconnectTransport)
+		logger.WithFields(log.Fields{"error": err.Error()}).Warningln("Error opening event stream")
+		return
+	}
+	defer sink.Close()
+	logger.WithFields(log.Fields{"webSocket": webSocket, "replayed": len(replay)}).Infoln("Event stream opened")
+
+	reason := s.pumpEventStream(sink, replay, subscriber)
+	s.stream.Unsubscribe(subscriber, reason)
+	if subscriber.err != nil {
+		sink.Fail(subscriber.err.Error())
+		logger.WithFields(log.Fields{"error": subscriber.err.Error()}).Infoln("Event stream closed")
+		return
+	}
+	logger.WithFields(log.Fields{"reason": reason}).Infoln("Event stream closed")
+}
+
+//Writes events to the sink until the subscriber is removed, the client goes away or a write fails
+func (s *Server) pumpEventStream(sink streamSink, replay []*model.StreamedEvent, subscriber *streamSubscriber) string {
+	for _, streamed := range replay {
+		if err := sendStreamedEvent(sink, streamed); err != nil {
+			return streamDisconnectTransport
+		}
+	}
+
+	keepAlive := time.NewTicker(time.Duration(s.config.Stream.KeepAliveInSeconds) * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case streamed, ok := <-subscriber.events:
+			if !ok {
+				return ""
+			}
+			if err := sendStreamedEvent(sink, streamed); err != nil {
+				return streamDisconnectTransport
+			}
+		case <-keepAlive.C:
+			if err := sink.KeepAlive(); err != nil {
+				return streamDisconnectTransport
+			}
+		case <-sink.Done():
+			return streamDisconnectClient
+		}
+	}
+}
+
+func sendStreamedEvent(sink streamSink, streamed *model.StreamedEvent) error {
+	data, err := model.MarshalJSON(streamed)
+	if err != nil {
+		return err
+	}
+	name := "event"
+	if streamed.GetMissedEvents() {
+		name = "reset"
+	}
+	return sink.Send(streamed.GetId(), name, data)
+}
+
+//The transport of one event stream subscriber
+type streamSink interface {
+	//Name is the Server-Sent Events type, "event" or "reset"
+	Send(id uint64, name string, data []byte) error
+	KeepAlive() error
+	//Tells the client why the server ends the stream
+	Fail(message string)
+	//Closed when the client disconnects
+	Done() <-chan struct{}
+	Close() error
+}
+
+type serverSentEvents struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    chan struct{}
+	stop    chan struct{}
+}
+
+func newServerSentEvents(w http.ResponseWriter) (*serverSentEvents, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("Response writer can't flush")
+	}
+	sse := &serverSentEvents{w: w, flusher: flusher, done: make(chan struct{}), stop: make(chan struct{})}
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed := notifier.CloseNotify()
+		go func() {
+			select {
+			case <-closed:
+				close(sse.done)
+			case <-sse.stop:
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", TEXT_EVENT_STREAM)
+	w.Header().Set("Cache-Control", "no-cache")
+	//Stops nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return sse, nil
+}
+
+const streamKeepAliveComment = ": keep-alive\n\n"
+
+func (sse *serverSentEvents) write(data []byte) error {
+	if _, err := sse.w.Write(data); err != nil {
+		return err
+	}
+	sse.flusher.Flush()
+	return nil
+}
+
+func (sse *serverSentEvents) Send(id uint64, name string, data []byte) error {
+	return sse.write([]byte("id: " + strconv.FormatUint(id, 10) + "\nevent: " + name + "\ndata: " + string(data) + "\n\n"))
+}
+
+func (sse *serverSentEvents) KeepAlive() error {
+	return sse.write([]byte(streamKeepAliveComment))
+}
+
+func (sse *serverSentEvents) Fail(message string) {
+	sse.write([]byte("event: error\ndata: " + message + "\n\n"))
+}
+
+func (sse *serverSentEvents) Done() <-chan struct{} {
+	return sse.done
+}
+
+func (sse *serverSentEvents) Close() error {
+	close(sse.stop)
+	return nil
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"meowtrics/model"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestStreamSettings(bufferSize int) StreamSettings {
+	return StreamSettings{BufferSize: bufferSize, ReplaySize: 10, KeepAliveInSeconds: 60, WebSocketEnabled: true}
+}
+
+func TestParseEventFilter(t *testing.T) {
+	filter, err := parseEventFilter(url.Values{"event_type": {"user_registered", "1"}, "device_type": {"ios"}, "kv_pair": {"country:NZ"}})
+	assert.NoError(t, err, "Valid filter should be parsed")
+	assert.Equal(t, []model.ClientEventType{model.ClientEventType_USER_REGISTERED, model.ClientEventType_UNKNOWN}, filter.GetEventTypes())
+	assert.Equal(t, []string{"ios"}, filter.GetDeviceTypes())
+	assert.Equal(t, "NZ", filter.GetKvPairs()[0].GetValue())
+
+	_, err = parseEventFilter(url.Values{"event_type": {"MEOW"}})
+	assert.Error(t, err, "Unknown event types should be rejected")
+	_, err = parseEventFilter(url.Values{"kv_pair": {"country"}})
+	assert.Error(t, err, "kv_pair without a value should be rejected")
+	_, err = parseEventFilter(url.Values{"kv_pair": {"duration!100"}})
+	assert.Error(t, err, "Unknown comparisons should be rejected")
+}
+
+func TestParseEventFilter_Comparisons(t *testing.T) {
+	filter, err := parseEventFilter(url.Values{"kv_pair": {"duration>=100", "retries<3", "country!=NZ"}})
+	assert.NoError(t, err, "Comparisons should be parsed")
+	assert.Equal(t, 0, len(filter.GetKvPairs()), "Comparisons should not be added as pairs")
+
+	conditions := filter.GetKvConditions()
+	assert.Equal(t, 3, len(conditions))
+	assert.Equal(t, "duration", conditions[0].GetKey())
+	assert.Equal(t, model.Comparison_COMPARISON_GREATER_OR_EQUAL, conditions[0].GetComparison())
+	assert.Equal(t, int64(100), conditions[0].GetValue().GetIntValue(), "Numbers of ordering comparisons should be typed")
+	assert.Equal(t, model.Comparison_COMPARISON_LESS, conditions[1].GetComparison())
+	assert.Equal(t, int64(3), conditions[1].GetValue().GetIntValue())
+	assert.Equal(t, model.Comparison_COMPARISON_NOT_EQUAL, conditions[2].GetComparison())
+	assert.Equal(t, "NZ", conditions[2].GetValue().GetStringValue())
+
+	filter, err = parseEventFilter(url.Values{"kv_pair": {"ratio>0.5", "zip!=02134", "version>=1.2.3"}})
+	assert.NoError(t, err)
+	conditions = filter.GetKvConditions()
+	assert.Equal(t, 0.5, conditions[0].GetValue().GetDoubleValue())
+	assert.Equal(t, "02134", conditions[1].GetValue().GetStringValue(), "Values of != should stay strings")
+	assert.Equal(t, "1.2.3", conditions[2].GetValue().GetStringValue())
+}
+
+func TestEventStream_FiltersAndResumes(t *testing.T) {
+	es := NewEventStream(generateTestStreamSettings(10), NewCounterVec("test_stream_disconnects_total", "", "reason"))
+	event := generateTestClientEvent()
+
+	es.Publish(event, "ios")
+	es.Publish(event, "android")
+	es.Publish(event, "ios")
+
+	replay, subscriber, err := es.Subscribe(&model.EventFilter{DeviceTypes: []string{"ios"}}, 1, true)
+	assert.NoError(t, err, "Error subscribing")
+	if assert.Equal(t, 1, len(replay), "Only matching events after Last-Event-ID should be replayed") {
+		assert.Equal(t, uint64(3), replay[0].GetId())
+	}
+
+	es.Publish(event, "android")
+	es.Publish(event, "ios")
+	streamed := <-subscriber.events
+	assert.Equal(t, uint64(5), streamed.GetId(), "Only matching events should be pushed")
+	assert.Equal(t, "ios", streamed.GetDeviceType())
+
+	es.Unsubscribe(subscriber, streamDisconnectClient)
+	assert.Equal(t, 0, es.Subscribers())
+	assert.Equal(t, float64(1), es.disconnects.Value(streamDisconnectClient))
+}
+
+func TestEventStream_ResetsOnGaps(t *testing.T) {
+	es := NewEventStream(generateTestStreamSettings(10), NewCounterVec("test_stream_disconnects_total", "", "reason"))
+	es.settings.ReplaySize = 2
+	for i := 0; i < 4; i++ {
+		es.Publish(generateTestClientEvent(), "ios")
+	}
+
+	replay, _, err := es.Subscribe(nil, 2, true)
+	assert.NoError(t, err, "Error subscribing")
+	assert.Equal(t, 2, len(replay), "Resuming within the replay buffer should not reset")
+	assert.False(t, replay[0].GetMissedEvents())
+
+	replay, _, _ = es.Subscribe(nil, 1, true)
+	if assert.Equal(t, 3, len(replay), "Resuming before the replay buffer should reset") {
+		assert.True(t, replay[0].GetMissedEvents())
+		assert.Equal(t, uint64(2), replay[0].GetId(), "Reset should carry the id before the oldest kept event")
+		assert.Nil(t, replay[0].GetEvent())
+		assert.Equal(t, uint64(3), replay[1].GetId())
+	}
+
+	replay, _, _ = es.Subscribe(nil, 40, true)
+	if assert.Equal(t, 3, len(replay), "Resuming after an unknown id should reset") {
+		assert.True(t, replay[0].GetMissedEvents())
+		assert.Equal(t, uint64(2), replay[0].GetId())
+	}
+
+	replay, _, _ = es.Subscribe(nil, 4, true)
+	assert.Equal(t, 0, len(replay), "Resuming after the last id should replay nothing")
+}
+
+func TestEventStream_DisconnectsSlowConsumers(t *testing.T) {
+	es := NewEventStream(generateTestStreamSettings(1), NewCounterVec("test_stream_disconnects_total", "", "reason"))
+	_, subscriber, err := es.Subscribe(nil, 0, false)
+	assert.NoError(t, err, "Error subscribing")
+
+	event := generateTestClientEvent()
+	es.Publish(event, "ios")
+	es.Publish(event, "ios")
+
+	<-subscriber.events
+	_, open := <-subscriber.events
+	assert.False(t, open, "Subscriber with a full buffer should be disconnected")
+	assert.Equal(t, StreamSlowConsumerError, subscriber.err)
+	assert.Equal(t, float64(1), es.disconnects.Value(streamDisconnectSlow))
+
+	es.Close()
+	_, _, err = es.Subscribe(nil, 0, false)
+	assert.Equal(t, StreamClosedError, err, "Closed stream should turn subscribers away")
+}
+
+//Waits until the server has registered the subscriber, so that events posted afterwards reach it
+func waitForSubscribers(t *testing.T, s *Server, count int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for s.stream.Subscribers() != count {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscriber was not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamEventsHandler_ServerSentEvents(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/events/stream?device_type=testDeviceAndroid")
+	assert.NoError(t, err, "Error opening stream")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, TEXT_EVENT_STREAM, res.Header.Get("Content-Type"))
+	waitForSubscribers(t, s, 1)
+
+	postTestEvent(t, s.Handler())
+	reader := bufio.NewReader(res.Body)
+	id, _ := reader.ReadString('\n')
+	assert.Equal(t, "id: 1\n", id)
+	eventName, _ := reader.ReadString('\n')
+	assert.Equal(t, "event: event\n", eventName)
+	data, _ := reader.ReadString('\n')
+
+	streamed := new(model.StreamedEvent)
+	assert.NoError(t, model.UnmarshalJSON([]byte(strings.TrimPrefix(data, "data: ")), streamed), "Error unmarshalling event")
+	assert.Equal(t, "testDeviceAndroid", streamed.GetDeviceType())
+	assert.Equal(t, "123", streamed.GetEvent().GetEventId())
+
+	resumed, err := http.Get(ts.URL + "/v1/events/stream?lastEventId=40")
+	assert.NoError(t, err, "Error opening stream")
+	defer resumed.Body.Close()
+	reader = bufio.NewReader(resumed.Body)
+	id, _ = reader.ReadString('\n')
+	assert.Equal(t, "id: 0\n", id, "Unknown ids should be reset to the one before the oldest kept event")
+	eventName, _ = reader.ReadString('\n')
+	assert.Equal(t, "event: reset\n", eventName)
+
+	req, _ := http.NewRequest("GET", "/v1/events/stream?event_type=MEOW", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid filters should be rejected")
+}
+
+func TestStreamEventsHandler_WebSocket(t *testing.T) {
+	config := DefaultConfig()
+	config.Stream.WebSocketEnabled = true
+	s := newTestServer(t, WithConfig(config))
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	assert.NoError(t, err, "Error connecting")
+	defer conn.Close()
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	conn.Write([]byte("GET /v1/events/stream HTTP/1.1\r\nHost: meowtrics\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+	assert.NoError(t, err, "Error reading handshake")
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+	waitForSubscribers(t, s, 1)
+
+	postTestEvent(t, s.Handler())
+	header := make([]byte, 2)
+	_, err = io.ReadFull(reader, header)
+	assert.NoError(t, err, "Error reading frame")
+	assert.Equal(t, byte(0x80|webSocketOpText), header[0], "Event should be sent as one text frame")
+	payload := make([]byte, header[1]&0x7F)
+	if header[1]&0x7F == 126 {
+		length := make([]byte, 2)
+		io.ReadFull(reader, length)
+		payload = make([]byte, int(length[0])<<8|int(length[1]))
+	}
+	_, err = io.ReadFull(reader, payload)
+	assert.NoError(t, err, "Error reading payload")
+
+	streamed := new(model.StreamedEvent)
+	assert.NoError(t, model.UnmarshalJSON(payload, streamed), "Error unmarshalling event")
+	assert.Equal(t, uint64(1), streamed.GetId())
+
+	assert.NoError(t, s.Shutdown(), "Error shutting down")
+	assert.Equal(t, float64(1), s.metrics.streamDisconnectsTotal.Value(streamDisconnectShutdown), "Shutdown should close open streams")
+}
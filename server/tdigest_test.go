@@ -0,0 +1,55 @@
+package server
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTDigest_Quantile(t *testing.T) {
+	digest := NewTDigest(100)
+	assert.Equal(t, float64(0), digest.Quantile(0.5), "Empty digest should return 0")
+
+	random := rand.New(rand.NewSource(42))
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = random.ExpFloat64() * 100
+		digest.Add(values[i], 1)
+	}
+	sort.Float64s(values)
+
+	assert.Equal(t, float64(len(values)), digest.Count())
+	assert.Equal(t, values[0], digest.Quantile(0), "q=0 should be the min")
+	assert.Equal(t, values[len(values)-1], digest.Quantile(1), "q=1 should be the max")
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.95, 0.99, 0.999} {
+		estimate := digest.Quantile(q)
+		rank := float64(sort.SearchFloat64s(values, estimate)) / float64(len(values))
+		assertInDelta(t, q, rank, digest.RankError(q), "Rank of the estimate should be within the rank error")
+	}
+	assert.True(t, len(digest.centroids) < 200, "Centroids should be bounded by the compression")
+}
+
+func TestTDigest_Merge(t *testing.T) {
+	low, high := NewTDigest(100), NewTDigest(100)
+	for i := 1; i <= 1000; i++ {
+		low.Add(float64(i), 1)
+		high.Add(float64(i+1000), 1)
+	}
+	high.Add(5000, 0)
+	low.Merge(high)
+
+	assert.Equal(t, float64(2000), low.Count())
+	assertInDelta(t, 1000, low.Quantile(0.5), 2000*low.RankError(0.5), "Median of the merged digest")
+	assert.Equal(t, float64(2000), low.Quantile(1), "Values with no weight should be ignored")
+}
+
+func TestTDigest_WeightedValues(t *testing.T) {
+	digest := NewTDigest(100)
+	digest.Add(10, 90)
+	digest.Add(100, 10)
+	assert.Equal(t, float64(100), digest.Count())
+	assert.Equal(t, float64(10), digest.Quantile(0.45), "Weight should count as repeated values")
+	assert.Equal(t, float64(100), digest.Quantile(0.99))
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+var (
+	MissingCertificateError = errors.New("TLS is enabled but tlsCertFile or tlsKeyFile is not set")
+	InvalidClientCAError    = errors.New("No certificates could be parsed from the client CA bundle")
+)
+
+//Keeps the serving certificate in memory and reloads it from disk when the files are rotated,
+//modification times are checked at most once per checkInterval
+type certificateReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+	logger        *log.Logger
+
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+	now       func() time.Time
+}
+
+func newCertificateReloader(certFile string, keyFile string, checkInterval time.Duration, logger *log.Logger) (*certificateReloader, error) {
+	cr := &certificateReloader{certFile: certFile, keyFile: keyFile, checkInterval: checkInterval, logger: logger, now: time.Now}
+	modTime, err := cr.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.load(modTime); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func (cr *certificateReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (cr *certificateReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+	cr.cert = &cert
+	cr.modTime = modTime
+	return nil
+}
+
+//Used as tls.Config.GetCertificate, a failed reload keeps serving the previous certificate
+func (cr *certificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	now := cr.now()
+	if now.Sub(cr.lastCheck) < cr.checkInterval {
+		return cr.cert, nil
+	}
+	cr.lastCheck = now
+
+	modTime, err := cr.latestModTime()
+	if err != nil {
+		cr.logger.WithFields(log.Fields{"method": "GetCertificate", "error": err.Error()}).Warningln("Error checking certificate files, keeping current certificate")
+		return cr.cert, nil
+	}
+	if !modTime.After(cr.modTime) {
+		return cr.cert, nil
+	}
+
+	if err := cr.load(modTime); err != nil {
+		cr.logger.WithFields(log.Fields{"method": "GetCertificate", "error": err.Error()}).Warningln("Error reloading rotated certificate, keeping current certificate")
+		return cr.cert, nil
+	}
+
+	cr.logger.WithFields(log.Fields{"method": "GetCertificate", "certFile": cr.certFile}).Infoln("Reloaded rotated certificate")
+	return cr.cert, nil
+}
+
+//Builds the TLS config from the TLS settings, returns nil when TLS is disabled.
+//Setting tlsClientCAFile turns on mutual TLS and requires client certificates signed by that bundle.
+func LoadTLSConfig(settings TLSSettings, logger *log.Logger) (*tls.Config, error) {
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	if settings.CertFile == "" || settings.KeyFile == "" {
+		return nil, MissingCertificateError
+	}
+
+	reloadInterval := time.Duration(settings.CertReloadIntervalInSeconds) * time.Second
+	reloader, err := newCertificateReloader(settings.CertFile, settings.KeyFile, reloadInterval, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	clientCAFile := settings.ClientCAFile
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, InvalidClientCAError
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		logger.WithFields(log.Fields{"method": "LoadTLSConfig", "clientCAFile": clientCAFile}).Infoln("Mutual TLS enabled")
+	}
+
+	return config, nil
+}
+
+//Subject of the verified client certificate, e.g. "CN=meow-client,O=Meowtrics", empty without mutual TLS. Unlike the
+//fingerprint it stays the same when the certificate is renewed, so it is what the admin and gRPC auth allow.
+func CertificateSubject(req *http.Request) string {
+	return verifiedSubject(req.TLS)
+}
+
+func verifiedSubject(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.String()
+}
+
+//Identifies the caller by the SHA-256 fingerprint of a verified client certificate, falling back to the X-API-Key
+//header. A verified certificate wins so a client can't pick another identity by sending a key.
+func CallerIdentity(req *http.Request) string {
+	if identity := certificateIdentity(req.TLS); identity != "" {
+		return identity
+	}
+	return req.Header.Get(API_KEY_HEADER)
+}
+
+func certificateIdentity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	fingerprint := sha256.Sum256(state.VerifiedChains[0][0].Raw)
+	return "cert:" + hex.EncodeToString(fingerprint[:])
+}
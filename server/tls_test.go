@@ -0,0 +1,144 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//Writes a self signed certificate and its key to dir, returns the parsed certificate
+func generateTestCertificate(t *testing.T, dir string, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := ioutil.WriteFile(filepath.Join(dir, "cert.pem"), certPem, 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "key.pem"), keyPem, 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return cert
+}
+
+func TestCertificateReloader_ReloadsRotatedCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-tls")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	generateTestCertificate(t, dir, "first")
+	reloader, err := newCertificateReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), time.Minute, testLogger)
+	assert.NoError(t, err, "Error loading certificate")
+
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+	cert, err := reloader.GetCertificate(nil)
+	assert.NoError(t, err, "Error getting certificate")
+	first := cert.Certificate[0]
+
+	generateTestCertificate(t, dir, "second")
+	future := now.Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "cert.pem"), future, future)
+
+	cert, _ = reloader.GetCertificate(nil)
+	assert.Equal(t, first, cert.Certificate[0], "Certificate should not be reloaded before the check interval")
+
+	now = now.Add(time.Minute)
+	cert, _ = reloader.GetCertificate(nil)
+	assert.NotEqual(t, first, cert.Certificate[0], "Rotated certificate should be reloaded")
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-tls")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	generateTestCertificate(t, dir, "meowtrics")
+
+	config, err := LoadTLSConfig(TLSSettings{}, testLogger)
+	assert.NoError(t, err, "Disabled TLS should not return an error")
+	assert.Nil(t, config, "Disabled TLS should not return a config")
+
+	settings := TLSSettings{Enabled: true}
+	_, err = LoadTLSConfig(settings, testLogger)
+	assert.Equal(t, MissingCertificateError, err, "Missing certificate paths should return an error")
+
+	settings.CertFile = filepath.Join(dir, "cert.pem")
+	settings.KeyFile = filepath.Join(dir, "key.pem")
+	settings.ClientCAFile = filepath.Join(dir, "cert.pem")
+	config, err = LoadTLSConfig(settings, testLogger)
+	assert.NoError(t, err, "Error loading TLS config")
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth, "Client CA bundle should turn on mutual TLS")
+}
+
+func TestCallerIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-tls")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	cert := generateTestCertificate(t, dir, "meow-client")
+
+	req, _ := http.NewRequest("GET", "/v1/events/1", nil)
+	assert.Equal(t, "", CallerIdentity(req), "Anonymous requests should have no identity")
+
+	req.Header.Set(API_KEY_HEADER, "testApiKey")
+	assert.Equal(t, "testApiKey", CallerIdentity(req), "Identity should come from the API key without a client certificate")
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	assert.Equal(t, "cert:"+hex.EncodeToString(fingerprint[:]), CallerIdentity(req), "Verified client certificate should take precedence")
+
+	other := generateTestCertificate(t, dir, "meow-client")
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{other}}}
+	assert.NotEqual(t, "cert:"+hex.EncodeToString(fingerprint[:]), CallerIdentity(req), "Certificates with the same common name should be told apart")
+	assert.Equal(t, "CN=meow-client", CertificateSubject(req), "Subject of the verified certificate should be exposed")
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	assert.Equal(t, "", CertificateSubject(req), "Unverified certificates should have no subject")
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"meowtrics/model"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var ProducerStreamingError = errors.New("Producer already has an open upload stream")
+
+//Batching of UploadEventStream calls and where the committed offsets are kept
+type UploadStreamSettings struct {
+	CheckpointEvents       int
+	CheckpointIntervalInMs int
+	OffsetFile             string
+}
+
+/*
+The committed offset of every producer that streamed events, kept in OffsetFile across restarts when it is set.
+A producer can only have one open stream, so that two connections can't commit offsets out of order.
+*/
+type ProducerOffsets struct {
+	file string
+
+	mutex     sync.Mutex
+	committed map[string]uint64
+	streaming map[string]bool
+}
+
+func NewProducerOffsets(file string) (*ProducerOffsets, error) {
+	po := &ProducerOffsets{file: file, committed: make(map[string]uint64), streaming: make(map[string]bool)}
+	if file == "" {
+		return po, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &po.committed); err != nil {
+			return nil, errors.New("Invalid offset file: " + err.Error())
+		}
+	}
+	return po, nil
+}
+
+//Marks the producer as streaming and returns its committed offset, 0 for producers that never committed one
+func (po *ProducerOffsets) acquire(producerId string) (uint64, error) {
+	po.mutex.Lock()
+	defer po.mutex.Unlock()
+
+	if po.streaming[producerId] {
+		return 0, ProducerStreamingError
+	}
+	po.streaming[producerId] = true
+	return po.committed[producerId], nil
+}
+
+func (po *ProducerOffsets) release(producerId string) {
+	po.mutex.Lock()
+	delete(po.streaming, producerId)
+	po.mutex.Unlock()
+}
+
+func (po *ProducerOffsets) Committed(producerId string) uint64 {
+	po.mutex.Lock()
+	defer po.mutex.Unlock()
+	return po.committed[producerId]
+}
+
+//Records the offset of the last stored event of the producer, synced to the offset file before it is acknowledged
+func (po *ProducerOffsets) commit(producerId string, offset uint64) error {
+	po.mutex.Lock()
+	defer po.mutex.Unlock()
+
+	previous := po.committed[producerId]
+	po.committed[producerId] = offset
+	if err := po.save(); err != nil {
+		po.committed[producerId] = previous
+		return err
+	}
+	return nil
+}
+
+func (po *ProducerOffsets) save() error {
+	if po.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(po.committed, "", "    ")
+	if err != nil {
+		return err
+	}
+	tmp := po.file + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, po.file); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(po.file))
+}
+
+//The events of one UploadEventStream call received since its last checkpoint
+type uploadBatch struct {
+	producerId string
+	deviceType string
+	committed  uint64
+	last       uint64
+	events     []*model.ClientEventData
+	skipped    int32
+}
+
+//Adds the event of msg to the batch, events at or below the committed offset were stored before and are skipped
+func (b *uploadBatch) add(msg *model.UploadStreamMessage) error {
+	if msg.Event == nil {
+		return nil
+	}
+	offset := msg.GetOffset()
+	switch {
+	case offset <= b.committed:
+		b.skipped++
+		return nil
+	case offset <= b.last:
+		return status.Errorf(codes.InvalidArgument, "Offset %d is not above the previous offset %d", offset, b.last)
+	case msg.GetEvent().GetEventId() == "":
+		return status.Errorf(codes.InvalidArgument, "Event at offset %d has an invalid eventId", offset)
+	}
+	b.last = offset
+	b.events = append(b.events, msg.GetEvent())
+	return nil
+}
+
+/*
+Stores the batch like an upload request and commits its last offset before the checkpoint is sent. With async
+ingestion the events are committed once they are in the segment log of the queue, without ingestionQueueDir they
+are stored right away since a queued batch would be lost in a crash after its offset was committed. A batch that
+fails isn't committed, the producer sends its events again after resuming from the previous checkpoint.
+*/
+func (s *Server) checkpointUploadBatch(ctx context.Context, b *uploadBatch, logger *log.Entry) (*model.UploadCheckpoint, error) {
+	events := int32(len(b.events))
+	if events > 0 {
+		uploadRequest := &model.ClientEventUploadRequest{RequestId: b.producerId + ":" + strconv.FormatUint(b.last, 10), DeviceType: b.deviceType, Events: b.events}
+		if err := s.grpcEventQuota(ctx, len(b.events), logger); err != nil {
+			return nil, err
+		}
+		queue := s.ingestion
+		if queue != nil && !queue.Durable() {
+			queue = nil
+		}
+		if err, errResp := s.ingestUploadRequest(uploadRequest, logger, queue); err != nil {
+			return nil, uploadError(err, errResp)
+		}
+		if err := s.producerOffsets.commit(b.producerId, b.last); err != nil {
+			logger.WithFields(log.Fields{"method": "checkpointUploadBatch", "error": err.Error(), "producerId": b.producerId}).Errorln("Error committing offset")
+			return nil, status.Errorf(codes.Internal, "Error committing offset")
+		}
+		b.committed = b.last
+	}
+
+	checkpoint := &model.UploadCheckpoint{ProducerId: b.producerId, CommittedOffset: proto.Uint64(b.committed), Events: proto.Int32(events), Skipped: proto.Int32(b.skipped)}
+	b.events, b.skipped = nil, 0
+	return checkpoint, nil
+}
+
+/*
+Reads events until the producer closes the stream, sending a checkpoint after every CheckpointEvents events and
+every CheckpointIntervalInMs with pending events. The first checkpoint carries the offset the producer resumes after.
+*/
+func (gs *grpcService) UploadEventStream(stream model.Meowtrics_UploadEventStreamServer) error {
+	logger := gs.s.grpcLogger(stream.Context()).WithFields(log.Fields{"method": "UploadEventStream"})
+	settings := gs.s.config.UploadStream
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if first.GetProducerId() == "" || first.GetDeviceType() == "" {
+		return status.Errorf(codes.InvalidArgument, "producer_id and device_type are required in the first message")
+	}
+
+	committed, err := gs.s.producerOffsets.acquire(first.GetProducerId())
+	if err != nil {
+		return status.Errorf(codes.Aborted, "%s", err.Error())
+	}
+	defer gs.s.producerOffsets.release(first.GetProducerId())
+	logger = logger.WithFields(log.Fields{"producerId": first.GetProducerId()})
+	logger.WithFields(log.Fields{"committedOffset": committed}).Infoln("Upload stream opened")
+
+	batch := &uploadBatch{producerId: first.GetProducerId(), deviceType: first.GetDeviceType(), committed: committed, last: committed}
+	checkpoint, _ := gs.s.checkpointUploadBatch(stream.Context(), batch, logger)
+	if err := stream.Send(checkpoint); err != nil {
+		return err
+	}
+
+	messages, recvErr := make(chan *model.UploadStreamMessage), make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	flush := func() error {
+		checkpoint, err := gs.s.checkpointUploadBatch(stream.Context(), batch, logger)
+		if err != nil {
+			return err
+		}
+		return stream.Send(checkpoint)
+	}
+	if err := batch.add(first); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(time.Duration(settings.CheckpointIntervalInMs) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if len(batch.events) >= settings.CheckpointEvents {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case msg := <-messages:
+			if err := batch.add(msg); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if len(batch.events) > 0 || batch.skipped > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case err := <-recvErr:
+			//Every message was handed over before the error, so the events of a closed stream are all in the batch
+			if err != io.EOF {
+				logger.WithFields(log.Fields{"error": err.Error(), "pending": len(batch.events)}).Infoln("Upload stream broken, pending events are not committed")
+				return err
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			logger.WithFields(log.Fields{"committedOffset": batch.committed}).Infoln("Upload stream closed")
+			return nil
+		}
+	}
+}
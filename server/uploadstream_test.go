@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"meowtrics/model"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func generateTestUploadStreamMessage(offset uint64) *model.UploadStreamMessage {
+	event := generateTestClientEvent()
+	event.EventId = "streamed" + strconv.FormatUint(offset, 10)
+	return &model.UploadStreamMessage{Offset: offset, Event: event}
+}
+
+//Opens an upload stream for producerId and returns it with the checkpoint it starts from
+func openTestUploadStream(t *testing.T, ctx context.Context, client model.MeowtricsClient, producerId string) (model.Meowtrics_UploadEventStreamClient, *model.UploadCheckpoint) {
+	stream, err := client.UploadEventStream(ctx)
+	if err != nil {
+		t.Fatal("Error opening upload stream: " + err.Error())
+	}
+	if err := stream.Send(&model.UploadStreamMessage{ProducerId: producerId, DeviceType: "testDeviceServer"}); err != nil {
+		t.Fatal("Error sending first message: " + err.Error())
+	}
+	checkpoint, err := stream.Recv()
+	if err != nil {
+		t.Fatal("Error receiving first checkpoint: " + err.Error())
+	}
+	return stream, checkpoint
+}
+
+func TestUploadEventStream_ResumesWithoutDuplicates(t *testing.T) {
+	store := NewMemoryStore()
+	s := newTestServer(t, WithStore(store))
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+
+	stream, checkpoint := openTestUploadStream(t, context.Background(), client, "producer1")
+	assert.Equal(t, uint64(0), checkpoint.GetCommittedOffset(), "New producers should start from offset 0")
+	for offset := uint64(1); offset <= 3; offset++ {
+		assert.NoError(t, stream.Send(generateTestUploadStreamMessage(offset)), "Error sending event")
+	}
+	assert.NoError(t, stream.CloseSend())
+	checkpoint, err := stream.Recv()
+	assert.NoError(t, err, "Closing the stream should commit the pending events")
+	assert.Equal(t, uint64(3), checkpoint.GetCommittedOffset())
+	assert.Equal(t, int32(3), checkpoint.GetEvents())
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+
+	stream, checkpoint = openTestUploadStream(t, context.Background(), client, "producer1")
+	assert.Equal(t, uint64(3), checkpoint.GetCommittedOffset(), "Producer should resume after its committed offset")
+	for offset := uint64(2); offset <= 4; offset++ {
+		assert.NoError(t, stream.Send(generateTestUploadStreamMessage(offset)), "Error sending event")
+	}
+	assert.NoError(t, stream.CloseSend())
+	checkpoint, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), checkpoint.GetCommittedOffset())
+	assert.Equal(t, int32(1), checkpoint.GetEvents())
+	assert.Equal(t, int32(2), checkpoint.GetSkipped(), "Committed events should be skipped")
+	assert.Equal(t, 4, store.CountEvents())
+	assert.Equal(t, float64(4), s.metrics.eventsIngestedTotal.Value(model.ClientEventType_UNKNOWN.String(), otherDeviceType), "Every event should be stored once")
+}
+
+func TestUploadEventStream_Checkpoints(t *testing.T) {
+	config := DefaultConfig()
+	config.UploadStream.CheckpointEvents = 2
+	s := newTestServer(t, WithConfig(config))
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, _ := openTestUploadStream(t, ctx, client, "producer1")
+	stream.Send(generateTestUploadStreamMessage(1))
+	stream.Send(generateTestUploadStreamMessage(2))
+	checkpoint, err := stream.Recv()
+	assert.NoError(t, err, "Full batches should be checkpointed while the stream is open")
+	assert.Equal(t, uint64(2), checkpoint.GetCommittedOffset())
+
+	second, _ := client.UploadEventStream(ctx)
+	second.Send(&model.UploadStreamMessage{ProducerId: "producer1", DeviceType: "testDeviceServer"})
+	_, err = second.Recv()
+	assert.Equal(t, codes.Aborted, status.Code(err), "Producers should only have one open stream")
+
+	stream.Send(generateTestUploadStreamMessage(2))
+	stream.Send(generateTestUploadStreamMessage(1))
+	stream.Send(generateTestUploadStreamMessage(3))
+	stream.Send(generateTestUploadStreamMessage(3))
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Offsets should grow with every event")
+	assert.Equal(t, uint64(2), s.producerOffsets.Committed("producer1"), "Failed batches should not be committed")
+}
+
+func TestUploadEventStream_AsyncWithoutQueueDir(t *testing.T) {
+	store := NewMemoryStore()
+	s := newTestServer(t, WithConfig(generateAsyncTestConfig(10)), WithStore(store))
+	client, stop := newTestGRPCClient(t, s)
+	defer stop()
+
+	stream, _ := openTestUploadStream(t, context.Background(), client, "producer1")
+	for offset := uint64(1); offset <= 3; offset++ {
+		assert.NoError(t, stream.Send(generateTestUploadStreamMessage(offset)), "Error sending event")
+	}
+	assert.NoError(t, stream.CloseSend())
+	checkpoint, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), checkpoint.GetCommittedOffset())
+	assert.Equal(t, 3, store.CountEvents(), "Events should be stored before a queue that doesn't survive a crash commits them")
+}
+
+func TestProducerOffsets_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-offsets")
+	assert.NoError(t, err, "Error creating temp dir")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "offsets.json")
+
+	po, err := NewProducerOffsets(file)
+	assert.NoError(t, err, "Missing offset file should be fine")
+	committed, err := po.acquire("producer1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), committed)
+	assert.NoError(t, po.commit("producer1", 42), "Error committing offset")
+	po.release("producer1")
+
+	reopened, err := NewProducerOffsets(file)
+	assert.NoError(t, err, "Error reading offset file")
+	assert.Equal(t, uint64(42), reopened.Committed("producer1"), "Committed offsets should be kept in the offset file")
+}
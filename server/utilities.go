@@ -1,4 +1,4 @@
-package main
+package server
 
 import (
 	"errors"
@@ -21,6 +21,19 @@ const (
 	RecordNotFound           = "REQUESTED_RECORD_NOT_FOUND"
 	Fatal                    = "FATAL_OPERATION"
 	UnsupportedMedia         = "UNSUPPORTED_MEDIA_TYPE"
+	RateLimited              = "RATE_LIMITED"
+	Unauthorized             = "UNAUTHORIZED"
+	QueueFull                = "INGESTION_QUEUE_FULL"
+	Unavailable              = "SERVICE_UNAVAILABLE"
+)
+
+//Keys for request scoped values stored with gorilla/context
+type contextKey int
+
+const (
+	rateLimitKeysKey contextKey = iota
+	retryAfterKey
+	requestStateKey
 )
 
 var (
@@ -28,14 +41,17 @@ var (
 	RecordNotFoundError    = errors.New(RecordNotFound)
 	FatalError             = errors.New(Fatal)
 	UnsupportedMediaError  = errors.New(UnsupportedMedia)
+	RateLimitedError       = errors.New(RateLimited)
+	UnauthorizedError      = errors.New(Unauthorized)
+	QueueFullError         = errors.New(QueueFull)
 )
 
-func InitializeLogger(file *os.File, logFileName string, logger *log.Logger, format log.Formatter) error {
+//Points the logger at the log file until the logging config is loaded, falls back to stdout if the file can't be opened
+func InitializeLogger(logFileName string, logger *log.Logger, format log.Formatter) (*os.File, error) {
 
 	file, err := os.OpenFile(logFileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
 	if err != nil {
 		fmt.Println("Log file cannot be opened")
-		file.Close()
 		file = os.Stdout
 	}
 
@@ -44,7 +60,7 @@ func InitializeLogger(file *os.File, logFileName string, logger *log.Logger, for
 		logger.Formatter = format
 	}
 
-	return err
+	return file, err
 }
 
 //Looks for config file in default deployment directory and the injected confiPath directory
@@ -65,3 +81,16 @@ func LoadAppProperties(configName string, configPath string, logger *log.Logger)
 
 	return nil
 }
+
+//Syncs the directory so that a file created or renamed in it survives a crash
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -1,6 +1,9 @@
-package main
+package server
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	log "github.com/Sirupsen/logrus"
@@ -12,8 +15,51 @@ var (
 	testLogger     = log.New()
 	testConfigName = "meowtricsConfig"
 	testConfigPath = ""
+	testServer     *Server
+	testStore      *MemoryStore
 )
 
+//Sets up the server the way the serve command does, except that it logs to a temporary directory
+func TestMain(m *testing.M) {
+	config, err := loadConfig(nil)
+	if err != nil {
+		panic("Error loading config: " + err.Error())
+	}
+	logDir, err := ioutil.TempDir("", "meowtrics-test-log")
+	if err != nil {
+		panic("Error creating log directory: " + err.Error())
+	}
+	config.Log.FileName = filepath.Join(logDir, logFileName)
+
+	testStore = NewMemoryStore()
+	testServer, err = NewServer(WithConfig(config), WithStore(testStore))
+	if err != nil {
+		panic("Error creating server: " + err.Error())
+	}
+	code := m.Run()
+	if testServer.logFile != nil {
+		testServer.logFile.Close()
+	}
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+//Standalone server for tests that change server state, logging is discarded
+func newTestServer(t *testing.T, options ...Option) *Server {
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	s, err := NewServer(append([]Option{WithLogger(logger)}, options...)...)
+	if err != nil {
+		t.Fatal("Error creating server: " + err.Error())
+	}
+	return s
+}
+
+func resetTestStore() {
+	testStore = NewMemoryStore()
+	testServer.store = testStore
+}
+
 func TestLoadAppProperties(t *testing.T) {
 	err := LoadAppProperties(testConfigName, testConfigPath, testLogger)
 	assert.NoError(t, err, "Error loading config")
@@ -0,0 +1,605 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"meowtrics/model"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	WEBHOOK_SIGNATURE_HEADER = "X-Meowtrics-Signature"
+	WEBHOOK_DELIVERY_HEADER  = "X-Meowtrics-Delivery"
+	webhookSignaturePrefix   = "sha256="
+)
+
+var (
+	WebhookNotFoundError  = errors.New("Webhook subscription not found")
+	WebhookQueueFullError = errors.New("Webhook delivery queue is full")
+)
+
+//Delivery of stored events to webhook subscriptions
+type WebhookSettings struct {
+	File                string
+	Workers             int
+	QueueSize           int
+	TimeoutInSeconds    int
+	MaxAttempts         int
+	InitialBackoffInMs  int
+	MaxBackoffInSeconds int
+	DeadLetterLimit     int
+}
+
+//One matching event on its way to one subscription
+type webhookDelivery struct {
+	subscription *model.WebhookSubscription
+	payload      *model.WebhookEvent
+	attempts     int
+	lastErr      error
+}
+
+//Dead letters are written to the webhooks file at most this often, so a burst of them while a target is down doesn't
+//rewrite the file for every delivery
+const deadLetterSaveInterval = time.Second
+
+/*
+Posts stored events to the webhook subscriptions they match. Deliveries are made by a pool of workers, failed ones are
+retried with exponential backoff and moved to the dead letter store after MaxAttempts. Subscriptions and dead letters
+are kept in File if one is set, so they survive restarts.
+*/
+type Webhooks struct {
+	settings   WebhookSettings
+	logger     *log.Logger
+	client     *http.Client
+	deliveries *CounterVec
+
+	//Serializes writes of the webhooks file, taken before mutex
+	fileMutex sync.Mutex
+	dirty     chan struct{}
+	stop      chan struct{}
+	saved     chan struct{}
+
+	mutex         sync.RWMutex
+	subscriptions map[string]*model.WebhookSubscription
+	deadLetters   []*model.DeadLetter
+	queue         chan *webhookDelivery
+	retries       map[*webhookDelivery]*time.Timer
+	closed        bool
+	wg            sync.WaitGroup
+}
+
+//Loads the subscriptions and dead letters from settings.File and starts the delivery workers. Deliveries are counted
+//by result in deliveries.
+func NewWebhooks(settings WebhookSettings, logger *log.Logger, deliveries *CounterVec) (*Webhooks, error) {
+	wh := &Webhooks{
+		settings:      settings,
+		logger:        logger,
+		client:        &http.Client{Timeout: time.Duration(settings.TimeoutInSeconds) * time.Second},
+		deliveries:    deliveries,
+		subscriptions: make(map[string]*model.WebhookSubscription),
+		queue:         make(chan *webhookDelivery, settings.QueueSize),
+		retries:       make(map[*webhookDelivery]*time.Timer),
+		dirty:         make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		saved:         make(chan struct{}),
+	}
+
+	if settings.File != "" {
+		data, err := ioutil.ReadFile(settings.File)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			//Files written before the state was a proto message decode too, they used the same field names
+			state := new(model.WebhookState)
+			if err := model.UnmarshalJSON(data, state); err != nil {
+				return nil, errors.New("Invalid webhooks file: " + err.Error())
+			}
+			for _, subscription := range state.Subscriptions {
+				wh.subscriptions[subscription.GetId()] = subscription
+			}
+			wh.deadLetters = state.DeadLetters
+		}
+	}
+
+	wh.wg.Add(settings.Workers)
+	for i := 0; i < settings.Workers; i++ {
+		go wh.work()
+	}
+	go wh.saveDeadLetters()
+	return wh, nil
+}
+
+//Hex encoded random bytes, used for subscription and delivery ids and for generated secrets
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+//Checks the url, content type and event types of a new subscription, defaults the content type to JSON
+func validateWebhookSubscription(subscription *model.WebhookSubscription) error {
+	target, err := url.Parse(subscription.GetUrl())
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	switch subscription.GetContentType() {
+	case "":
+		subscription.ContentType = APPLICATION_JSON
+	case APPLICATION_JSON, APPLICATION_PROTOBUF:
+	default:
+		return errors.New("content_type must be " + APPLICATION_JSON + " or " + APPLICATION_PROTOBUF)
+	}
+	for _, eventType := range subscription.GetFilter().GetEventTypes() {
+		if _, ok := model.ClientEventType_name[int32(eventType)]; !ok {
+			return errors.New("Unknown event type " + strconv.Itoa(int(eventType)))
+		}
+	}
+	return nil
+}
+
+//Validates and adds a subscription. A random secret is generated unless one is given, the returned subscription is
+//the only place it can be read back from.
+func (wh *Webhooks) Subscribe(subscription *model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	if err := validateWebhookSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Id = id
+	if subscription.GetSecret() == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+	subscription.CreatedAt = time.Now().Unix()
+
+	wh.fileMutex.Lock()
+	defer wh.fileMutex.Unlock()
+	wh.mutex.Lock()
+	defer wh.mutex.Unlock()
+	wh.subscriptions[id] = subscription
+	if err := wh.save(); err != nil {
+		delete(wh.subscriptions, id)
+		return nil, err
+	}
+	return subscription, nil
+}
+
+//Removes a subscription, its queued deliveries are still attempted
+func (wh *Webhooks) Unsubscribe(id string) (*model.WebhookSubscription, error) {
+	wh.fileMutex.Lock()
+	defer wh.fileMutex.Unlock()
+	wh.mutex.Lock()
+	defer wh.mutex.Unlock()
+
+	subscription, ok := wh.subscriptions[id]
+	if !ok {
+		return nil, WebhookNotFoundError
+	}
+	delete(wh.subscriptions, id)
+	if err := wh.save(); err != nil {
+		wh.subscriptions[id] = subscription
+		return nil, err
+	}
+	return withoutSecret(subscription), nil
+}
+
+//Every subscription ordered by creation, without secrets
+func (wh *Webhooks) Subscriptions() []*model.WebhookSubscription {
+	wh.mutex.RLock()
+	defer wh.mutex.RUnlock()
+
+	subscriptions := make([]*model.WebhookSubscription, 0, len(wh.subscriptions))
+	for _, subscription := range wh.subscriptions {
+		subscriptions = append(subscriptions, withoutSecret(subscription))
+	}
+	sort.Sort(subscriptionsByCreation(subscriptions))
+	return subscriptions
+}
+
+func withoutSecret(subscription *model.WebhookSubscription) *model.WebhookSubscription {
+	copied := proto.Clone(subscription).(*model.WebhookSubscription)
+	copied.Secret = ""
+	return copied
+}
+
+type subscriptionsByCreation []*model.WebhookSubscription
+
+func (s subscriptionsByCreation) Len() int      { return len(s) }
+func (s subscriptionsByCreation) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s subscriptionsByCreation) Less(i, j int) bool {
+	if s[i].GetCreatedAt() != s[j].GetCreatedAt() {
+		return s[i].GetCreatedAt() < s[j].GetCreatedAt()
+	}
+	return s[i].GetId() < s[j].GetId()
+}
+
+func (wh *Webhooks) DeadLetters() []*model.DeadLetter {
+	wh.mutex.RLock()
+	defer wh.mutex.RUnlock()
+	return append([]*model.DeadLetter{}, wh.deadLetters...)
+}
+
+//Queues the dead letters of subscriptions that still exist for another round of attempts, the rest is dropped.
+//Returns the number of queued deliveries.
+func (wh *Webhooks) Redeliver() (int, error) {
+	wh.fileMutex.Lock()
+	wh.mutex.Lock()
+	deadLetters := wh.deadLetters
+	wh.deadLetters = nil
+	var deliveries []*webhookDelivery
+	for _, deadLetter := range deadLetters {
+		if subscription, ok := wh.subscriptions[deadLetter.GetDelivery().GetSubscriptionId()]; ok {
+			deliveries = append(deliveries, &webhookDelivery{subscription: subscription, payload: deadLetter.GetDelivery()})
+		}
+	}
+	err := wh.save()
+	wh.mutex.Unlock()
+	wh.fileMutex.Unlock()
+
+	for _, delivery := range deliveries {
+		wh.enqueue(delivery)
+	}
+	return len(deliveries), err
+}
+
+//Queues a delivery for every subscription the stored event matches, without blocking
+func (wh *Webhooks) Notify(event *model.ClientEventData, deviceType string) {
+	wh.mutex.RLock()
+	var matching []*model.WebhookSubscription
+	for _, subscription := range wh.subscriptions {
+		if eventFilterMatches(subscription.GetFilter(), event, deviceType) {
+			matching = append(matching, subscription)
+		}
+	}
+	wh.mutex.RUnlock()
+
+	for _, subscription := range matching {
+		deliveryId, err := randomHex(8)
+		if err != nil {
+			wh.logger.WithFields(log.Fields{"method": "Notify", "error": err.Error()}).Errorln("Error creating delivery id")
+			continue
+		}
+		payload := &model.WebhookEvent{DeliveryId: deliveryId, SubscriptionId: subscription.GetId(), DeviceType: deviceType, Event: event}
+		wh.enqueue(&webhookDelivery{subscription: subscription, payload: payload})
+	}
+}
+
+//Deliveries that can't be queued because the queue is full or closed go straight to the dead letter store
+func (wh *Webhooks) enqueue(delivery *webhookDelivery) {
+	wh.mutex.RLock()
+	if !wh.closed {
+		select {
+		case wh.queue <- delivery:
+			wh.mutex.RUnlock()
+			return
+		default:
+		}
+	}
+	wh.mutex.RUnlock()
+
+	if delivery.lastErr == nil {
+		delivery.lastErr = WebhookQueueFullError
+	}
+	wh.deadLetter(delivery)
+}
+
+//Deliveries waiting for a worker
+func (wh *Webhooks) Depth() int {
+	return len(wh.queue)
+}
+
+//Stops taking deliveries, waits for the queued ones to get one last attempt and dead letters the ones waiting for a retry
+func (wh *Webhooks) Close() error {
+	wh.mutex.Lock()
+	if wh.closed {
+		wh.mutex.Unlock()
+		return nil
+	}
+	wh.closed = true
+	close(wh.queue)
+	wh.mutex.Unlock()
+
+	wh.wg.Wait()
+
+	wh.mutex.Lock()
+	var waiting []*webhookDelivery
+	for delivery, timer := range wh.retries {
+		//Timers that already fired find the queue closed and dead letter their delivery themselves
+		if timer.Stop() {
+			waiting = append(waiting, delivery)
+		}
+	}
+	wh.retries = nil
+	wh.mutex.Unlock()
+
+	for _, delivery := range waiting {
+		wh.deadLetter(delivery)
+	}
+	close(wh.stop)
+	<-wh.saved
+	return nil
+}
+
+func (wh *Webhooks) work() {
+	defer wh.wg.Done()
+	for delivery := range wh.queue {
+		wh.deliver(delivery)
+	}
+}
+
+func (wh *Webhooks) deliver(delivery *webhookDelivery) {
+	delivery.attempts++
+	logger := wh.logger.WithFields(log.Fields{"method": "deliver", "subscriptionId": delivery.subscription.GetId(), "deliveryId": delivery.payload.GetDeliveryId(), "attempt": delivery.attempts})
+
+	err := wh.post(delivery)
+	if err == nil {
+		wh.deliveries.Inc("delivered")
+		logger.Debugln("Webhook delivered")
+		return
+	}
+	delivery.lastErr = err
+
+	wh.mutex.Lock()
+	if wh.closed || delivery.attempts >= wh.settings.MaxAttempts {
+		wh.mutex.Unlock()
+		wh.deadLetter(delivery)
+		return
+	}
+	backoff := wh.backoff(delivery.attempts)
+	wh.retries[delivery] = time.AfterFunc(backoff, func() {
+		wh.mutex.Lock()
+		delete(wh.retries, delivery)
+		wh.mutex.Unlock()
+		wh.enqueue(delivery)
+	})
+	wh.mutex.Unlock()
+
+	wh.deliveries.Inc("retried")
+	logger.WithFields(log.Fields{"error": err.Error(), "backoff": backoff.String()}).Warningln("Webhook delivery failed, retrying")
+}
+
+//InitialBackoffInMs doubled for every failed attempt, up to MaxBackoffInSeconds
+func (wh *Webhooks) backoff(attempts int) time.Duration {
+	backoff := time.Duration(wh.settings.InitialBackoffInMs) * time.Millisecond
+	max := time.Duration(wh.settings.MaxBackoffInSeconds) * time.Second
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+func (wh *Webhooks) post(delivery *webhookDelivery) error {
+	var body []byte
+	var err error
+	contentType := delivery.subscription.GetContentType()
+	if contentType == APPLICATION_PROTOBUF {
+		body, err = proto.Marshal(delivery.payload)
+	} else {
+		body, err = model.MarshalJSON(delivery.payload)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", delivery.subscription.GetUrl(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(WEBHOOK_DELIVERY_HEADER, delivery.payload.GetDeliveryId())
+	req.Header.Set(WEBHOOK_SIGNATURE_HEADER, webhookSignature(delivery.subscription.GetSecret(), body))
+
+	res, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.New("Webhook responded " + res.Status)
+	}
+	return nil
+}
+
+//HMAC-SHA256 of the request body with the subscription secret, hex encoded after "sha256="
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+//Keeps the newest DeadLetterLimit dead letters, the webhooks file is written by saveDeadLetters
+func (wh *Webhooks) deadLetter(delivery *webhookDelivery) {
+	deadLetter := &model.DeadLetter{Delivery: delivery.payload, Url: delivery.subscription.GetUrl(), Attempts: int32(delivery.attempts), FailedAt: time.Now().Unix()}
+	if delivery.lastErr != nil {
+		deadLetter.LastError = delivery.lastErr.Error()
+	}
+	wh.deliveries.Inc("dead_lettered")
+	wh.logger.WithFields(log.Fields{"method": "deadLetter", "subscriptionId": delivery.subscription.GetId(), "deliveryId": delivery.payload.GetDeliveryId(), "error": deadLetter.GetLastError()}).Errorln("Webhook delivery moved to dead letters")
+
+	wh.mutex.Lock()
+	wh.deadLetters = append(wh.deadLetters, deadLetter)
+	if over := len(wh.deadLetters) - wh.settings.DeadLetterLimit; over > 0 {
+		wh.deadLetters = wh.deadLetters[over:]
+	}
+	wh.mutex.Unlock()
+
+	select {
+	case wh.dirty <- struct{}{}:
+	default:
+	}
+}
+
+//Writes the webhooks file when dead letters were added, then waits deadLetterSaveInterval before the next write.
+//Writes once more when Close stops it.
+func (wh *Webhooks) saveDeadLetters() {
+	defer close(wh.saved)
+	for {
+		select {
+		case <-wh.dirty:
+		case <-wh.stop:
+			wh.flush()
+			return
+		}
+		wh.flush()
+
+		select {
+		case <-time.After(deadLetterSaveInterval):
+		case <-wh.stop:
+			wh.flush()
+			return
+		}
+	}
+}
+
+//Writes the webhooks file without holding wh.mutex while it is written
+func (wh *Webhooks) flush() {
+	if wh.settings.File == "" {
+		return
+	}
+	wh.fileMutex.Lock()
+	defer wh.fileMutex.Unlock()
+
+	wh.mutex.RLock()
+	state := wh.state()
+	wh.mutex.RUnlock()
+	if err := wh.write(state); err != nil {
+		wh.logger.WithFields(log.Fields{"method": "flush", "error": err.Error()}).Errorln("Error saving webhooks file")
+	}
+}
+
+//Copy of the subscriptions and dead letters, callers hold wh.mutex
+func (wh *Webhooks) state() *model.WebhookState {
+	state := &model.WebhookState{DeadLetters: append([]*model.DeadLetter{}, wh.deadLetters...)}
+	for _, subscription := range wh.subscriptions {
+		state.Subscriptions = append(state.Subscriptions, subscription)
+	}
+	sort.Sort(subscriptionsByCreation(state.Subscriptions))
+	return state
+}
+
+//Writes the subscriptions and dead letters to the webhooks file, callers hold wh.fileMutex and wh.mutex
+func (wh *Webhooks) save() error {
+	return wh.write(wh.state())
+}
+
+//Callers hold wh.fileMutex
+func (wh *Webhooks) write(state *model.WebhookState) error {
+	if wh.settings.File == "" {
+		return nil
+	}
+	data, err := model.MarshalJSON(state)
+	if err != nil {
+		return err
+	}
+	tmp := wh.settings.File + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, wh.settings.File)
+}
+
+func (s *Server) webhookQueueDepth() int {
+	if s.webhooks == nil {
+		return 0
+	}
+	return s.webhooks.Depth()
+}
+
+//Lists the webhook subscriptions, secrets are left out
+func (s *Server) ListWebhooksHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, &model.WebhookSubscriptionList{Subscriptions: s.webhooks.Subscriptions()})
+	})
+}
+
+//Adds a subscription from a JSON WebhookSubscription, the response is the only one carrying its secret
+func (s *Server) CreateWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		subscription := new(model.WebhookSubscription)
+		if err := s.decodeJSONBody(req, subscription); err != nil {
+			writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: MalformedRequest, ErrorMessage: "Request body contains malformed JSON", Description: err.Error()})
+			return
+		}
+		if err := validateWebhookSubscription(subscription); err != nil {
+			writeJSON(w, http.StatusBadRequest, &model.ErrorResponse{Code: InvalidRequestParameters, ErrorMessage: "Invalid webhook subscription", Description: err.Error()})
+			return
+		}
+
+		subscription, err := s.webhooks.Subscribe(subscription)
+		if err != nil {
+			s.RequestLogger(req).WithFields(log.Fields{"method": "CreateWebhookHandler", "error": err.Error()}).Errorln("Error adding webhook subscription")
+
+			writeJSON(w, http.StatusInternalServerError, &model.ErrorResponse{Code: Fatal, ErrorMessage: "Error adding webhook subscription"})
+			return
+		}
+		s.RequestLogger(req).WithFields(log.Fields{"method": "CreateWebhookHandler", "subscriptionId": subscription.GetId(), "url": subscription.GetUrl()}).Infoln("Added webhook subscription")
+		writeJSON(w, http.StatusCreated, subscription)
+	})
+}
+
+func (s *Server) DeleteWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := mux.Vars(req)["id"]
+		subscription, err := s.webhooks.Unsubscribe(id)
+		switch err {
+		case nil:
+		case WebhookNotFoundError:
+			writeJSON(w, http.StatusNotFound, &model.ErrorResponse{Code: RecordNotFound, ErrorMessage: WebhookNotFoundError.Error()})
+			return
+		default:
+			s.RequestLogger(req).WithFields(log.Fields{"method": "DeleteWebhookHandler", "subscriptionId": id, "error": err.Error()}).Errorln("Error removing webhook subscription")
+
+			writeJSON(w, http.StatusInternalServerError, &model.ErrorResponse{Code: Fatal, ErrorMessage: "Error removing webhook subscription"})
+			return
+		}
+		s.RequestLogger(req).WithFields(log.Fields{"method": "DeleteWebhookHandler", "subscriptionId": id}).Infoln("Removed webhook subscription")
+		writeJSON(w, http.StatusOK, subscription)
+	})
+}
+
+func (s *Server) DeadLettersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, &model.DeadLetterList{DeadLetters: s.webhooks.DeadLetters()})
+	})
+}
+
+//Queues every dead letter whose subscription still exists for another round of attempts
+func (s *Server) RedeliverHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		queued, err := s.webhooks.Redeliver()
+		if err != nil {
+			s.RequestLogger(req).WithFields(log.Fields{"method": "RedeliverHandler", "error": err.Error()}).Errorln("Error saving webhooks file")
+		}
+		s.RequestLogger(req).WithFields(log.Fields{"method": "RedeliverHandler", "deliveries": queued}).Infoln("Redelivering dead letters")
+
+		writeJSON(w, http.StatusOK, &model.EventCount{Count: proto.Int64(int64(queued))})
+	})
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"io/ioutil"
+	"meowtrics/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func generateTestWebhookSettings() WebhookSettings {
+	return WebhookSettings{Workers: 1, QueueSize: 10, TimeoutInSeconds: 1, MaxAttempts: 2, InitialBackoffInMs: 1, MaxBackoffInSeconds: 1, DeadLetterLimit: 10}
+}
+
+func newTestWebhooks(t *testing.T, settings WebhookSettings) *Webhooks {
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	wh, err := NewWebhooks(settings, logger, NewCounterVec("test_webhook_deliveries_total", "", "result"))
+	if err != nil {
+		t.Fatal("Error creating webhooks: " + err.Error())
+	}
+	return wh
+}
+
+func TestEventFilterMatches(t *testing.T) {
+	event := generateTestClientEvent()
+	event.KvPair = []*model.KeyValuePair{{Key: "country", Value: proto.String("NZ")}}
+
+	assert.True(t, eventFilterMatches(nil, event, "android"), "Nil filter should match every event")
+	assert.True(t, eventFilterMatches(&model.EventFilter{EventTypes: []model.ClientEventType{model.ClientEventType_UNKNOWN}, DeviceTypes: []string{"ios", "android"}}, event, "android"))
+	assert.False(t, eventFilterMatches(&model.EventFilter{EventTypes: []model.ClientEventType{model.ClientEventType_USER_REGISTERED}}, event, "android"), "Other event types should not match")
+	assert.False(t, eventFilterMatches(&model.EventFilter{DeviceTypes: []string{"ios"}}, event, "android"), "Other device types should not match")
+
+	assert.True(t, eventFilterMatches(&model.EventFilter{KvPairs: []*model.KeyValuePair{{Key: "country", Value: proto.String("NZ")}}}, event, "android"))
+	assert.False(t, eventFilterMatches(&model.EventFilter{KvPairs: []*model.KeyValuePair{{Key: "country", Value: proto.String("AU")}}}, event, "android"), "Other kv pair values should not match")
+}
+
+func TestEventFilterMatches_TypedValues(t *testing.T) {
+	event := generateTestClientEvent()
+	event.KvPair = []*model.KeyValuePair{
+		{Key: "duration", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 250}}},
+		{Key: "premium", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_BoolValue{BoolValue: true}}},
+		{Key: "retries", Value: proto.String("2")},
+	}
+	condition := func(key string, comparison model.Comparison, value string) *model.EventFilter {
+		return &model.EventFilter{KvConditions: []*model.KeyValueCondition{{Key: key, Comparison: comparison, Value: stringAttribute(value)}}}
+	}
+
+	assert.True(t, eventFilterMatches(&model.EventFilter{KvPairs: []*model.KeyValuePair{{Key: "duration", Value: proto.String("250")}}}, event, "android"), "Typed values should equal their string form")
+	assert.True(t, eventFilterMatches(&model.EventFilter{KvPairs: []*model.KeyValuePair{{Key: "premium", Value: proto.String("true")}}}, event, "android"))
+
+	assert.True(t, eventFilterMatches(condition("duration", model.Comparison_COMPARISON_GREATER, "100"), event, "android"), "Numbers should be compared numerically")
+	assert.False(t, eventFilterMatches(condition("duration", model.Comparison_COMPARISON_LESS, "99.5"), event, "android"))
+	assert.False(t, eventFilterMatches(condition("retries", model.Comparison_COMPARISON_LESS_OR_EQUAL, "10"), event, "android"), "Two strings should not be ordered")
+	retries := &model.EventFilter{KvConditions: []*model.KeyValueCondition{{Key: "retries", Comparison: model.Comparison_COMPARISON_LESS_OR_EQUAL,
+		Value: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 10}}}}}
+	assert.True(t, eventFilterMatches(retries, event, "android"), "Stringified numbers should be compared with typed numbers")
+	assert.True(t, eventFilterMatches(condition("premium", model.Comparison_COMPARISON_NOT_EQUAL, "false"), event, "android"))
+	assert.False(t, eventFilterMatches(condition("premium", model.Comparison_COMPARISON_GREATER, "0"), event, "android"), "Booleans can't be ordered")
+	assert.False(t, eventFilterMatches(condition("country", model.Comparison_COMPARISON_NOT_EQUAL, "NZ"), event, "android"), "Events without the key should not match")
+}
+
+func TestWebhooks_DeliversSignedEvents(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received <- req
+		bodies <- body
+	}))
+	defer target.Close()
+
+	wh := newTestWebhooks(t, generateTestWebhookSettings())
+	defer wh.Close()
+	subscription, err := wh.Subscribe(&model.WebhookSubscription{Url: target.URL, ContentType: APPLICATION_PROTOBUF})
+	assert.NoError(t, err, "Error subscribing")
+	assert.NotEmpty(t, subscription.GetSecret(), "Secret should be generated")
+	assert.Empty(t, wh.Subscriptions()[0].GetSecret(), "Listed subscriptions should not carry secrets")
+
+	event := generateTestClientEvent()
+	wh.Notify(event, "testDeviceAndroid")
+
+	select {
+	case req := <-received:
+		body := <-bodies
+		assert.Equal(t, APPLICATION_PROTOBUF, req.Header.Get("Content-Type"))
+		assert.Equal(t, webhookSignature(subscription.GetSecret(), body), req.Header.Get(WEBHOOK_SIGNATURE_HEADER), "Body should be signed with the secret")
+
+		payload := new(model.WebhookEvent)
+		assert.NoError(t, proto.Unmarshal(body, payload), "Error unmarshalling delivery")
+		assert.Equal(t, subscription.GetId(), payload.GetSubscriptionId())
+		assert.Equal(t, payload.GetDeliveryId(), req.Header.Get(WEBHOOK_DELIVERY_HEADER))
+		assert.Equal(t, "testDeviceAndroid", payload.GetDeviceType())
+		assert.Equal(t, event.GetEventId(), payload.GetEvent().GetEventId())
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+}
+
+func TestWebhooks_DeadLettersFailedDeliveries(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attempts <- struct{}{}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer target.Close()
+
+	dir, err := ioutil.TempDir("", "meowtrics-webhooks")
+	assert.NoError(t, err, "Error creating temp dir")
+	defer os.RemoveAll(dir)
+	settings := generateTestWebhookSettings()
+	settings.File = filepath.Join(dir, "webhooks.json")
+
+	wh := newTestWebhooks(t, settings)
+	_, err = wh.Subscribe(&model.WebhookSubscription{Url: target.URL})
+	assert.NoError(t, err, "Error subscribing")
+
+	event := generateTestClientEvent()
+	wh.Notify(event, "testDeviceAndroid")
+	for i := 0; i < settings.MaxAttempts; i++ {
+		select {
+		case <-attempts:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Delivery was not retried")
+		}
+	}
+	assert.NoError(t, wh.Close(), "Error closing webhooks")
+
+	deadLetters := wh.DeadLetters()
+	if assert.Equal(t, 1, len(deadLetters), "Failed delivery should be dead lettered") {
+		assert.Equal(t, int32(settings.MaxAttempts), deadLetters[0].GetAttempts())
+		assert.Contains(t, deadLetters[0].GetLastError(), "500")
+	}
+	assert.Equal(t, float64(1), wh.deliveries.Value("retried"))
+	assert.Equal(t, float64(1), wh.deliveries.Value("dead_lettered"))
+
+	reopened := newTestWebhooks(t, settings)
+	defer reopened.Close()
+	assert.Equal(t, 1, len(reopened.Subscriptions()), "Subscriptions should be kept in the webhooks file")
+	assert.Equal(t, 1, len(reopened.DeadLetters()), "Dead letters should be kept in the webhooks file")
+}
+
+func TestWebhooks_DeadLettersWithoutWaitingForTheFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-webhooks")
+	assert.NoError(t, err, "Error creating temp dir")
+	defer os.RemoveAll(dir)
+	settings := generateTestWebhookSettings()
+	settings.File = filepath.Join(dir, "webhooks.json")
+	settings.Workers, settings.QueueSize = 0, 0
+
+	wh := newTestWebhooks(t, settings)
+	_, err = wh.Subscribe(&model.WebhookSubscription{Url: "http://127.0.0.1:1/hook"})
+	assert.NoError(t, err, "Error subscribing")
+
+	//A slow write of the webhooks file must not hold up Notify
+	wh.fileMutex.Lock()
+	notified := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			wh.Notify(generateTestClientEvent(), "testDeviceAndroid")
+		}
+		close(notified)
+	}()
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notify waited for the webhooks file")
+	}
+	wh.fileMutex.Unlock()
+	assert.Equal(t, 5, len(wh.DeadLetters()), "Deliveries that don't fit the queue should be dead lettered")
+	assert.NoError(t, wh.Close(), "Error closing webhooks")
+
+	reopened := newTestWebhooks(t, settings)
+	defer reopened.Close()
+	assert.Equal(t, 5, len(reopened.DeadLetters()), "Dead letters should be written by Close")
+}
+
+func TestWebhooks_FileKeepsTypedValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-webhooks")
+	assert.NoError(t, err, "Error creating temp dir")
+	defer os.RemoveAll(dir)
+	settings := generateTestWebhookSettings()
+	settings.File = filepath.Join(dir, "webhooks.json")
+
+	wh := newTestWebhooks(t, settings)
+	filter := &model.EventFilter{KvConditions: []*model.KeyValueCondition{{Key: "retries", Comparison: model.Comparison_COMPARISON_GREATER,
+		Value: &model.AttributeValue{Kind: &model.AttributeValue_IntValue{IntValue: 5}}}}}
+	_, err = wh.Subscribe(&model.WebhookSubscription{Url: "http://localhost/hook", Filter: filter})
+	assert.NoError(t, err, "Error subscribing")
+	event := generateTestClientEvent()
+	event.KvPair = append(event.KvPair, &model.KeyValuePair{Key: "retries", TypedValue: &model.AttributeValue{Kind: &model.AttributeValue_DoubleValue{DoubleValue: 1.5}}})
+	wh.mutex.Lock()
+	wh.deadLetters = append(wh.deadLetters, &model.DeadLetter{Delivery: &model.WebhookEvent{Event: event}, Attempts: 2})
+	assert.NoError(t, wh.save(), "Error saving webhooks file")
+	wh.mutex.Unlock()
+	assert.NoError(t, wh.Close())
+
+	reopened := newTestWebhooks(t, settings)
+	defer reopened.Close()
+	if assert.Equal(t, 1, len(reopened.Subscriptions())) {
+		assert.True(t, proto.Equal(filter, reopened.Subscriptions()[0].GetFilter()), "Typed filter values should survive a restart")
+	}
+	if assert.Equal(t, 1, len(reopened.DeadLetters())) {
+		assert.True(t, proto.Equal(event, reopened.DeadLetters()[0].GetDelivery().GetEvent()), "Typed kv pairs of dead letters should survive a restart")
+	}
+}
+
+func TestWebhooks_ReadsOldFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meowtrics-webhooks")
+	assert.NoError(t, err, "Error creating temp dir")
+	defer os.RemoveAll(dir)
+	settings := generateTestWebhookSettings()
+	settings.File = filepath.Join(dir, "webhooks.json")
+	//Written by encoding/json before the file held a WebhookState: enums as numbers and int64 values as JSON numbers
+	old := `{"subscriptions":[{"id":"ab12","url":"http://localhost/hook","secret":"s","content_type":"application/json",
+		"filter":{"event_types":[2]},"created_at":1422406800}],
+		"dead_letters":[{"delivery":{"delivery_id":"cd34","event":{"event_id":"1","event_type":2,"timestamp":1422406800}},"attempts":5}]}`
+	assert.NoError(t, ioutil.WriteFile(settings.File, []byte(old), 0600))
+
+	wh := newTestWebhooks(t, settings)
+	defer wh.Close()
+	if assert.Equal(t, 1, len(wh.Subscriptions())) {
+		assert.Equal(t, model.ClientEventType_USER_REGISTERED, wh.Subscriptions()[0].GetFilter().GetEventTypes()[0])
+		assert.Equal(t, int64(1422406800), wh.Subscriptions()[0].GetCreatedAt())
+	}
+	if assert.Equal(t, 1, len(wh.DeadLetters())) {
+		assert.Equal(t, int64(1422406800), wh.DeadLetters()[0].GetDelivery().GetEvent().GetTimestamp())
+	}
+}
+
+func TestWebhooks_Backoff(t *testing.T) {
+	wh := &Webhooks{settings: WebhookSettings{InitialBackoffInMs: 500, MaxBackoffInSeconds: 3}}
+	assert.Equal(t, 500*time.Millisecond, wh.backoff(1))
+	assert.Equal(t, 2*time.Second, wh.backoff(3), "Backoff should double for every attempt")
+	assert.Equal(t, 3*time.Second, wh.backoff(10), "Backoff should be capped")
+}
+
+func TestWebhookAdminHandlers(t *testing.T) {
+	s := newTestServer(t)
+	s.adminApiKey.set("testAdminKey")
+	adminRequest := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(API_KEY_HEADER, "testAdminKey")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := adminRequest("POST", "/admin/webhooks", `{"url": "ftp://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Non HTTP urls should be rejected")
+
+	w = adminRequest("POST", "/admin/webhooks", `{"url": "https://example.com/hook", "filter": {"event_types": [1]}}`)
+	assert.Equal(t, http.StatusCreated, w.Code, "Subscription should be created")
+	subscription := new(model.WebhookSubscription)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), subscription), "Error unmarshalling subscription")
+	assert.NotEmpty(t, subscription.GetSecret(), "Created subscription should carry its secret")
+	assert.Equal(t, APPLICATION_JSON, subscription.GetContentType(), "Content type should default to JSON")
+
+	w = adminRequest("GET", "/admin/webhooks", "")
+	list := new(model.WebhookSubscriptionList)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), list), "Error unmarshalling subscriptions")
+	if assert.Equal(t, 1, len(list.GetSubscriptions())) {
+		assert.Equal(t, subscription.GetId(), list.GetSubscriptions()[0].GetId())
+		assert.Empty(t, list.GetSubscriptions()[0].GetSecret(), "Listed subscriptions should not carry secrets")
+	}
+
+	w = adminRequest("DELETE", "/admin/webhooks/"+subscription.GetId(), "")
+	assert.Equal(t, http.StatusOK, w.Code, "Subscription should be removed")
+	w = adminRequest("DELETE", "/admin/webhooks/"+subscription.GetId(), "")
+	assert.Equal(t, http.StatusNotFound, w.Code, "Removed subscription should not be found")
+
+	w = adminRequest("GET", "/admin/webhooks/deadletters", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = adminRequest("POST", "/admin/webhooks/deadletters/redeliver", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	count := new(model.EventCount)
+	assert.NoError(t, model.UnmarshalJSON(w.Body.Bytes(), count))
+	if assert.NotNil(t, count.Count, "Zero counts should be sent") {
+		assert.Equal(t, int64(0), count.GetCount())
+	}
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//The parts of RFC 6455 the event stream needs: the server sends text frames and pings, clients are only read for
+//pings, pongs and the close frame
+const (
+	webSocketGUID            = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	webSocketOpText          = 0x1
+	webSocketOpClose         = 0x8
+	webSocketOpPing          = 0x9
+	webSocketOpPong          = 0xA
+	webSocketGoingAway       = 1001
+	webSocketMaxClientFrame  = 1 << 16
+	webSocketMaxCloseMessage = 123
+)
+
+var WebSocketFrameTooLargeError = errors.New("WebSocket frame too large")
+
+func checkWebSocketHandshake(req *http.Request) error {
+	if req.Method != "GET" {
+		return errors.New("WebSocket handshakes must be GET requests")
+	}
+	if !headerContainsToken(req.Header, "Connection", "upgrade") {
+		return errors.New("Connection header must contain Upgrade")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		return errors.New("Sec-WebSocket-Version must be 13")
+	}
+	if req.Header.Get("Sec-WebSocket-Key") == "" {
+		return errors.New("Sec-WebSocket-Key is missing")
+	}
+	return nil
+}
+
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header[name] {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func webSocketAccept(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+webSocketGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+//A server side WebSocket connection streaming events, see upgradeWebSocket
+type webSocketConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	done chan struct{}
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+//Takes over the connection of a request that passed checkWebSocketHandshake and answers the handshake
+func upgradeWebSocket(w http.ResponseWriter, req *http.Request) (*webSocketConn, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("Response writer can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + webSocketAccept(req.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &webSocketConn{conn: conn, rw: rw, done: make(chan struct{})}
+	go ws.read()
+	return ws, nil
+}
+
+//Answers pings until the client closes the connection or sends something it shouldn't
+func (ws *webSocketConn) read() {
+	defer close(ws.done)
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case webSocketOpPing:
+			if ws.writeFrame(webSocketOpPong, payload) != nil {
+				return
+			}
+		case webSocketOpClose:
+			return
+		}
+	}
+}
+
+//Client frames are always masked, fragments are read like whole frames since their payload is discarded anyway
+func (ws *webSocketConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.rw, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.rw, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.rw, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	var mask [4]byte
+	if header[1]&0x80 != 0 {
+		if _, err := io.ReadFull(ws.rw, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	if length > webSocketMaxClientFrame {
+		return 0, nil, WebSocketFrameTooLargeError
+	}
+	if opcode != webSocketOpPing {
+		_, err := io.CopyN(ioutil.Discard, ws.rw, int64(length))
+		return opcode, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+//Server frames are sent unmasked and unfragmented
+func (ws *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	if ws.closed {
+		return io.ErrClosedPipe
+	}
+	ws.rw.WriteByte(0x80 | opcode)
+	switch length := len(payload); {
+	case length < 126:
+		ws.rw.WriteByte(byte(length))
+	case length <= 0xFFFF:
+		ws.rw.WriteByte(126)
+		binary.Write(ws.rw, binary.BigEndian, uint16(length))
+	default:
+		ws.rw.WriteByte(127)
+		binary.Write(ws.rw, binary.BigEndian, uint64(length))
+	}
+	ws.rw.Write(payload)
+	return ws.rw.Flush()
+}
+
+func (ws *webSocketConn) Send(id uint64, name string, data []byte) error {
+	return ws.writeFrame(webSocketOpText, data)
+}
+
+func (ws *webSocketConn) KeepAlive() error {
+	return ws.writeFrame(webSocketOpPing, nil)
+}
+
+func (ws *webSocketConn) Fail(message string) {
+	if len(message) > webSocketMaxCloseMessage {
+		message = message[:webSocketMaxCloseMessage]
+	}
+	payload := make([]byte, 2, 2+len(message))
+	binary.BigEndian.PutUint16(payload, webSocketGoingAway)
+	ws.writeFrame(webSocketOpClose, append(payload, message...))
+}
+
+func (ws *webSocketConn) Done() <-chan struct{} {
+	return ws.done
+}
+
+func (ws *webSocketConn) Close() error {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	if ws.closed {
+		return nil
+	}
+	ws.closed = true
+	return ws.conn.Close()
+}